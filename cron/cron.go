@@ -10,9 +10,12 @@ package cron
 
 import (
 	"errors"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"pkg.re/essentialkaos/ek.v9/mathutil"
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -25,6 +28,7 @@ const (
 	WEEKLY   = "0 0 * * 0"
 	DAILY    = "0 0 * * *"
 	HOURLY   = "0 * * * *"
+	REBOOT   = "@reboot"
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -34,6 +38,10 @@ const (
 	_SYMBOL_INTERVAL = "/"
 	_SYMBOL_ENUM     = ","
 	_SYMBOL_ANY      = "*"
+	_SYMBOL_NO_VALUE = "?"
+	_SYMBOL_LAST     = "L"
+	_SYMBOL_WEEKDAY  = "W"
+	_SYMBOL_NTH      = "#"
 )
 
 const (
@@ -42,21 +50,61 @@ const (
 	_NAMES_MONTHS uint8 = 2
 )
 
+const (
+	_RULE_LAST_DAY        uint8 = 1 // L or L-N in days of month
+	_RULE_LAST_WEEKDAY    uint8 = 2 // LW in days of month
+	_RULE_NEAREST_WEEKDAY uint8 = 3 // NW in days of month
+	_RULE_LAST_DOW        uint8 = 4 // NL in days of week
+	_RULE_NTH_DOW         uint8 = 5 // N#M in days of week
+)
+
+const (
+	_FIELD_DOMS = 2
+	_FIELD_DOWS = 4
+)
+
+// _SEARCH_YEARS is search horizon for Next and Prev, Gregorian calendar repeats
+// every 400 years, so if there is no matching moment within this period,
+// expression never matches
+const _SEARCH_YEARS = 400
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 // Expr cron expression struct
 type Expr struct {
 	expression string
+	tokens     []string  // raw fields of expression
+	seconds    *exprPart // nil if expression doesn't contain seconds field
 	minutes    *exprPart
 	hours      *exprPart
 	doms       *exprPart
 	months     *exprPart
 	dows       *exprPart
+	every      time.Duration  // interval for @every expressions
+	reboot     bool           // true for @reboot expression
+	location   *time.Location // expression time zone (nil if not set)
+	fixedTime  bool           // true if minutes and hours fields don't contain wildcards
 }
 
 type exprPart struct {
 	index  map[uint8]bool
 	tokens []uint8
+	rules  []dayRule // special rules for days of month and days of week
+}
+
+type wallClock struct {
+	year   int
+	month  int
+	day    int
+	hour   int
+	minute int
+	second int
+}
+
+type dayRule struct {
+	kind uint8
+	day  uint8 // day of month, day of week or offset from last day
+	num  uint8 // number of day of week in month
 }
 
 type exprInfo struct {
@@ -67,7 +115,14 @@ type exprInfo struct {
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
-var ErrMalformedExpression = errors.New("Expression must have 5 tokens")
+// Errors
+var (
+	ErrMalformedExpression = errors.New("Expression must have 5 or 6 tokens")
+	ErrMalformedInterval   = errors.New("Interval must be valid duration greater than or equal to 1 second")
+	ErrMalformedRule       = errors.New("Expression contains malformed special token")
+	ErrMalformedField      = errors.New("Expression contains malformed or out of range value")
+	ErrNilLocation         = errors.New("Location is nil")
+)
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
@@ -76,51 +131,135 @@ var info = []exprInfo{
 	{0, 23, _NAMES_NONE},
 	{1, 31, _NAMES_NONE},
 	{1, 12, _NAMES_MONTHS},
-	{0, 6, _NAMES_DAYS},
+	{0, 7, _NAMES_DAYS},
 }
 
+var secondsInfo = exprInfo{0, 59, _NAMES_NONE}
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
-// Parse parse cron expression
+// Parse parse cron expression. Besides standard syntax, parser supports optional
+// seconds field (first of 6 fields), "?" (any value), "L" (last day of month or
+// "5L" for last friday of month), "L-3" (third day before last day of month),
+// "W" (nearest weekday, e.g. "15W" or "LW"), "#" (nth day of week, e.g. "5#3"),
+// "@every <duration>" and "@reboot". Time zone of expression can be set using
+// "CRON_TZ=" or "TZ=" prefix (e.g. "CRON_TZ=Europe/Berlin 0 9 * * *").
+//
+// Expressions are evaluated using wall clock time of their time zone. If the time
+// doesn't exist due to DST transition (clock moves forward), job with fixed time
+// (without wildcards in minutes and hours fields) is run right after transition,
+// other jobs are skipped. If the time occurs twice (clock moves backward), job
+// with fixed time is run only once (at first occurrence), other jobs are run
+// twice.
 func Parse(expr string) (*Expr, error) {
 	result := &Expr{expression: expr}
 
-	expr = strings.Replace(expr, "\t", " ", -1)
+	expr = strings.TrimSpace(strings.Replace(expr, "\t", " ", -1))
+
+	if strings.HasPrefix(expr, "CRON_TZ=") || strings.HasPrefix(expr, "TZ=") {
+		var tz string
+		var err error
+
+		tz, expr = splitTimeZone(expr)
+		result.location, err = time.LoadLocation(tz)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	expr = getAliasExpression(expr)
 
-	exprAr := strings.Split(expr, " ")
+	switch {
+	case expr == REBOOT:
+		result.reboot = true
+		return result, nil
+	case strings.HasPrefix(expr, "@every "):
+		every, err := time.ParseDuration(strings.TrimSpace(expr[7:]))
+
+		if err != nil || every < time.Second {
+			return nil, ErrMalformedInterval
+		}
+
+		result.every = every
+
+		return result, nil
+	}
+
+	exprAr := strings.Fields(expr)
+	result.tokens = exprAr
+
+	switch len(exprAr) {
+	case 5:
+		// ok
+	case 6:
+		var err error
+
+		result.seconds, err = parseField(exprAr[0], secondsInfo)
+
+		if err != nil {
+			return nil, err
+		}
 
-	if len(exprAr) != 5 {
+		exprAr = exprAr[1:]
+	default:
 		return nil, ErrMalformedExpression
 	}
 
-	data := make([][]uint8, 5)
+	parts := make([]*exprPart, 5)
 
 	for tn, ei := range info {
 		token := exprAr[tn]
 
-		switch {
-		case isAnyToken(token):
-			data[tn] = fillUintSlice(ei.min, ei.max, 1)
-		case isEnumToken(token):
-			data[tn] = getEnumFromToken(token, ei.nt)
-		case isPeriodToken(token):
-			ts, te := getPeriodFromToken(token, ei.nt)
-			ts = between(ts, ei.min, ei.max)
-			te = between(te, ei.min, ei.max)
-			data[tn] = fillUintSlice(ts, te, 1)
-		case isIntervalToken(token):
-			data[tn] = fillUintSlice(ei.min, ei.max, getIntervalFromToken(token))
-		default:
-			data[tn] = []uint8{parseToken(token, ei.nt)}
+		var rules []dayRule
+
+		if tn == _FIELD_DOMS || tn == _FIELD_DOWS {
+			var err error
+
+			token, rules, err = extractDayRules(token, tn)
+
+			if err != nil {
+				return nil, err
+			}
 		}
+
+		part, err := parseField(token, ei)
+
+		if err != nil {
+			return nil, err
+		}
+
+		parts[tn] = part
+		parts[tn].rules = rules
 	}
 
-	result.minutes = &exprPart{slice2map(data[0]), data[0]}
-	result.hours = &exprPart{slice2map(data[1]), data[1]}
-	result.doms = &exprPart{slice2map(data[2]), data[2]}
-	result.months = &exprPart{slice2map(data[3]), data[3]}
-	result.dows = &exprPart{slice2map(data[4]), data[4]}
+	result.minutes = parts[0]
+	result.hours = parts[1]
+	result.doms = parts[2]
+	result.months = parts[3]
+	result.dows = parts[4]
+
+	result.fixedTime = !isWildcardToken(exprAr[0]) && !isWildcardToken(exprAr[1])
+
+	return result, nil
+}
+
+// ParseInLocation parse cron expression and set its time zone, time zone from
+// "CRON_TZ=" prefix has a priority over given location
+func ParseInLocation(expr string, loc *time.Location) (*Expr, error) {
+	if loc == nil {
+		return nil, ErrNilLocation
+	}
+
+	result, err := Parse(expr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if result.location == nil {
+		result.location = loc
+	}
 
 	return result, nil
 }
@@ -137,27 +276,22 @@ func (expr *Expr) IsDue(args ...time.Time) bool {
 		t = time.Now()
 	}
 
-	if expr.minutes.index[uint8(t.Minute())] == false {
-		return false
-	}
-
-	if expr.hours.index[uint8(t.Hour())] == false {
-		return false
-	}
-
-	if expr.doms.index[uint8(t.Day())] == false {
+	switch {
+	case expr.reboot:
 		return false
+	case expr.every != 0:
+		return t.Truncate(expr.every).Equal(t.Truncate(time.Second))
 	}
 
-	if expr.months.index[uint8(t.Month())] == false {
-		return false
-	}
+	t = t.In(expr.getLocation(t))
 
-	if expr.dows.index[uint8(t.Weekday())] == false {
-		return false
+	if !expr.isWallClockMatch(t) {
+		// Check for fixed time job which time was skipped due to DST transition
+		return expr.fixedTime && isAfterTransition(t) && expr.isFirstAfterTransition(t)
 	}
 
-	return true
+	// Fixed time job must be run only at first occurrence of repeated time
+	return !expr.fixedTime || !isRepeatedTime(t)
 }
 
 // Next get time of next matched moment
@@ -170,51 +304,87 @@ func (expr *Expr) Next(args ...time.Time) time.Time {
 		t = time.Now()
 	}
 
-	year := t.Year()
-
-	mStart := getNearPrevIndex(expr.months.tokens, uint8(t.Month()))
-	dStart := getNearPrevIndex(expr.doms.tokens, uint8(t.Day()))
-
-	for y := year; y < year+5; y++ {
-		for i := mStart; i < len(expr.months.tokens); i++ {
-			for j := dStart; j < len(expr.doms.tokens); j++ {
-				for k := 0; k < len(expr.hours.tokens); k++ {
-					for l := 0; l < len(expr.minutes.tokens); l++ {
-						d := time.Date(
-							y,
-							time.Month(expr.months.tokens[i]),
-							int(expr.doms.tokens[j]),
-							int(expr.hours.tokens[k]),
-							int(expr.minutes.tokens[l]),
-							0, 0, t.Location())
-
-						if d.Unix() <= t.Unix() {
-							continue
-						}
-
-						switch {
-						case uint8(d.Month()) != expr.months.tokens[i],
-							uint8(d.Day()) != expr.doms.tokens[j],
-							uint8(d.Hour()) != expr.hours.tokens[k],
-							uint8(d.Minute()) != expr.minutes.tokens[l],
-							expr.dows.index[uint8(d.Weekday())] == false:
-							continue
-						}
-
-						return d
-					}
+	switch {
+	case expr.reboot:
+		return time.Unix(0, 0)
+	case expr.every != 0:
+		return t.Truncate(expr.every).Add(expr.every)
+	}
+
+	loc := t.Location()
+	t = t.In(expr.getLocation(t))
+
+	maxYear := t.Year() + _SEARCH_YEARS
+	w := getStartWallClock(t, 3*time.Hour)
+	w.second++
+
+	var result time.Time
+
+	for {
+		var ok bool
+
+		w, ok = expr.findNextWallClock(w, maxYear)
+
+		if !ok {
+			break
+		}
+
+		moments := expr.getMoments(w, t.Location())
+
+		if len(moments) != 0 {
+			// All next moments will be after found one
+			if !result.IsZero() && moments[0].Unix() >= result.Unix() {
+				break
+			}
+
+			for _, d := range moments {
+				if d.Unix() > t.Unix() && (result.IsZero() || d.Before(result)) {
+					result = d
 				}
 			}
 
-			dStart = 0
+			// Without DST transition nearby next wall clock time can't give
+			// earlier moment
+			if !result.IsZero() && !isNearTransition(result) {
+				break
+			}
 		}
 
-		mStart = 0
+		w.second++
+	}
+
+	if !result.IsZero() {
+		return result.In(loc)
 	}
 
 	return time.Unix(0, 0)
 }
 
+// NextN get times of next n matched moments
+func (expr *Expr) NextN(n int, args ...time.Time) []time.Time {
+	var t time.Time
+
+	if len(args) >= 1 {
+		t = args[0]
+	} else {
+		t = time.Now()
+	}
+
+	var result []time.Time
+
+	for i := 0; i < n; i++ {
+		t = expr.Next(t)
+
+		if t.Equal(time.Unix(0, 0)) {
+			break
+		}
+
+		result = append(result, t)
+	}
+
+	return result
+}
+
 // Prev get time of prev matched moment
 func (expr *Expr) Prev(args ...time.Time) time.Time {
 	var t time.Time
@@ -225,51 +395,74 @@ func (expr *Expr) Prev(args ...time.Time) time.Time {
 		t = time.Now()
 	}
 
-	year := t.Year()
-
-	mStart := getNearNextIndex(expr.months.tokens, uint8(t.Month()))
-	dStart := getNearNextIndex(expr.doms.tokens, uint8(t.Day()))
-
-	for y := year; y >= year-5; y-- {
-		for i := mStart; i >= 0; i-- {
-			for j := dStart; j >= 0; j-- {
-				for k := len(expr.hours.tokens) - 1; k >= 0; k-- {
-					for l := len(expr.minutes.tokens) - 1; l >= 0; l-- {
-						d := time.Date(
-							y,
-							time.Month(expr.months.tokens[i]),
-							int(expr.doms.tokens[j]),
-							int(expr.hours.tokens[k]),
-							int(expr.minutes.tokens[l]),
-							0, 0, t.Location())
-
-						if d.Unix() >= t.Unix() {
-							continue
-						}
-
-						switch {
-						case uint8(d.Month()) != expr.months.tokens[i],
-							uint8(d.Day()) != expr.doms.tokens[j],
-							uint8(d.Hour()) != expr.hours.tokens[k],
-							uint8(d.Minute()) != expr.minutes.tokens[l],
-							expr.dows.index[uint8(d.Weekday())] == false:
-							continue
-						}
-
-						return d
-					}
+	switch {
+	case expr.reboot:
+		return time.Unix(0, 0)
+	case expr.every != 0:
+		prev := t.Truncate(expr.every)
+
+		if prev.Before(t.Truncate(time.Second)) {
+			return prev
+		}
+
+		return prev.Add(-expr.every)
+	}
+
+	loc := t.Location()
+	t = t.In(expr.getLocation(t))
+
+	minYear := t.Year() - _SEARCH_YEARS
+	w := getStartWallClock(t, -3*time.Hour)
+	w.second--
+
+	var result time.Time
+
+	for {
+		var ok bool
+
+		w, ok = expr.findPrevWallClock(w, minYear)
+
+		if !ok {
+			break
+		}
+
+		moments := expr.getMoments(w, t.Location())
+
+		if len(moments) != 0 {
+			// All previous moments will be before found one
+			if !result.IsZero() && moments[len(moments)-1].Unix() <= result.Unix() {
+				break
+			}
+
+			for _, d := range moments {
+				if d.Unix() < t.Unix() && (result.IsZero() || d.After(result)) {
+					result = d
 				}
 			}
 
-			dStart = len(expr.doms.tokens) - 1
+			// Without DST transition nearby previous wall clock time can't give
+			// later moment
+			if !result.IsZero() && !isNearTransition(result) {
+				break
+			}
 		}
 
-		mStart = len(expr.months.tokens) - 1
+		w.second--
+	}
+
+	if !result.IsZero() {
+		return result.In(loc)
 	}
 
 	return time.Unix(0, 0)
 }
 
+// Location return expression time zone (nil if expression uses location of
+// given time)
+func (expr *Expr) Location() *time.Location {
+	return expr.location
+}
+
 // String return raw expression
 func (expr *Expr) String() string {
 	return expr.expression
@@ -277,36 +470,711 @@ func (expr *Expr) String() string {
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
-func isAnyToken(t string) bool {
-	return t == _SYMBOL_ANY
+// isWallClockMatch return true if wall clock time is match for expression
+func (expr *Expr) isWallClockMatch(t time.Time) bool {
+	if expr.seconds != nil && expr.seconds.index[uint8(t.Second())] == false {
+		return false
+	}
+
+	if expr.minutes.index[uint8(t.Minute())] == false {
+		return false
+	}
+
+	if expr.hours.index[uint8(t.Hour())] == false {
+		return false
+	}
+
+	if expr.months.index[uint8(t.Month())] == false {
+		return false
+	}
+
+	return expr.isDayMatch(t)
 }
 
-func isEnumToken(t string) bool {
-	return strings.Contains(t, _SYMBOL_ENUM)
+// isFirstAfterTransition return true if given moment is the first moment
+// after DST transition when skipped time must be run
+func (expr *Expr) isFirstAfterTransition(t time.Time) bool {
+	step := time.Minute
+
+	if expr.seconds != nil {
+		step = time.Second
+	}
+
+	t = t.Truncate(step)
+
+	return expr.Next(t.Add(-step)).Equal(t)
 }
 
-func isPeriodToken(t string) bool {
-	return strings.Contains(t, _SYMBOL_PERIOD)
+// findNextWallClock return the earliest wall clock time which is equal to or
+// after given time and match for expression
+func (expr *Expr) findNextWallClock(w wallClock, maxYear int) (wallClock, bool) {
+	seconds, doms := expr.getSecondsTokens(), expr.getDomsTokens()
+
+	for w.year <= maxYear {
+		month, ok := getNextToken(expr.months.tokens, w.month)
+
+		if !ok {
+			w = wallClock{w.year + 1, 1, 1, 0, 0, 0}
+			continue
+		}
+
+		if month != w.month {
+			w = wallClock{w.year, month, 1, 0, 0, 0}
+		}
+
+		day, ok := getNextToken(doms, w.day)
+
+		if !ok || day > getDaysInMonth(w.year, w.month) {
+			w = wallClock{w.year, w.month + 1, 1, 0, 0, 0}
+			continue
+		}
+
+		if day != w.day {
+			w = wallClock{w.year, w.month, day, 0, 0, 0}
+		}
+
+		if !expr.isDateMatch(w.year, w.month, w.day) {
+			w = wallClock{w.year, w.month, w.day + 1, 0, 0, 0}
+			continue
+		}
+
+		hour, ok := getNextToken(expr.hours.tokens, w.hour)
+
+		if !ok {
+			w = wallClock{w.year, w.month, w.day + 1, 0, 0, 0}
+			continue
+		}
+
+		if hour != w.hour {
+			w.hour, w.minute, w.second = hour, 0, 0
+		}
+
+		minute, ok := getNextToken(expr.minutes.tokens, w.minute)
+
+		if !ok {
+			w.hour, w.minute, w.second = w.hour+1, 0, 0
+			continue
+		}
+
+		if minute != w.minute {
+			w.minute, w.second = minute, 0
+		}
+
+		second, ok := getNextToken(seconds, w.second)
+
+		if !ok {
+			w.minute, w.second = w.minute+1, 0
+			continue
+		}
+
+		w.second = second
+
+		return w, true
+	}
+
+	return w, false
 }
 
-func isIntervalToken(t string) bool {
-	return strings.Contains(t, _SYMBOL_INTERVAL)
+// findPrevWallClock return the latest wall clock time which is equal to or
+// before given time and match for expression
+func (expr *Expr) findPrevWallClock(w wallClock, minYear int) (wallClock, bool) {
+	seconds, doms := expr.getSecondsTokens(), expr.getDomsTokens()
+
+	for w.year >= minYear {
+		month, ok := getPrevToken(expr.months.tokens, w.month)
+
+		if !ok {
+			w = wallClock{w.year - 1, 12, 31, 23, 59, 59}
+			continue
+		}
+
+		if month != w.month {
+			w = wallClock{w.year, month, 31, 23, 59, 59}
+		}
+
+		if lastDay := getDaysInMonth(w.year, w.month); w.day > lastDay {
+			w = wallClock{w.year, w.month, lastDay, 23, 59, 59}
+		}
+
+		day, ok := getPrevToken(doms, w.day)
+
+		if !ok {
+			w = wallClock{w.year, w.month - 1, 31, 23, 59, 59}
+			continue
+		}
+
+		if day != w.day {
+			w = wallClock{w.year, w.month, day, 23, 59, 59}
+		}
+
+		if !expr.isDateMatch(w.year, w.month, w.day) {
+			w = wallClock{w.year, w.month, w.day - 1, 23, 59, 59}
+			continue
+		}
+
+		hour, ok := getPrevToken(expr.hours.tokens, w.hour)
+
+		if !ok {
+			w = wallClock{w.year, w.month, w.day - 1, 23, 59, 59}
+			continue
+		}
+
+		if hour != w.hour {
+			w.hour, w.minute, w.second = hour, 59, 59
+		}
+
+		minute, ok := getPrevToken(expr.minutes.tokens, w.minute)
+
+		if !ok {
+			w.hour, w.minute, w.second = w.hour-1, 59, 59
+			continue
+		}
+
+		if minute != w.minute {
+			w.minute, w.second = minute, 59
+		}
+
+		second, ok := getPrevToken(seconds, w.second)
+
+		if !ok {
+			w.minute, w.second = w.minute-1, 59
+			continue
+		}
+
+		w.second = second
+
+		return w, true
+	}
+
+	return w, false
 }
 
-func getEnumFromToken(t string, nt uint8) []uint8 {
-	var result []uint8
+// isDateMatch return true if date match for expression
+func (expr *Expr) isDateMatch(year, month, day int) bool {
+	// We use UTC noon for checking date to avoid any DST problems
+	return expr.isDayMatch(time.Date(year, time.Month(month), day, 12, 0, 0, 0, time.UTC))
+}
+
+// getMoments return sorted slice with moments which have given wall clock
+// time according to DST rules
+func (expr *Expr) getMoments(w wallClock, loc *time.Location) []time.Time {
+	if !isValidWallClock(w) {
+		return nil
+	}
+
+	d := time.Date(w.year, time.Month(w.month), w.day, w.hour, w.minute, w.second, 0, loc)
+
+	if !isSameWallClock(d, w) {
+		// Time doesn't exist due to DST transition
+		if !expr.fixedTime {
+			return nil
+		}
+
+		return []time.Time{getTransitionTime(d)}
+	}
+
+	for _, delta := range []time.Duration{-time.Hour, time.Hour, -30 * time.Minute, 30 * time.Minute} {
+		a := d.Add(delta)
+
+		if !isSameWallClock(a, w) {
+			continue
+		}
+
+		// Time occurs twice due to DST transition
+		first, second := d, a
+
+		if a.Before(d) {
+			first, second = a, d
+		}
+
+		if expr.fixedTime {
+			return []time.Time{first}
+		}
+
+		return []time.Time{first, second}
+	}
+
+	return []time.Time{d}
+}
+
+// getLocation return location for evaluating expression
+func (expr *Expr) getLocation(t time.Time) *time.Location {
+	if expr.location != nil {
+		return expr.location
+	}
+
+	return t.Location()
+}
+
+// isDayMatch return true if day of month and day of week of given date are
+// match for expression
+func (expr *Expr) isDayMatch(t time.Time) bool {
+	if !expr.doms.index[uint8(t.Day())] && !isRulesMatch(expr.doms.rules, t) {
+		return false
+	}
+
+	if !expr.dows.index[uint8(t.Weekday())] && !isRulesMatch(expr.dows.rules, t) {
+		return false
+	}
+
+	return true
+}
+
+// getSecondsTokens return seconds tokens (zero second if expression
+// doesn't contain seconds field)
+func (expr *Expr) getSecondsTokens() []uint8 {
+	if expr.seconds == nil {
+		return []uint8{0}
+	}
+
+	return expr.seconds.tokens
+}
+
+// getDomsTokens return days of month which must be checked
+func (expr *Expr) getDomsTokens() []uint8 {
+	if len(expr.doms.rules) == 0 {
+		return expr.doms.tokens
+	}
+
+	return fillUintSlice(1, 31, 1)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseField parse field token
+func parseField(token string, ei exprInfo) (*exprPart, error) {
+	var data []uint8
+
+	// Empty token means that field contains only special rules
+	if token != "" {
+		for _, t := range strings.Split(token, _SYMBOL_ENUM) {
+			values, err := parseFieldItem(t, ei)
+
+			if err != nil {
+				return nil, err
+			}
+
+			data = append(data, values...)
+		}
+	}
+
+	index := slice2map(data)
+
+	// 7 is also sunday
+	if ei.nt == _NAMES_DAYS && index[7] {
+		delete(index, 7)
+		index[0] = true
+	}
+
+	return &exprPart{index: index, tokens: map2slice(index)}, nil
+}
+
+// parseFieldItem parse part of field (value, period or interval) and return
+// all values which match it
+func parseFieldItem(t string, ei exprInfo) ([]uint8, error) {
+	switch {
+	case isAnyToken(t):
+		return fillUintSlice(ei.min, ei.max, 1), nil
+
+	case isIntervalToken(t):
+		ts := strings.Split(t, _SYMBOL_INTERVAL)
+
+		if len(ts) != 2 {
+			return nil, ErrMalformedField
+		}
+
+		interval, err := strconv.ParseUint(ts[1], 10, 8)
+
+		if err != nil || interval == 0 {
+			return nil, ErrMalformedField
+		}
+
+		start, end, err := parseIntervalBase(ts[0], ei)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return fillUintSlice(start, end, uint8(interval)), nil
+
+	case isPeriodToken(t):
+		start, end, err := parsePeriod(t, ei)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return fillUintSlice(start, end, 1), nil
+	}
+
+	value, ok := parseValue(t, ei.nt)
+
+	if !ok || value < int(ei.min) || value > int(ei.max) {
+		return nil, ErrMalformedField
+	}
+
+	return []uint8{uint8(value)}, nil
+}
+
+// parseIntervalBase parse part of interval before step (*, a-b or a) and return
+// start and end of interval
+func parseIntervalBase(t string, ei exprInfo) (uint8, uint8, error) {
+	switch {
+	case isAnyToken(t):
+		return ei.min, ei.max, nil
+	case isPeriodToken(t):
+		return parsePeriod(t, ei)
+	}
+
+	value, ok := parseValue(t, ei.nt)
+
+	if !ok || value < int(ei.min) || value > int(ei.max) {
+		return 0, 0, ErrMalformedField
+	}
+
+	return uint8(value), ei.max, nil
+}
+
+// parsePeriod parse period token (a-b) and return start and end of period
+func parsePeriod(t string, ei exprInfo) (uint8, uint8, error) {
+	ts := strings.Split(t, _SYMBOL_PERIOD)
+
+	if len(ts) != 2 {
+		return 0, 0, ErrMalformedField
+	}
+
+	start, ok1 := parseValue(ts[0], ei.nt)
+	end, ok2 := parseValue(ts[1], ei.nt)
+
+	if !ok1 || !ok2 || start > int(ei.max) {
+		return 0, 0, ErrMalformedField
+	}
+
+	// Period is limited by min and max values
+	start, end = mathutil.Max(start, int(ei.min)), mathutil.Min(end, int(ei.max))
+
+	if start > end {
+		return 0, 0, ErrMalformedField
+	}
+
+	return uint8(start), uint8(end), nil
+}
+
+// extractDayRules extract special tokens (L, W, #) from days of month or days
+// of week field and return remaining token
+func extractDayRules(token string, tn int) (string, []dayRule, error) {
+	if !strings.ContainsAny(strings.ToUpper(token), "LW#") {
+		return token, nil, nil
+	}
+
+	var rules []dayRule
+	var tokens []string
+
+	for _, t := range strings.Split(token, _SYMBOL_ENUM) {
+		// Last day of week is saturday
+		if tn == _FIELD_DOWS && strings.ToUpper(t) == _SYMBOL_LAST {
+			tokens = append(tokens, "6")
+			continue
+		}
+
+		rule, ok, err := parseDayRule(t, tn)
 
-	for _, tt := range strings.Split(t, _SYMBOL_ENUM) {
 		switch {
-		case isPeriodToken(tt):
-			ts, te := getPeriodFromToken(tt, nt)
-			result = append(result, fillUintSlice(ts, te, 1)...)
+		case err != nil:
+			return "", nil, err
+		case ok:
+			rules = append(rules, rule)
 		default:
-			result = append(result, parseToken(tt, nt))
+			tokens = append(tokens, t)
 		}
 	}
 
-	return result
+	return strings.Join(tokens, _SYMBOL_ENUM), rules, nil
+}
+
+// parseDayRule parse special token
+func parseDayRule(token string, tn int) (dayRule, bool, error) {
+	t := strings.ToUpper(token)
+
+	if tn == _FIELD_DOMS {
+		switch {
+		case t == _SYMBOL_LAST:
+			return dayRule{kind: _RULE_LAST_DAY}, true, nil
+
+		case t == _SYMBOL_LAST+_SYMBOL_WEEKDAY:
+			return dayRule{kind: _RULE_LAST_WEEKDAY}, true, nil
+
+		case strings.HasPrefix(t, _SYMBOL_LAST+_SYMBOL_PERIOD):
+			offset, err := strconv.ParseUint(t[2:], 10, 8)
+
+			if err != nil || offset > 30 {
+				return dayRule{}, false, ErrMalformedRule
+			}
+
+			return dayRule{kind: _RULE_LAST_DAY, day: uint8(offset)}, true, nil
+
+		case strings.HasSuffix(t, _SYMBOL_WEEKDAY):
+			day, err := strconv.ParseUint(t[:len(t)-1], 10, 8)
+
+			if err != nil || day < 1 || day > 31 {
+				return dayRule{}, false, ErrMalformedRule
+			}
+
+			return dayRule{kind: _RULE_NEAREST_WEEKDAY, day: uint8(day)}, true, nil
+		}
+
+		return dayRule{}, false, nil
+	}
+
+	switch {
+	case strings.Contains(t, _SYMBOL_NTH):
+		ts := strings.Split(t, _SYMBOL_NTH)
+		num, err := strconv.ParseUint(ts[1], 10, 8)
+
+		if len(ts) != 2 || err != nil || num < 1 || num > 5 {
+			return dayRule{}, false, ErrMalformedRule
+		}
+
+		day, ok := parseDowRuleDay(ts[0])
+
+		if !ok {
+			return dayRule{}, false, ErrMalformedRule
+		}
+
+		return dayRule{kind: _RULE_NTH_DOW, day: day, num: uint8(num)}, true, nil
+
+	case len(t) > 1 && strings.HasSuffix(t, _SYMBOL_LAST):
+		day, ok := parseDowRuleDay(t[:len(t)-1])
+
+		if !ok {
+			return dayRule{}, false, ErrMalformedRule
+		}
+
+		return dayRule{kind: _RULE_LAST_DOW, day: day}, true, nil
+	}
+
+	return dayRule{}, false, nil
+}
+
+// parseDowRuleDay parse day of week in special token
+func parseDowRuleDay(token string) (uint8, bool) {
+	day, ok := getDayNumByName(token)
+
+	if ok {
+		return day, true
+	}
+
+	d, err := strconv.ParseUint(token, 10, 8)
+
+	if err != nil || d > 7 {
+		return 0, false
+	}
+
+	// 7 is also sunday
+	return uint8(d % 7), true
+}
+
+// isRulesMatch return true if given date match any of given rules
+func isRulesMatch(rules []dayRule, t time.Time) bool {
+	if len(rules) == 0 {
+		return false
+	}
+
+	day := t.Day()
+	weekday := t.Weekday()
+	lastDay := getLastDayOfMonth(t)
+
+	for _, rule := range rules {
+		switch rule.kind {
+		case _RULE_LAST_DAY:
+			if day == lastDay-int(rule.day) {
+				return true
+			}
+
+		case _RULE_LAST_WEEKDAY:
+			if day == getNearestWeekday(t, lastDay, lastDay) {
+				return true
+			}
+
+		case _RULE_NEAREST_WEEKDAY:
+			if int(rule.day) <= lastDay && day == getNearestWeekday(t, int(rule.day), lastDay) {
+				return true
+			}
+
+		case _RULE_LAST_DOW:
+			if uint8(weekday) == rule.day && day+7 > lastDay {
+				return true
+			}
+
+		case _RULE_NTH_DOW:
+			if uint8(weekday) == rule.day && (day-1)/7+1 == int(rule.num) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// splitTimeZone split expression with time zone prefix to time zone
+// name and expression
+func splitTimeZone(expr string) (string, string) {
+	expr = expr[strings.Index(expr, "=")+1:]
+	index := strings.IndexAny(expr, " ")
+
+	if index == -1 {
+		return expr, ""
+	}
+
+	return expr[:index], strings.TrimSpace(expr[index:])
+}
+
+// isSameWallClock return true if given time has given wall clock time
+func isSameWallClock(t time.Time, w wallClock) bool {
+	return t.Year() == w.year && int(t.Month()) == w.month && t.Day() == w.day &&
+		t.Hour() == w.hour && t.Minute() == w.minute && t.Second() == w.second
+}
+
+// isValidWallClock return true if given wall clock time is valid calendar time
+func isValidWallClock(w wallClock) bool {
+	return w.month >= 1 && w.month <= 12 && w.day >= 1 &&
+		w.day <= getDaysInMonth(w.year, w.month) &&
+		w.hour >= 0 && w.hour <= 23 && w.minute >= 0 && w.minute <= 59 &&
+		w.second >= 0 && w.second <= 59
+}
+
+// getWallClock return wall clock time of given time
+func getWallClock(t time.Time) wallClock {
+	return wallClock{t.Year(), int(t.Month()), t.Day(), t.Hour(), t.Minute(), t.Second()}
+}
+
+// getStartWallClock return wall clock time for starting search from given
+// time. If clock moves backward during given period, wall clock time is
+// calculated using offset after transition, so moments with repeated time
+// will not be missed.
+func getStartWallClock(t time.Time, period time.Duration) wallClock {
+	_, offset := t.Zone()
+	_, periodOffset := t.Add(period).Zone()
+
+	if (period > 0 && periodOffset < offset) || (period < 0 && periodOffset > offset) {
+		return getWallClock(t.In(time.FixedZone("", periodOffset)))
+	}
+
+	return getWallClock(t)
+}
+
+// isNearTransition return true if DST transition happens less than 3 hours
+// before or after given time
+func isNearTransition(t time.Time) bool {
+	_, offset := t.Zone()
+	_, prevOffset := t.Add(-3 * time.Hour).Zone()
+	_, nextOffset := t.Add(3 * time.Hour).Zone()
+
+	return offset != prevOffset || offset != nextOffset
+}
+
+// isRepeatedTime return true if given time is second occurrence of the same
+// wall clock time
+func isRepeatedTime(t time.Time) bool {
+	for _, delta := range []time.Duration{time.Hour, 30 * time.Minute} {
+		p := t.Add(-delta)
+
+		if p.Hour() == t.Hour() && p.Minute() == t.Minute() && p.Day() == t.Day() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isAfterTransition return true if DST transition happened less than
+// 3 hours before given time
+func isAfterTransition(t time.Time) bool {
+	_, offset := t.Zone()
+	_, prevOffset := t.Add(-3 * time.Hour).Zone()
+
+	return offset != prevOffset
+}
+
+// getTransitionTime return the first moment after DST transition which happened
+// near given time
+func getTransitionTime(t time.Time) time.Time {
+	lo, hi := t.Add(-3*time.Hour).Unix(), t.Add(3*time.Hour).Unix()
+	_, loOffset := time.Unix(lo, 0).In(t.Location()).Zone()
+	_, hiOffset := time.Unix(hi, 0).In(t.Location()).Zone()
+
+	if loOffset == hiOffset {
+		return t
+	}
+
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		_, offset := time.Unix(mid, 0).In(t.Location()).Zone()
+
+		if offset == loOffset {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return time.Unix(hi, 0).In(t.Location())
+}
+
+// getLastDayOfMonth return number of days in month of given date
+func getLastDayOfMonth(t time.Time) int {
+	return getDaysInMonth(t.Year(), int(t.Month()))
+}
+
+// getDaysInMonth return number of days in given month
+func getDaysInMonth(year, month int) int {
+	return time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// getNearestWeekday return weekday (Mon-Fri) nearest to given day of month,
+// result is always in the same month
+func getNearestWeekday(t time.Time, day, lastDay int) int {
+	weekday := time.Date(t.Year(), t.Month(), day, 0, 0, 0, 0, time.UTC).Weekday()
+
+	switch weekday {
+	case time.Saturday:
+		if day == 1 {
+			return day + 2
+		}
+
+		return day - 1
+
+	case time.Sunday:
+		if day == lastDay {
+			return day - 2
+		}
+
+		return day + 1
+	}
+
+	return day
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func isWildcardToken(t string) bool {
+	return strings.HasPrefix(t, _SYMBOL_ANY) || strings.HasPrefix(t, _SYMBOL_NO_VALUE)
+}
+
+func isAnyToken(t string) bool {
+	return t == _SYMBOL_ANY || t == _SYMBOL_NO_VALUE
+}
+
+func isEnumToken(t string) bool {
+	return strings.Contains(t, _SYMBOL_ENUM)
+}
+
+func isPeriodToken(t string) bool {
+	return strings.Contains(t, _SYMBOL_PERIOD)
+}
+
+func isIntervalToken(t string) bool {
+	return strings.Contains(t, _SYMBOL_INTERVAL)
 }
 
 func getPeriodFromToken(t string, nt uint8) (uint8, uint8) {
@@ -413,8 +1281,9 @@ func getMonthNumByName(token string) (uint8, bool) {
 func fillUintSlice(start, end, interval uint8) []uint8 {
 	var result []uint8
 
-	for i := start; i <= end; i += interval {
-		result = append(result, i)
+	// Use int for iteration to prevent uint8 overflow with big intervals
+	for i := int(start); i <= int(end); i += int(interval) {
+		result = append(result, uint8(i))
 	}
 
 	return result
@@ -430,38 +1299,81 @@ func slice2map(s []uint8) map[uint8]bool {
 	return result
 }
 
+func map2slice(m map[uint8]bool) []uint8 {
+	var result []uint8
+
+	for i := 0; i <= 255; i++ {
+		if m[uint8(i)] {
+			result = append(result, uint8(i))
+		}
+	}
+
+	return result
+}
+
+func parseValue(t string, nt uint8) (int, bool) {
+	switch nt {
+	case _NAMES_DAYS:
+		if tu, ok := getDayNumByName(t); ok {
+			return int(tu), true
+		}
+
+	case _NAMES_MONTHS:
+		if tu, ok := getMonthNumByName(t); ok {
+			return int(tu), true
+		}
+	}
+
+	value, err := strconv.Atoi(t)
+
+	if err != nil || value < 0 || strings.HasPrefix(t, "+") {
+		return 0, false
+	}
+
+	return value, true
+}
+
 func str2uint(t string) uint8 {
 	u, _ := strconv.ParseUint(t, 10, 8)
 	return uint8(u)
 }
 
 func getNearNextIndex(items []uint8, item uint8) int {
-	for i := 0; i < len(items); i++ {
-		if items[i] >= item {
-			return i
-		}
+	i := sort.Search(len(items), func(i int) bool { return items[i] >= item })
+
+	if i == len(items) {
+		return 0
 	}
 
-	return 0
+	return i
 }
 
 func getNearPrevIndex(items []uint8, item uint8) int {
-	for i := len(items) - 1; i >= 0; i-- {
-		if items[i] <= item {
-			return i
-		}
+	i := sort.Search(len(items), func(i int) bool { return items[i] > item })
+
+	if i == 0 {
+		return len(items) - 1
 	}
 
-	return len(items) - 1
+	return i - 1
 }
 
-func between(val, min, max uint8) uint8 {
-	switch {
-	case val < min:
-		return min
-	case val > max:
-		return max
-	default:
-		return val
+func getNextToken(tokens []uint8, value int) (int, bool) {
+	if len(tokens) == 0 || value > 255 {
+		return 0, false
 	}
+
+	token := tokens[getNearNextIndex(tokens, uint8(value))]
+
+	return int(token), int(token) >= value
+}
+
+func getPrevToken(tokens []uint8, value int) (int, bool) {
+	if len(tokens) == 0 || value < 0 {
+		return 0, false
+	}
+
+	token := tokens[getNearPrevIndex(tokens, uint8(value))]
+
+	return int(token), int(token) <= value
 }
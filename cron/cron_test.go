@@ -8,10 +8,14 @@ package cron
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 import (
+	"io/ioutil"
+	"sync"
 	"testing"
 	"time"
 
 	. "pkg.re/check.v1"
+
+	"pkg.re/essentialkaos/ek.v9/log"
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -28,7 +32,7 @@ var _ = Suite(&CronSuite{})
 
 func (s *CronSuite) TestParsing(c *C) {
 
-	e0, err := Parse("* * * * * *")
+	e0, err := Parse("* * * * * * *")
 
 	c.Assert(err, NotNil)
 	c.Assert(e0, IsNil)
@@ -113,7 +117,7 @@ func (s *CronSuite) TestParsing(c *C) {
 	c.Assert(
 		e9.Prev(time.Date(2015, 1, 1, 0, 0, 0, 0, time.Local)),
 		Equals,
-		time.Unix(0, 0),
+		time.Date(2007, 1, 1, 12, 0, 0, 0, time.Local),
 	)
 
 	e10, err := Parse("0 12 1 1 Wed")
@@ -124,7 +128,7 @@ func (s *CronSuite) TestParsing(c *C) {
 	c.Assert(
 		e10.Next(time.Date(2015, 6, 1, 0, 0, 0, 0, time.Local)),
 		Equals,
-		time.Unix(0, 0),
+		time.Date(2020, 1, 1, 12, 0, 0, 0, time.Local),
 	)
 
 	e11, err := Parse("45 17 7 0-99999 1")
@@ -133,6 +137,580 @@ func (s *CronSuite) TestParsing(c *C) {
 	c.Assert(e11, NotNil)
 }
 
+func (s *CronSuite) TestRanges(c *C) {
+	for _, expr := range []string{
+		"0 24 * * *", "60 0 * * *", "0 0 0 * *", "0 0 32 * *", "0 0 * 13 *",
+		"0 0 * 0 *", "0 0 * * 8", "60 * * * * *", "*/0 * * * *", "60/10 * * * *",
+		"5-1/2 * * * *", "0 24-25 * * *", "5-1 * * * *", "abc * * * *", "+5 * * * *", "1-2-3 * * * *",
+		"0 0 * Jan-Foo *", "1/2/3 * * * *", "1/a * * * *", "a/5 * * * *",
+	} {
+		e, err := Parse(expr)
+		c.Assert(err, Equals, ErrMalformedField, Commentf("Expression: %s", expr))
+		c.Assert(e, IsNil)
+	}
+
+	e1, err := Parse("0 0 * * 7")
+
+	c.Assert(err, IsNil)
+	c.Assert(e1.IsDue(time.Date(2015, 6, 21, 0, 0, 0, 0, time.Local)), Equals, true)
+	c.Assert(e1.IsDue(time.Date(2015, 6, 20, 0, 0, 0, 0, time.Local)), Equals, false)
+
+	e2, err := Parse("0 0 * * 5-7")
+
+	c.Assert(err, IsNil)
+	c.Assert(e2.dows.tokens, DeepEquals, []uint8{0, 5, 6})
+
+	e3, err := Parse("0 0 31 2 *")
+
+	c.Assert(err, IsNil)
+	c.Assert(e3.Next(time.Date(2017, 3, 2, 10, 0, 0, 0, time.UTC)), Equals, time.Unix(0, 0))
+	c.Assert(e3.Prev(time.Date(2017, 3, 2, 10, 0, 0, 0, time.UTC)), Equals, time.Unix(0, 0))
+
+	c.Assert(e3.getMoments(wallClock{2017, 2, 30, 0, 0, 0}, time.UTC), IsNil)
+	c.Assert(e3.getMoments(wallClock{2017, 3, 2, 24, 0, 0}, time.UTC), IsNil)
+	c.Assert(e3.getMoments(wallClock{2017, 3, 2, 0, 60, 0}, time.UTC), IsNil)
+}
+
+func (s *CronSuite) TestIntervalsWithBase(c *C) {
+	e1, err := Parse("0-30/5 * * * *")
+
+	c.Assert(err, IsNil)
+	c.Assert(e1.minutes.tokens, DeepEquals, []uint8{0, 5, 10, 15, 20, 25, 30})
+
+	e2, err := Parse("10/15 * * * *")
+
+	c.Assert(err, IsNil)
+	c.Assert(e2.minutes.tokens, DeepEquals, []uint8{10, 25, 40, 55})
+
+	e3, err := Parse("0/15 * * * * *")
+
+	c.Assert(err, IsNil)
+	c.Assert(e3.seconds.tokens, DeepEquals, []uint8{0, 15, 30, 45})
+	c.Assert(e3.minutes.tokens, HasLen, 60)
+
+	e4, err := Parse("5-55/10 * * * *")
+
+	c.Assert(err, IsNil)
+	c.Assert(e4.minutes.tokens, DeepEquals, []uint8{5, 15, 25, 35, 45, 55})
+	c.Assert(
+		e4.Next(time.Date(2017, 3, 2, 10, 16, 0, 0, time.UTC)),
+		Equals,
+		time.Date(2017, 3, 2, 10, 25, 0, 0, time.UTC),
+	)
+
+	e5, err := Parse("0 9-17/4 * Jan-Jun/2 Mon/2")
+
+	c.Assert(err, IsNil)
+	c.Assert(e5.hours.tokens, DeepEquals, []uint8{9, 13, 17})
+	c.Assert(e5.months.tokens, DeepEquals, []uint8{1, 3, 5})
+	c.Assert(e5.dows.tokens, DeepEquals, []uint8{0, 1, 3, 5})
+
+	e6, err := Parse("50/250 * * * *")
+
+	c.Assert(err, IsNil)
+	c.Assert(e6.minutes.tokens, DeepEquals, []uint8{50})
+}
+
+func (s *CronSuite) TestExtendedSyntax(c *C) {
+	e1, err := Parse("*/15 * * * * *")
+
+	c.Assert(err, IsNil)
+	c.Assert(e1.IsDue(time.Date(2015, 1, 1, 0, 0, 15, 0, time.Local)), Equals, true)
+	c.Assert(e1.IsDue(time.Date(2015, 1, 1, 0, 0, 20, 0, time.Local)), Equals, false)
+	c.Assert(e1.Next(time.Date(2015, 1, 1, 0, 0, 20, 0, time.Local)), Equals, time.Date(2015, 1, 1, 0, 0, 30, 0, time.Local))
+	c.Assert(e1.Prev(time.Date(2015, 1, 1, 0, 0, 10, 0, time.Local)), Equals, time.Date(2015, 1, 1, 0, 0, 0, 0, time.Local))
+
+	e2, err := Parse("0 12 L * ?")
+
+	c.Assert(err, IsNil)
+	c.Assert(e2.Next(time.Date(2015, 2, 3, 0, 0, 0, 0, time.Local)), Equals, time.Date(2015, 2, 28, 12, 0, 0, 0, time.Local))
+	c.Assert(e2.Next(time.Date(2016, 2, 3, 0, 0, 0, 0, time.Local)), Equals, time.Date(2016, 2, 29, 12, 0, 0, 0, time.Local))
+	c.Assert(e2.Prev(time.Date(2015, 5, 3, 0, 0, 0, 0, time.Local)), Equals, time.Date(2015, 4, 30, 12, 0, 0, 0, time.Local))
+
+	e3, err := Parse("0 0 L-2,1 * *")
+
+	c.Assert(err, IsNil)
+	c.Assert(e3.Next(time.Date(2015, 1, 1, 12, 0, 0, 0, time.Local)), Equals, time.Date(2015, 1, 29, 0, 0, 0, 0, time.Local))
+	c.Assert(e3.Next(time.Date(2015, 1, 29, 12, 0, 0, 0, time.Local)), Equals, time.Date(2015, 2, 1, 0, 0, 0, 0, time.Local))
+
+	// 2015-08-01 is saturday, 2015-05-31 is sunday
+	e4, err := Parse("0 0 1W,15W * *")
+
+	c.Assert(err, IsNil)
+	c.Assert(e4.IsDue(time.Date(2015, 8, 3, 0, 0, 0, 0, time.Local)), Equals, true)
+	c.Assert(e4.IsDue(time.Date(2015, 8, 1, 0, 0, 0, 0, time.Local)), Equals, false)
+	c.Assert(e4.IsDue(time.Date(2015, 8, 14, 0, 0, 0, 0, time.Local)), Equals, true)
+	c.Assert(e4.IsDue(time.Date(2015, 2, 16, 0, 0, 0, 0, time.Local)), Equals, true)
+
+	e5, err := Parse("0 0 LW * *")
+
+	c.Assert(err, IsNil)
+	c.Assert(e5.Next(time.Date(2015, 5, 1, 0, 0, 0, 0, time.Local)), Equals, time.Date(2015, 5, 29, 0, 0, 0, 0, time.Local))
+	c.Assert(e5.Next(time.Date(2015, 6, 1, 0, 0, 0, 0, time.Local)), Equals, time.Date(2015, 6, 30, 0, 0, 0, 0, time.Local))
+
+	e6, err := Parse("0 0 ? * Fri#3,1L")
+
+	c.Assert(err, IsNil)
+	c.Assert(e6.Next(time.Date(2015, 6, 1, 0, 0, 0, 0, time.Local)), Equals, time.Date(2015, 6, 19, 0, 0, 0, 0, time.Local))
+	c.Assert(e6.Next(time.Date(2015, 6, 19, 0, 0, 0, 0, time.Local)), Equals, time.Date(2015, 6, 29, 0, 0, 0, 0, time.Local))
+	c.Assert(e6.Prev(time.Date(2015, 6, 19, 0, 0, 0, 0, time.Local)), Equals, time.Date(2015, 5, 25, 0, 0, 0, 0, time.Local))
+
+	e7, err := Parse("0 0 * * L")
+
+	c.Assert(err, IsNil)
+	c.Assert(e7.IsDue(time.Date(2015, 6, 20, 0, 0, 0, 0, time.Local)), Equals, true)
+	c.Assert(e7.IsDue(time.Date(2015, 6, 21, 0, 0, 0, 0, time.Local)), Equals, false)
+
+	e8, err := Parse("@every 5m")
+
+	c.Assert(err, IsNil)
+	c.Assert(e8.IsDue(time.Date(2015, 6, 20, 0, 5, 0, 0, time.UTC)), Equals, true)
+	c.Assert(e8.IsDue(time.Date(2015, 6, 20, 0, 6, 0, 0, time.UTC)), Equals, false)
+	c.Assert(e8.Next(time.Date(2015, 6, 20, 0, 6, 0, 0, time.UTC)), Equals, time.Date(2015, 6, 20, 0, 10, 0, 0, time.UTC))
+	c.Assert(e8.Prev(time.Date(2015, 6, 20, 0, 6, 0, 0, time.UTC)), Equals, time.Date(2015, 6, 20, 0, 5, 0, 0, time.UTC))
+	c.Assert(e8.Prev(time.Date(2015, 6, 20, 0, 5, 0, 0, time.UTC)), Equals, time.Date(2015, 6, 20, 0, 0, 0, 0, time.UTC))
+
+	e9, err := Parse(REBOOT)
+
+	c.Assert(err, IsNil)
+	c.Assert(e9.IsDue(), Equals, false)
+	c.Assert(e9.Next(), Equals, time.Unix(0, 0))
+	c.Assert(e9.Prev(), Equals, time.Unix(0, 0))
+
+	for _, expr := range []string{
+		"@every 5", "@every 10ms", "0 0 L-X * *", "0 0 32W * *",
+		"0 0 * * 5#6", "0 0 * * X#1", "0 0 * * 5#1#2", "0 0 * * XL",
+		"0 0 1-L * *", "0 0 L-1-2 * *", "0 0 L/2 * *", "0 0 3L * *", "0 0 LWW * *",
+		"0 0 L-2W * *", "0 0 5#2 * *", "0 0 * * W", "0 0 * * LW", "0 0 * * 15W",
+		"0 0 * * L-2", "0 0 * * 5#", "0 0 * * #2", "0 0 * * 1-L", "L * * * *",
+		"0 L * * *", "0 0 * L *", "0 0 * 1W *", "0 1#2 * * *", "L 0 0 * * *",
+	} {
+		_, err = Parse(expr)
+		c.Assert(err, NotNil, Commentf("Expression: %s", expr))
+	}
+}
+
+func (s *CronSuite) TestTimeZones(c *C) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	e1, err := Parse("CRON_TZ=Europe/Berlin 30 9 * * *")
+
+	c.Assert(err, IsNil)
+	c.Assert(e1.Location().String(), Equals, "Europe/Berlin")
+	c.Assert(e1.String(), Equals, "CRON_TZ=Europe/Berlin 30 9 * * *")
+	c.Assert(e1.IsDue(time.Date(2017, 6, 1, 7, 30, 0, 0, time.UTC)), Equals, true)
+	c.Assert(e1.IsDue(time.Date(2017, 6, 1, 9, 30, 0, 0, time.UTC)), Equals, false)
+	c.Assert(e1.Next(time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC)), Equals, time.Date(2017, 6, 1, 7, 30, 0, 0, time.UTC))
+	c.Assert(e1.Prev(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)), Equals, time.Date(2016, 12, 31, 8, 30, 0, 0, time.UTC))
+
+	e2, err := ParseInLocation("TZ=UTC @daily", berlin)
+
+	c.Assert(err, IsNil)
+	c.Assert(e2.Location().String(), Equals, "UTC")
+
+	e3, err := ParseInLocation("@daily", berlin)
+
+	c.Assert(err, IsNil)
+	c.Assert(e3.Location(), Equals, berlin)
+
+	e4, err := Parse("@daily")
+
+	c.Assert(err, IsNil)
+	c.Assert(e4.Location(), IsNil)
+
+	_, err = Parse("CRON_TZ=Unknown/Zone * * * * *")
+	c.Assert(err, NotNil)
+	_, err = Parse("CRON_TZ=UTC")
+	c.Assert(err, Equals, ErrMalformedExpression)
+	_, err = ParseInLocation("* * * * *", nil)
+	c.Assert(err, Equals, ErrNilLocation)
+	_, err = ParseInLocation("* * * *", time.UTC)
+	c.Assert(err, NotNil)
+}
+
+func (s *CronSuite) TestDSTTransitions(c *C) {
+	fixed, _ := Parse("CRON_TZ=Europe/Berlin 30 2 * * *")
+	hourly, _ := Parse("CRON_TZ=Europe/Berlin 30 * * * *")
+
+	// Clock moves forward from 02:00 CET to 03:00 CEST (01:00 UTC)
+	c.Assert(fixed.Next(time.Date(2017, 3, 25, 12, 0, 0, 0, time.UTC)), Equals, time.Date(2017, 3, 26, 1, 0, 0, 0, time.UTC))
+	c.Assert(fixed.Next(time.Date(2017, 3, 26, 1, 0, 0, 0, time.UTC)), Equals, time.Date(2017, 3, 27, 0, 30, 0, 0, time.UTC))
+	c.Assert(fixed.Prev(time.Date(2017, 3, 27, 0, 0, 0, 0, time.UTC)), Equals, time.Date(2017, 3, 26, 1, 0, 0, 0, time.UTC))
+	c.Assert(fixed.IsDue(time.Date(2017, 3, 26, 1, 0, 0, 0, time.UTC)), Equals, true)
+	c.Assert(fixed.IsDue(time.Date(2017, 3, 26, 1, 1, 0, 0, time.UTC)), Equals, false)
+	c.Assert(hourly.Next(time.Date(2017, 3, 26, 0, 30, 0, 0, time.UTC)), Equals, time.Date(2017, 3, 26, 1, 30, 0, 0, time.UTC))
+	c.Assert(hourly.Prev(time.Date(2017, 3, 26, 1, 30, 0, 0, time.UTC)), Equals, time.Date(2017, 3, 26, 0, 30, 0, 0, time.UTC))
+	c.Assert(hourly.IsDue(time.Date(2017, 3, 26, 1, 0, 0, 0, time.UTC)), Equals, false)
+
+	// Clock moves backward from 03:00 CEST to 02:00 CET (01:00 UTC)
+	c.Assert(fixed.Next(time.Date(2017, 10, 28, 12, 0, 0, 0, time.UTC)), Equals, time.Date(2017, 10, 29, 0, 30, 0, 0, time.UTC))
+	c.Assert(fixed.Next(time.Date(2017, 10, 29, 0, 30, 0, 0, time.UTC)), Equals, time.Date(2017, 10, 30, 1, 30, 0, 0, time.UTC))
+	c.Assert(fixed.Prev(time.Date(2017, 10, 30, 0, 0, 0, 0, time.UTC)), Equals, time.Date(2017, 10, 29, 0, 30, 0, 0, time.UTC))
+	c.Assert(fixed.IsDue(time.Date(2017, 10, 29, 0, 30, 0, 0, time.UTC)), Equals, true)
+	c.Assert(fixed.IsDue(time.Date(2017, 10, 29, 1, 30, 0, 0, time.UTC)), Equals, false)
+	c.Assert(hourly.Next(time.Date(2017, 10, 29, 0, 0, 0, 0, time.UTC)), Equals, time.Date(2017, 10, 29, 0, 30, 0, 0, time.UTC))
+	c.Assert(hourly.Next(time.Date(2017, 10, 29, 0, 30, 0, 0, time.UTC)), Equals, time.Date(2017, 10, 29, 1, 30, 0, 0, time.UTC))
+	c.Assert(hourly.Next(time.Date(2017, 10, 29, 1, 30, 0, 0, time.UTC)), Equals, time.Date(2017, 10, 29, 2, 30, 0, 0, time.UTC))
+	c.Assert(hourly.Prev(time.Date(2017, 10, 29, 2, 30, 0, 0, time.UTC)), Equals, time.Date(2017, 10, 29, 1, 30, 0, 0, time.UTC))
+	c.Assert(hourly.Prev(time.Date(2017, 10, 29, 1, 30, 0, 0, time.UTC)), Equals, time.Date(2017, 10, 29, 0, 30, 0, 0, time.UTC))
+	c.Assert(hourly.IsDue(time.Date(2017, 10, 29, 1, 30, 0, 0, time.UTC)), Equals, true)
+
+	// Lord Howe Island has 30 minutes DST shift
+	lordHowe, _ := Parse("CRON_TZ=Australia/Lord_Howe 15 2 * * *")
+
+	c.Assert(lordHowe.Next(time.Date(2017, 9, 30, 12, 0, 0, 0, time.UTC)), Equals, time.Date(2017, 9, 30, 15, 30, 0, 0, time.UTC))
+
+	lordHowe, _ = Parse("CRON_TZ=Australia/Lord_Howe 45 1 * * *")
+
+	c.Assert(lordHowe.Next(time.Date(2017, 4, 1, 12, 0, 0, 0, time.UTC)), Equals, time.Date(2017, 4, 1, 14, 45, 0, 0, time.UTC))
+	c.Assert(lordHowe.Next(time.Date(2017, 4, 1, 14, 45, 0, 0, time.UTC)), Equals, time.Date(2017, 4, 2, 15, 15, 0, 0, time.UTC))
+}
+
+func (s *CronSuite) TestDSTExhaustive(c *C) {
+	zones := []string{"Europe/Berlin", "America/New_York", "Australia/Lord_Howe", "UTC"}
+
+	for _, zone := range zones {
+		loc, err := time.LoadLocation(zone)
+
+		c.Assert(err, IsNil)
+
+		everyMinute, _ := ParseInLocation("* * * * *", loc)
+
+		for _, transition := range getTransitions(loc, 2017) {
+			start := transition.Add(-3 * time.Hour)
+
+			for t := start; t.Before(transition.Add(3 * time.Hour)); t = t.Add(time.Minute) {
+				c.Assert(everyMinute.Next(t), Equals, t.Add(time.Minute), Commentf("Zone: %s", zone))
+				c.Assert(everyMinute.Prev(t), Equals, t.Add(-time.Minute), Commentf("Zone: %s", zone))
+				c.Assert(everyMinute.IsDue(t), Equals, true, Commentf("Zone: %s", zone))
+			}
+		}
+
+		for _, expr := range []string{"0 0 * * *", "15 2 * * *", "30 2 * * *", "45 1 * * *", "0 3 * * *"} {
+			fixed, _ := ParseInLocation(expr, loc)
+
+			var runs []time.Time
+
+			t := time.Date(2016, 12, 31, 12, 0, 0, 0, loc)
+
+			for {
+				t = fixed.Next(t)
+
+				if t.Year() != 2017 {
+					break
+				}
+
+				runs = append(runs, t)
+			}
+
+			c.Assert(runs, HasLen, 365, Commentf("Zone: %s, Expression: %s", zone, expr))
+
+			for i := len(runs) - 1; i > 0; i-- {
+				c.Assert(fixed.Prev(runs[i]), Equals, runs[i-1], Commentf("Zone: %s, Expression: %s", zone, expr))
+				c.Assert(fixed.IsDue(runs[i]), Equals, true, Commentf("Zone: %s, Expression: %s", zone, expr))
+				c.Assert(runs[i].In(loc).YearDay(), Equals, runs[i-1].In(loc).YearDay()+1)
+			}
+		}
+	}
+}
+
+func (s *CronSuite) TestNextN(c *C) {
+	e1, _ := Parse("0 0 29 2 *")
+
+	c.Assert(e1.NextN(3, time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)), DeepEquals, []time.Time{
+		time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+	})
+
+	e2, _ := Parse("0 0 10,20 * *")
+
+	c.Assert(e2.NextN(3, time.Date(2017, 3, 5, 0, 0, 0, 0, time.UTC)), DeepEquals, []time.Time{
+		time.Date(2017, 3, 10, 0, 0, 0, 0, time.UTC),
+		time.Date(2017, 3, 20, 0, 0, 0, 0, time.UTC),
+		time.Date(2017, 4, 10, 0, 0, 0, 0, time.UTC),
+	})
+
+	e3, _ := Parse("30 10 * 6,9 *")
+
+	c.Assert(e3.Next(time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)), Equals, time.Date(2017, 6, 1, 10, 30, 0, 0, time.UTC))
+	c.Assert(e3.Prev(time.Date(2017, 12, 1, 0, 0, 0, 0, time.UTC)), Equals, time.Date(2017, 9, 30, 10, 30, 0, 0, time.UTC))
+
+	e4, _ := Parse("0 0 30 2 *")
+
+	c.Assert(e4.NextN(3, time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)), HasLen, 0)
+
+	e5, _ := Parse("@reboot")
+
+	c.Assert(e5.NextN(3), HasLen, 0)
+
+	e6, _ := Parse("*/15 * * * *")
+
+	c.Assert(e6.NextN(5), HasLen, 5)
+	c.Assert(e6.NextN(0), HasLen, 0)
+}
+
+func (s *CronSuite) TestSearchConsistency(c *C) {
+	exprs := []string{
+		"* * * * *", "*/7 * * * *", "30 2 * * *", "0 0 29 2 *", "0 12 1 1 Wed",
+		"15 10 L * *", "0 9 15W * *", "0 9 * * 5L", "0 9 * * 1#2", "5,55 */3 10-20 * Mon-Fri",
+		"*/20 0 12 * * *", "0 0 0 31 * ?", "45 1 * * *", "0,30 * * * *", "0 0 30 2 *",
+		"0 0 LW * *", "0 0 1W * *", "30 1 * * 0,7",
+	}
+
+	// Expressions with out of range values must be rejected instead of giving
+	// normalized times
+	for _, expr := range []string{"0 0 * 13 *", "0 24 * * *", "60 0 * * *", "0 0 32 * *"} {
+		_, err := Parse(expr)
+		c.Assert(err, NotNil, Commentf("Expression: %s", expr))
+	}
+
+	// Sparse expressions may have no matching moment for decades
+	sparse := []struct {
+		expr string
+		t    time.Time
+		next time.Time
+		prev time.Time
+	}{
+		{
+			"0 0 29 2 *", time.Date(2097, 3, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2104, 2, 29, 0, 0, 0, 0, time.UTC),
+			time.Date(2096, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			"0 0 29 2 1", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2044, 2, 29, 0, 0, 0, 0, time.UTC),
+			time.Date(2016, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			"0 0 29 2 1", time.Date(2016, 2, 29, 0, 0, 0, 0, time.UTC),
+			time.Date(2044, 2, 29, 0, 0, 0, 0, time.UTC),
+			time.Date(1988, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			"0 0 30 2 *", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Unix(0, 0), time.Unix(0, 0),
+		},
+	}
+
+	for _, st := range sparse {
+		e, err := ParseInLocation(st.expr, time.UTC)
+		comment := Commentf("Expression: %s, Time: %v", st.expr, st.t)
+
+		c.Assert(err, IsNil, comment)
+		c.Assert(e.Next(st.t).Equal(st.next), Equals, true, comment)
+		c.Assert(e.Prev(st.t).Equal(st.prev), Equals, true, comment)
+	}
+
+	e, _ := ParseInLocation("0 0 29 2 *", time.UTC)
+
+	c.Assert(e.NextN(3, time.Date(2090, 1, 1, 0, 0, 0, 0, time.UTC)), DeepEquals, []time.Time{
+		time.Date(2092, 2, 29, 0, 0, 0, 0, time.UTC),
+		time.Date(2096, 2, 29, 0, 0, 0, 0, time.UTC),
+		time.Date(2104, 2, 29, 0, 0, 0, 0, time.UTC),
+	})
+
+	zones := []string{"Europe/Berlin", "America/New_York", "Australia/Lord_Howe", "UTC"}
+
+	for _, zone := range zones {
+		loc, _ := time.LoadLocation(zone)
+		transitions := getTransitions(loc, 2017)
+
+		for _, expr := range exprs {
+			e, err := ParseInLocation(expr, loc)
+
+			c.Assert(err, IsNil)
+
+			moments := []time.Time{time.Date(2017, 1, 1, 0, 0, 0, 0, loc), time.Date(2017, 12, 31, 23, 59, 30, 0, loc)}
+
+			for _, transition := range transitions {
+				for d := -2 * time.Hour; d <= 2*time.Hour; d += 20 * time.Minute {
+					moments = append(moments, transition.Add(d))
+				}
+			}
+
+			for _, t := range moments {
+				comment := Commentf("Zone: %s, Expression: %s, Time: %v", zone, expr, t)
+
+				c.Assert(e.Next(t), Equals, bruteNext(e, t), comment)
+				c.Assert(e.Prev(t), Equals, brutePrev(e, t), comment)
+			}
+		}
+	}
+}
+
+func (s *CronSuite) TestDescription(c *C) {
+	descriptions := map[string]string{
+		"5 4 * * 1":                    "at 04:05 on every Monday",
+		"5 4 * * *":                    "at 04:05",
+		"0 9,12,18 * * Mon-Fri":        "at 09:00, 12:00 and 18:00 on every Monday through Friday",
+		"* * * * *":                    "every minute",
+		"*/15 * * * *":                 "every 15 minutes",
+		"5 * * * *":                    "at minute 5 of every hour",
+		"0,30 9-17 * * *":              "at minutes 0 and 30 of every hour from 9 through 17",
+		"*/10 */2 * * *":               "every 10 minutes of every 2 hours",
+		"5-55/10 * * * *":              "every 10 minutes from 5 through 55",
+		"10/15 9 * * *":                "every 15 minutes starting at 10 of hour 9",
+		"0 0 1/2 Jan-Jun/2 *":          "at 00:00 every 2 days starting at 1 of the month every 2 months from January through June",
+		"* 9 * * *":                    "every minute of hour 9",
+		"0 0 1 * *":                    "at 00:00 on day 1 of the month",
+		"0 0 1,15 Jan,Jul *":           "at 00:00 on days 1 and 15 of the month in January and July",
+		"0 0 1-7 */3 *":                "at 00:00 on days 1 through 7 of the month every 3 months",
+		"0 0 L * ?":                    "at 00:00 on the last day of the month",
+		"0 0 1,L-2,LW,15W * *":         "at 00:00 on day 1, 2 days before the last day, the last weekday and the weekday nearest to day 15 of the month",
+		"0 0 * * 5L,Mon#2":             "at 00:00 on the last Friday and the second Monday of the month",
+		"0 0 1 * 1,L":                  "at 00:00 on day 1 of the month and on every Monday and Saturday",
+		"0 0 */2 Mar-May */3":          "at 00:00 every 2 days of the month and every 3 days of the week in March through May",
+		"30 15 10 * * *":               "at 10:15:30",
+		"*/10 * * * * *":               "every 10 seconds",
+		"30 * * * * *":                 "at second 30 of every minute",
+		"0 */5 * * * *":                "every 5 minutes",
+		"@weekly":                      "at 00:00 on every Sunday",
+		"@every 90m":                   "every 1 hour and 30 minutes",
+		"@reboot":                      "at startup",
+		"CRON_TZ=Europe/Berlin @daily": "at 00:00 (Europe/Berlin)",
+	}
+
+	for expr, desc := range descriptions {
+		e, err := Parse(expr)
+
+		c.Assert(err, IsNil, Commentf("Expression: %s", expr))
+		c.Assert(e.Description(), Equals, desc, Commentf("Expression: %s", expr))
+	}
+}
+
+func (s *CronSuite) TestCrontab(c *C) {
+	data := `# /etc/crontab: system-wide crontab
+
+SHELL=/bin/sh
+PATH = "/usr/local/sbin:/usr/local/bin:/sbin:/bin"
+
+# m h dom mon dow user  command
+17 *	* * *	root    cd / && run-parts --report /etc/cron.hourly
+25 6	* * *	root	test -x /usr/sbin/anacron || ( cd / && run-parts --report /etc/cron.daily )
+
+MAILTO=''
+CRON_TZ=Europe/Berlin
+@reboot      nobody /usr/bin/daemon --start
+`
+
+	ct, err := ParseCrontab([]byte(data), true)
+
+	c.Assert(err, IsNil)
+	c.Assert(ct.Env, DeepEquals, map[string]string{
+		"SHELL":   "/bin/sh",
+		"PATH":    "/usr/local/sbin:/usr/local/bin:/sbin:/bin",
+		"MAILTO":  "",
+		"CRON_TZ": "Europe/Berlin",
+	})
+	c.Assert(ct.Entries, HasLen, 3)
+
+	c.Assert(ct.Entries[0].Expr.String(), Equals, "17 * * * *")
+	c.Assert(ct.Entries[0].User, Equals, "root")
+	c.Assert(ct.Entries[0].Command, Equals, "cd / && run-parts --report /etc/cron.hourly")
+	c.Assert(ct.Entries[0].Line, Equals, 7)
+	c.Assert(ct.Entries[0].Env, HasLen, 2)
+
+	c.Assert(ct.Entries[1].Command, Equals, "test -x /usr/sbin/anacron || ( cd / && run-parts --report /etc/cron.daily )")
+
+	c.Assert(ct.Entries[2].Expr.String(), Equals, "CRON_TZ=Europe/Berlin @reboot")
+	c.Assert(ct.Entries[2].Expr.Location().String(), Equals, "Europe/Berlin")
+	c.Assert(ct.Entries[2].User, Equals, "nobody")
+	c.Assert(ct.Entries[2].Command, Equals, "/usr/bin/daemon --start")
+	c.Assert(ct.Entries[2].Env, HasLen, 4)
+
+	ct, err = ParseCrontab([]byte("*/5 * * * * /usr/bin/backup.sh  --full\n@hourly echo 1"), false)
+
+	c.Assert(err, IsNil)
+	c.Assert(ct.Entries, HasLen, 2)
+	c.Assert(ct.Entries[0].User, Equals, "")
+	c.Assert(ct.Entries[0].Command, Equals, "/usr/bin/backup.sh  --full")
+	c.Assert(ct.Entries[1].Command, Equals, "echo 1")
+
+	ct, err = ParseCrontab([]byte("@every 5m /bin/cmd --all\n@every 1h root /bin/cmd"), false)
+
+	c.Assert(err, IsNil)
+	c.Assert(ct.Entries, HasLen, 2)
+	c.Assert(ct.Entries[0].Expr.String(), Equals, "@every 5m")
+	c.Assert(ct.Entries[0].Command, Equals, "/bin/cmd --all")
+	c.Assert(ct.Entries[1].Command, Equals, "root /bin/cmd")
+
+	ct, err = ParseCrontab([]byte("@every 1h root /bin/cmd"), true)
+
+	c.Assert(err, IsNil)
+	c.Assert(ct.Entries[0].User, Equals, "root")
+	c.Assert(ct.Entries[0].Command, Equals, "/bin/cmd")
+
+	ct, err = ParseCrontab([]byte(`0 * * * * mail -s "100\% done" root%Line 1%Line 2\%`), false)
+
+	c.Assert(err, IsNil)
+	c.Assert(ct.Entries[0].Command, Equals, `mail -s "100% done" root`)
+	c.Assert(ct.Entries[0].Input, Equals, "Line 1\nLine 2%")
+
+	ct, err = ParseCrontab([]byte("* * * * * root\n* * * * * root echo\n\n* * * *"), true)
+
+	c.Assert(err, Equals, ErrMalformedCrontab)
+	c.Assert(ct.Entries, HasLen, 1)
+	c.Assert(ct.Entries[0].Line, Equals, 2)
+	c.Assert(ct.Errors, HasLen, 2)
+	c.Assert(ct.Errors[0], ErrorMatches, "Can't parse line 1: Line must contain expression, user and command")
+	c.Assert(ct.Errors[1], ErrorMatches, "Can't parse line 4: Line must contain expression, user and command")
+
+	ct, err = ParseCrontab([]byte("\n* * * *"), false)
+
+	c.Assert(err, Equals, ErrMalformedCrontab)
+	c.Assert(ct.Errors[0], ErrorMatches, "Can't parse line 2: Line must contain expression and command")
+
+	_, err = ParseCrontab([]byte("CRON_TZ=Unknown\n* * * * * echo"), false)
+	c.Assert(err, NotNil)
+
+	tmpDir := c.MkDir()
+
+	c.Assert(ioutil.WriteFile(tmpDir+"/crontab", []byte(data), 0644), IsNil)
+
+	ct, err = ReadCrontab(tmpDir+"/crontab", true)
+
+	c.Assert(err, IsNil)
+	c.Assert(ct.Entries, HasLen, 3)
+
+	_, err = ReadCrontab(tmpDir+"/unknown", true)
+
+	c.Assert(err, NotNil)
+}
+
+func (s *CronSuite) TestCrontabSystemFile(c *C) {
+	// /etc/cron.d/sysstat from Debian
+	data := `# The first element of the path is a directory where the debian-sa1
+# script is located
+PATH=/usr/lib/sysstat:/usr/sbin:/usr/sbin:/usr/bin:/sbin:/bin
+
+# Activity reports every 10 minutes everyday
+5-55/10 * * * * root command -v debian-sa1 > /dev/null && debian-sa1 1 1
+
+# Additional run at 23:59 to rotate the statistics file
+59 23 * * * root command -v debian-sa1 > /dev/null && debian-sa1 60 2
+`
+
+	ct, err := ParseCrontab([]byte(data), true)
+
+	c.Assert(err, IsNil)
+	c.Assert(ct.Errors, HasLen, 0)
+	c.Assert(ct.Entries, HasLen, 2)
+
+	c.Assert(ct.Entries[0].Expr.String(), Equals, "5-55/10 * * * *")
+	c.Assert(ct.Entries[0].User, Equals, "root")
+	c.Assert(ct.Entries[0].Command, Equals, "command -v debian-sa1 > /dev/null && debian-sa1 1 1")
+	c.Assert(ct.Entries[0].Line, Equals, 6)
+	c.Assert(
+		ct.Entries[0].Expr.Next(time.Date(2017, 3, 2, 10, 56, 0, 0, time.UTC)),
+		Equals,
+		time.Date(2017, 3, 2, 11, 5, 0, 0, time.UTC),
+	)
+
+	c.Assert(ct.Entries[1].Expr.String(), Equals, "59 23 * * *")
+	c.Assert(ct.Entries[1].Command, Equals, "command -v debian-sa1 > /dev/null && debian-sa1 60 2")
+}
+
 func (s *CronSuite) TestAliases(c *C) {
 	c.Assert(getAliasExpression("@yearly"), Equals, YEARLY)
 	c.Assert(getAliasExpression("@annually"), Equals, ANNUALLY)
@@ -219,3 +797,726 @@ func (s *CronSuite) TestNearIndex(c *C) {
 	c.Assert(getNearPrevIndex(items, 6), Equals, 4)
 	c.Assert(getNearPrevIndex(items, 0), Equals, 7)
 }
+
+func (s *CronSuite) BenchmarkNext(c *C) {
+	e, _ := Parse("0 0 29 2 *")
+	t := time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < c.N; i++ {
+		e.Next(t)
+	}
+}
+
+func (s *CronSuite) BenchmarkNextBrute(c *C) {
+	e, _ := Parse("0 0 29 2 *")
+	t := time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < c.N; i++ {
+		bruteNext(e, t)
+	}
+}
+
+func (s *CronSuite) BenchmarkNextDense(c *C) {
+	e, _ := Parse("*/5 9-18 * * 1-5")
+	t := time.Date(2017, 3, 3, 17, 57, 0, 0, time.UTC)
+
+	for i := 0; i < c.N; i++ {
+		e.Next(t)
+	}
+}
+
+func (s *CronSuite) BenchmarkNextDenseBrute(c *C) {
+	e, _ := Parse("*/5 9-18 * * 1-5")
+	t := time.Date(2017, 3, 3, 17, 57, 0, 0, time.UTC)
+
+	for i := 0; i < c.N; i++ {
+		bruteNext(e, t)
+	}
+}
+
+func (s *CronSuite) BenchmarkPrev(c *C) {
+	e, _ := Parse("0 0 29 2 *")
+	t := time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < c.N; i++ {
+		e.Prev(t)
+	}
+}
+
+func (s *CronSuite) BenchmarkPrevBrute(c *C) {
+	e, _ := Parse("0 0 29 2 *")
+	t := time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < c.N; i++ {
+		brutePrev(e, t)
+	}
+}
+
+func (s *CronSuite) BenchmarkNextN(c *C) {
+	e, _ := Parse("CRON_TZ=Europe/Berlin 30 2 * * *")
+	t := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < c.N; i++ {
+		e.NextN(10, t)
+	}
+}
+
+func (s *CronSuite) TestScheduler(c *C) {
+	clock := newFakeClock(time.Date(2017, 1, 1, 0, 0, 30, 0, time.Local))
+	sc := NewScheduler()
+	sc.Clock = clock
+
+	runs := make(chan string, 10)
+
+	c.Assert(sc.Add("test1", "* * * * *", func() { runs <- "test1" }), IsNil)
+	c.Assert(sc.Add("test2", "*/2 * * * *", func() { runs <- "test2" }), IsNil)
+	c.Assert(sc.Add("test3", "* * * *", func() {}), NotNil)
+	c.Assert(sc.Add("test1", "* * * * *", func() {}), Equals, ErrJobExist)
+	c.Assert(sc.Jobs(), DeepEquals, []string{"test1", "test2"})
+	c.Assert(sc.NextRun("test2"), Equals, time.Date(2017, 1, 1, 0, 2, 0, 0, time.Local))
+	c.Assert(sc.NextRun("unknown").IsZero(), Equals, true)
+
+	c.Assert(sc.Start(), IsNil)
+	c.Assert(sc.Start(), Equals, ErrSchedulerRunning)
+
+	clock.waitWaiters(c, 1)
+	clock.Advance(30 * time.Second)
+
+	c.Assert(<-runs, Equals, "test1")
+
+	clock.waitWaiters(c, 1)
+	clock.Advance(time.Minute)
+
+	r1, r2 := <-runs, <-runs
+
+	c.Assert(r1 != r2, Equals, true)
+
+	c.Assert(sc.Remove("test2"), Equals, true)
+	c.Assert(sc.Remove("test2"), Equals, false)
+	c.Assert(sc.Jobs(), DeepEquals, []string{"test1"})
+
+	sc.Stop()
+	sc.Stop()
+
+	c.Assert(runs, HasLen, 0)
+}
+
+func (s *CronSuite) TestSchedulerReboot(c *C) {
+	clock := newFakeClock(time.Date(2017, 1, 1, 0, 0, 0, 0, time.Local))
+	sc := NewScheduler()
+	sc.Clock = clock
+
+	runs := make(chan bool, 10)
+
+	c.Assert(sc.Add("test", REBOOT, func() { runs <- true }), IsNil)
+	c.Assert(sc.Start(), IsNil)
+
+	<-runs
+
+	sc.Stop()
+
+	c.Assert(sc.Start(), IsNil)
+
+	sc.Stop()
+
+	c.Assert(runs, HasLen, 0)
+}
+
+func (s *CronSuite) TestSchedulerOverlap(c *C) {
+	expr, _ := Parse("* * * * *")
+
+	expected := map[int]int{
+		OVERLAP_SKIP:  1,
+		OVERLAP_QUEUE: 3,
+		OVERLAP_ALLOW: 3,
+	}
+
+	for overlap, runsNum := range expected {
+		clock := newFakeClock(time.Date(2017, 1, 1, 0, 0, 0, 0, time.Local))
+		sc := NewScheduler()
+		sc.Clock = clock
+
+		started := make(chan bool, 10)
+		release := make(chan bool)
+
+		var mu sync.Mutex
+		var runs int
+
+		c.Assert(sc.AddJob(&Job{
+			Name:    "test",
+			Expr:    expr,
+			Overlap: overlap,
+			Func: func() {
+				mu.Lock()
+				runs++
+				mu.Unlock()
+
+				started <- true
+				<-release
+			},
+		}), IsNil)
+
+		sc.Start()
+
+		for i := 0; i < 3; i++ {
+			clock.waitWaiters(c, 1)
+			clock.Advance(time.Minute)
+
+			if i == 0 || overlap == OVERLAP_ALLOW {
+				<-started
+			}
+		}
+
+		// Wait until last tick will be processed
+		clock.waitWaiters(c, 1)
+
+		for i := 0; i < runsNum; i++ {
+			release <- true
+		}
+
+		sc.Stop()
+
+		mu.Lock()
+		c.Assert(runs, Equals, runsNum, Commentf("Overlap policy: %d", overlap))
+		mu.Unlock()
+	}
+
+	c.Assert(NewScheduler().AddJob(&Job{Name: "test", Expr: expr, Func: func() {}, Overlap: 10}), Equals, ErrUnknownOverlap)
+	c.Assert(NewScheduler().AddJob(&Job{Expr: expr, Func: func() {}}), Equals, ErrEmptyJobName)
+	c.Assert(NewScheduler().AddJob(&Job{Name: "test", Func: func() {}}), Equals, ErrNilJobExpr)
+	c.Assert(NewScheduler().AddJob(&Job{Name: "test", Expr: expr}), Equals, ErrNilJobFunc)
+}
+
+func (s *CronSuite) TestSchedulerQueueRace(c *C) {
+	expr, _ := Parse("* * * * *")
+	clock := newFakeClock(time.Date(2017, 1, 1, 0, 0, 0, 0, time.Local))
+	sc := NewScheduler()
+	sc.Clock = clock
+
+	started := make(chan bool, 10)
+	release := make(chan bool)
+
+	c.Assert(sc.AddJob(&Job{
+		Name:    "test",
+		Expr:    expr,
+		Overlap: OVERLAP_QUEUE,
+		Func: func() {
+			started <- true
+			<-release
+		},
+	}), IsNil)
+
+	sc.Start()
+
+	clock.waitWaiters(c, 1)
+	clock.Advance(time.Minute)
+
+	// Every next tick fires while previous run is finishing, so it must be
+	// either queued or started as a new run, but never lost
+	for i := 0; i < 500; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			c.Fatalf("Queued run #%d was lost", i+1)
+		}
+
+		clock.waitWaiters(c, 1)
+
+		go func() { release <- true }()
+
+		clock.Advance(time.Minute)
+	}
+
+	<-started
+	release <- true
+
+	sc.Stop()
+
+	// Tick which happens after job function returned, but before run is
+	// finished, must be queued
+	sc = NewScheduler()
+	ji := &jobInfo{job: &Job{Name: "test", Expr: expr, Overlap: OVERLAP_QUEUE}, runs: 1}
+	stop := make(chan struct{})
+
+	sc.mu.Lock()
+	sc.runJob(ji, stop)
+	sc.mu.Unlock()
+
+	c.Assert(ji.pending, Equals, 1)
+	c.Assert(sc.finishRun(ji, stop), Equals, true)
+	c.Assert(ji.runs, Equals, 1)
+	c.Assert(sc.finishRun(ji, stop), Equals, false)
+	c.Assert(ji.runs, Equals, 0)
+
+	ji.runs, ji.pending = 1, 2
+	close(stop)
+
+	c.Assert(sc.finishRun(ji, stop), Equals, false)
+	c.Assert(ji.runs, Equals, 0)
+	c.Assert(ji.pending, Equals, 0)
+}
+
+func (s *CronSuite) TestSchedulerPanic(c *C) {
+	clock := newFakeClock(time.Date(2017, 1, 1, 0, 0, 0, 0, time.Local))
+	sc := NewScheduler()
+	sc.Clock = clock
+
+	panics := make(chan string, 10)
+
+	sc.PanicHandler = func(name string, panicData interface{}) {
+		panics <- name + ": " + panicData.(string)
+	}
+
+	sc.Add("test", "* * * * *", func() { panic("error") })
+	sc.Start()
+
+	for i := 0; i < 2; i++ {
+		clock.waitWaiters(c, 1)
+		clock.Advance(time.Minute)
+
+		// Job with OVERLAP_SKIP policy must be run again after panic
+		c.Assert(<-panics, Equals, "test: error")
+	}
+
+	sc.Stop()
+
+	logFile := c.MkDir() + "/cron.log"
+	logger, err := log.New(logFile, 0644)
+
+	c.Assert(err, IsNil)
+
+	globalLogger := log.Global
+	log.Global = logger
+
+	defer func() { log.Global = globalLogger }()
+
+	sc.PanicHandler = nil
+	sc.Start()
+
+	clock.waitWaiters(c, 1)
+	clock.Advance(time.Minute)
+	clock.waitWaiters(c, 1)
+
+	sc.Stop()
+
+	data, err := ioutil.ReadFile(logFile)
+
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Matches, `(?s).*Job test panicked: error\n.*`)
+}
+
+func (s *CronSuite) TestSchedulerWithoutConstructor(c *C) {
+	clock := newFakeClock(time.Date(2017, 1, 1, 0, 0, 0, 0, time.Local))
+	sc := &Scheduler{Clock: clock}
+
+	runs := make(chan bool, 10)
+
+	c.Assert(sc.Add("test", "* * * * *", func() { runs <- true }), IsNil)
+	c.Assert(sc.Jobs(), DeepEquals, []string{"test"})
+	c.Assert(sc.Start(), IsNil)
+
+	clock.waitWaiters(c, 1)
+	clock.Advance(time.Minute)
+
+	c.Assert(<-runs, Equals, true)
+
+	sc.Stop()
+
+	c.Assert(sc.Remove("test"), Equals, true)
+	c.Assert((&Scheduler{}).Jobs(), HasLen, 0)
+	c.Assert((&Scheduler{}).Remove("test"), Equals, false)
+}
+
+func (s *CronSuite) TestSchedulerJitterAndStop(c *C) {
+	expr, _ := Parse("* * * * *")
+
+	clock := newFakeClock(time.Date(2017, 1, 1, 0, 0, 0, 0, time.Local))
+	sc := NewScheduler()
+	sc.Clock = clock
+
+	runs := make(chan bool, 10)
+	release := make(chan bool)
+
+	sc.AddJob(&Job{
+		Name:   "test",
+		Expr:   expr,
+		Jitter: 30 * time.Second,
+		Func: func() {
+			runs <- true
+			<-release
+		},
+	})
+
+	sc.Start()
+
+	clock.waitWaiters(c, 1)
+	clock.Advance(time.Minute)
+
+	// Scheduler loop and delayed run
+	clock.waitWaiters(c, 2)
+
+	c.Assert(runs, HasLen, 0)
+
+	clock.Advance(30 * time.Second)
+
+	<-runs
+
+	stopped := make(chan bool)
+
+	go func() {
+		sc.Stop()
+		stopped <- true
+	}()
+
+	select {
+	case <-stopped:
+		c.Fatal("Scheduler stopped before job is finished")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	release <- true
+
+	<-stopped
+
+	// Delayed run must be cancelled on stop
+	c.Assert(sc.Start(), IsNil)
+
+	clock.waitWaiters(c, 1)
+	clock.Advance(time.Minute)
+	clock.waitWaiters(c, 2)
+
+	sc.Stop()
+
+	c.Assert(runs, HasLen, 0)
+
+	var sysClock systemClock
+
+	c.Assert(sysClock.Now().IsZero(), Equals, false)
+	timer := sysClock.NewTimer(time.Hour)
+	timer.Reset(time.Millisecond)
+
+	c.Assert(<-timer.C(), NotNil)
+
+	timer.Reset(time.Millisecond)
+	timer.Stop()
+
+	select {
+	case <-timer.C():
+		c.Fatal("Stopped timer fired")
+	case <-time.After(5 * time.Millisecond):
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func getTransitions(loc *time.Location, year int) []time.Time {
+	var result []time.Time
+
+	t := time.Date(year, 1, 1, 0, 0, 0, 0, loc)
+	_, offset := t.Zone()
+
+	for ; t.Year() == year; t = t.Add(15 * time.Minute) {
+		if _, o := t.Zone(); o != offset {
+			result = append(result, t)
+			offset = o
+		}
+	}
+
+	return result
+}
+
+// bruteNext is reference implementation of Expr.Next which checks all moments
+// one by one and doesn't use any search helpers of Expr
+func bruteNext(expr *Expr, t time.Time) time.Time {
+	loc, step := getBruteParams(expr, t)
+	maxYear := t.In(loc).Year() + 4
+
+	m := t.In(loc).Truncate(step).Add(step)
+
+	for m.Year() <= maxYear {
+		if !bruteDateMatch(expr, getNaiveTime(m)) {
+			y, mm, d := m.Date()
+			m = time.Date(y, mm, d+1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if bruteMatch(expr, m, step) {
+			return m.In(t.Location())
+		}
+
+		m = m.Add(step)
+	}
+
+	return time.Unix(0, 0)
+}
+
+// brutePrev is reference implementation of Expr.Prev which checks all moments
+// one by one and doesn't use any search helpers of Expr
+func brutePrev(expr *Expr, t time.Time) time.Time {
+	loc, step := getBruteParams(expr, t)
+	minYear := t.In(loc).Year() - 5
+
+	m := t.In(loc).Truncate(step)
+
+	if !m.Before(t) {
+		m = m.Add(-step)
+	}
+
+	for m.Year() >= minYear {
+		if !bruteDateMatch(expr, getNaiveTime(m)) {
+			y, mm, d := m.Date()
+			m = time.Date(y, mm, d, 0, 0, 0, 0, loc).Add(-step)
+			continue
+		}
+
+		if bruteMatch(expr, m, step) {
+			return m.In(t.Location())
+		}
+
+		m = m.Add(-step)
+	}
+
+	return time.Unix(0, 0)
+}
+
+// getBruteParams return location and step for brute force search
+func getBruteParams(expr *Expr, t time.Time) (*time.Location, time.Duration) {
+	loc, step := t.Location(), time.Minute
+
+	if expr.location != nil {
+		loc = expr.location
+	}
+
+	if expr.seconds != nil {
+		step = time.Second
+	}
+
+	return loc, step
+}
+
+// bruteMatch return true if expression must be run at given moment
+func bruteMatch(expr *Expr, m time.Time, step time.Duration) bool {
+	naive := getNaiveTime(m)
+
+	if bruteWallClockMatch(expr, naive) {
+		if !expr.fixedTime {
+			return true
+		}
+
+		// Fixed time job is run only at first occurrence of repeated time
+		for _, d := range []time.Duration{time.Hour, 30 * time.Minute} {
+			if getNaiveTime(m.Add(-d)).Equal(naive) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	if !expr.fixedTime {
+		return false
+	}
+
+	// Fixed time job which time was skipped due to DST transition is run at
+	// the first moment after transition
+	prev := m.Add(-step)
+	_, offset := m.Zone()
+	_, prevOffset := prev.Zone()
+
+	if offset <= prevOffset {
+		return false
+	}
+
+	for w := getNaiveTime(prev).Add(step); w.Before(naive); w = w.Add(step) {
+		if bruteDateMatch(expr, w) && bruteWallClockMatch(expr, w) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bruteWallClockMatch return true if time fields of given wall clock time
+// match for expression
+func bruteWallClockMatch(expr *Expr, w time.Time) bool {
+	if expr.seconds != nil && !expr.seconds.index[uint8(w.Second())] {
+		return false
+	}
+
+	if expr.seconds == nil && w.Second() != 0 {
+		return false
+	}
+
+	return expr.minutes.index[uint8(w.Minute())] && expr.hours.index[uint8(w.Hour())]
+}
+
+// bruteDateMatch return true if date of given wall clock time match for
+// expression
+func bruteDateMatch(expr *Expr, w time.Time) bool {
+	if !expr.months.index[uint8(w.Month())] {
+		return false
+	}
+
+	day, weekday := w.Day(), w.Weekday()
+	lastDay := time.Date(w.Year(), w.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	domMatch := expr.doms.index[uint8(day)]
+	dowMatch := expr.dows.index[uint8(weekday)]
+
+	for _, rule := range expr.doms.rules {
+		switch rule.kind {
+		case _RULE_LAST_DAY:
+			domMatch = domMatch || day == lastDay-int(rule.day)
+		case _RULE_LAST_WEEKDAY:
+			domMatch = domMatch || day == getBruteWeekday(w, lastDay, lastDay, -1)
+		case _RULE_NEAREST_WEEKDAY:
+			if int(rule.day) <= lastDay {
+				domMatch = domMatch || day == getBruteWeekday(w, int(rule.day), lastDay, 0)
+			}
+		}
+	}
+
+	for _, rule := range expr.dows.rules {
+		switch rule.kind {
+		case _RULE_LAST_DOW:
+			dowMatch = dowMatch || (uint8(weekday) == rule.day && day > lastDay-7)
+		case _RULE_NTH_DOW:
+			dowMatch = dowMatch || (uint8(weekday) == rule.day && day > int(rule.num-1)*7 && day <= int(rule.num)*7)
+		}
+	}
+
+	return domMatch && dowMatch
+}
+
+// getBruteWeekday return weekday nearest to given day in the same month. If
+// direction is negative, only previous days are checked.
+func getBruteWeekday(w time.Time, day, lastDay, direction int) int {
+	isWeekday := func(d int) bool {
+		wd := time.Date(w.Year(), w.Month(), d, 0, 0, 0, 0, time.UTC).Weekday()
+		return wd != time.Saturday && wd != time.Sunday
+	}
+
+	for delta := 0; delta < 3; delta++ {
+		for _, d := range []int{day - delta, day + delta} {
+			if d < 1 || d > lastDay || (direction < 0 && d > day) {
+				continue
+			}
+
+			if isWeekday(d) {
+				return d
+			}
+		}
+	}
+
+	return -1
+}
+
+// getNaiveTime return UTC time with the same wall clock time as given moment
+func getNaiveTime(m time.Time) time.Time {
+	return time.Date(m.Year(), m.Month(), m.Day(), m.Hour(), m.Minute(), m.Second(), 0, time.UTC)
+}
+
+type fakeClock struct {
+	now     time.Time
+	waiters []*fakeTimer
+	mu      sync.Mutex
+}
+
+type fakeTimer struct {
+	clock *fakeClock
+	at    time.Time
+	ch    chan time.Time
+}
+
+func newFakeClock(t time.Time) *fakeClock {
+	return &fakeClock{now: t}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: c, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	var waiters []*fakeTimer
+
+	for _, t := range c.waiters {
+		if t.at.After(c.now) {
+			waiters = append(waiters, t)
+		} else {
+			t.ch <- c.now
+		}
+	}
+
+	c.waiters = waiters
+}
+
+func (c *fakeClock) removeWaiter(t *fakeTimer) {
+	var waiters []*fakeTimer
+
+	for _, w := range c.waiters {
+		if w != t {
+			waiters = append(waiters, w)
+		}
+	}
+
+	c.waiters = waiters
+
+	select {
+	case <-t.ch:
+	default:
+	}
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Reset(d time.Duration) {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.clock.removeWaiter(t)
+	t.at = t.clock.now.Add(d)
+
+	if d <= 0 {
+		t.ch <- t.clock.now
+	} else {
+		t.clock.waiters = append(t.clock.waiters, t)
+	}
+}
+
+func (t *fakeTimer) Stop() {
+	t.clock.mu.Lock()
+	t.clock.removeWaiter(t)
+	t.clock.mu.Unlock()
+}
+
+func (c *fakeClock) waitWaiters(cc *C, num int) {
+	for i := 0; i < 1000; i++ {
+		c.mu.Lock()
+		count := len(c.waiters)
+		c.mu.Unlock()
+
+		if count >= num {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	cc.Fatalf("Timeout while waiting for %d clock waiters", num)
+}
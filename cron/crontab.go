@@ -0,0 +1,251 @@
+package cron
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Crontab contains crontab file data
+type Crontab struct {
+	Env     map[string]string // Environment variables defined in crontab
+	Entries []*Entry          // Crontab entries
+	Errors  []error           // Errors for lines which can't be parsed
+}
+
+// Entry contains crontab entry data
+type Entry struct {
+	Expr    *Expr             // Cron expression
+	User    string            // User name (only for system crontabs)
+	Command string            // Command
+	Input   string            // Data for command stdin (text after first "%" symbol)
+	Env     map[string]string // Environment variables defined before entry
+	Line    int               // Line number in crontab file
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ErrMalformedCrontab is returned if some crontab lines can't be parsed
+var ErrMalformedCrontab = errors.New("Crontab contains malformed lines")
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ReadCrontab read and parse crontab file, system crontabs (/etc/crontab and
+// files in /etc/cron.d) contain user name after expression
+func ReadCrontab(file string, system bool) (*Crontab, error) {
+	data, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseCrontab(data, system)
+}
+
+// ParseCrontab parse crontab data. Malformed lines are skipped, if there are
+// such lines, result contains all valid entries, errors for malformed lines
+// are stored in Errors field and ErrMalformedCrontab is returned.
+//
+// As in cron, "%" symbols in command are replaced by newlines and all data
+// after the first one is stored in Input field. Use "\%" for literal "%".
+func ParseCrontab(data []byte, system bool) (*Crontab, error) {
+	result := &Crontab{Env: make(map[string]string)}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	var lineNum int
+
+	for scanner.Scan() {
+		lineNum++
+
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if name, value, ok := parseEnvAssignment(line); ok {
+			result.Env[name] = value
+			continue
+		}
+
+		entry, err := parseCrontabEntry(line, system, result.Env)
+
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("Can't parse line %d: %v", lineNum, err))
+			continue
+		}
+
+		entry.Line = lineNum
+		result.Entries = append(result.Entries, entry)
+	}
+
+	if scanner.Err() != nil {
+		return nil, scanner.Err()
+	}
+
+	if len(result.Errors) != 0 {
+		return result, ErrMalformedCrontab
+	}
+
+	return result, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseCrontabEntry parse line with cron expression, user and command
+func parseCrontabEntry(line string, system bool, env map[string]string) (*Entry, error) {
+	fieldsNum := 5
+
+	switch {
+	case strings.HasPrefix(line, "@every ") || strings.HasPrefix(line, "@every\t"):
+		fieldsNum = 2
+	case strings.HasPrefix(line, "@"):
+		fieldsNum = 1
+	}
+
+	if system {
+		fieldsNum++
+	}
+
+	fields := splitFields(line, fieldsNum)
+
+	if len(fields) != fieldsNum+1 {
+		return nil, fmt.Errorf("Line must contain expression%s and command", getUserHint(system))
+	}
+
+	var user string
+
+	command := fields[fieldsNum]
+	fields = fields[:fieldsNum]
+
+	if system {
+		user, fields = fields[fieldsNum-1], fields[:fieldsNum-1]
+	}
+
+	exprData := strings.Join(fields, " ")
+
+	if env["CRON_TZ"] != "" {
+		exprData = "CRON_TZ=" + env["CRON_TZ"] + " " + exprData
+	}
+
+	expr, err := Parse(exprData)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entryEnv := make(map[string]string)
+
+	for k, v := range env {
+		entryEnv[k] = v
+	}
+
+	command, input := parseCommand(command)
+
+	return &Entry{
+		Expr:    expr,
+		User:    user,
+		Command: command,
+		Input:   input,
+		Env:     entryEnv,
+	}, nil
+}
+
+// parseCommand split command to command itself and data for stdin
+func parseCommand(command string) (string, string) {
+	var result []byte
+	var inputIndex = -1
+
+	for i := 0; i < len(command); i++ {
+		switch {
+		case command[i] == '\\' && i+1 < len(command) && command[i+1] == '%':
+			result = append(result, '%')
+			i++
+		case command[i] == '%' && inputIndex == -1:
+			inputIndex = len(result)
+		case command[i] == '%':
+			result = append(result, '\n')
+		default:
+			result = append(result, command[i])
+		}
+	}
+
+	if inputIndex == -1 {
+		return string(result), ""
+	}
+
+	return string(result[:inputIndex]), string(result[inputIndex:])
+}
+
+// parseEnvAssignment parse environment variable assignment (NAME=value)
+func parseEnvAssignment(line string) (string, string, bool) {
+	index := strings.Index(line, "=")
+
+	if index <= 0 {
+		return "", "", false
+	}
+
+	name := strings.TrimSpace(line[:index])
+
+	if name == "" || strings.ContainsAny(name, " \t*@") {
+		return "", "", false
+	}
+
+	value := strings.TrimSpace(line[index+1:])
+
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+
+	return name, value, true
+}
+
+// splitFields split line to given number of fields and the rest of line
+func splitFields(line string, num int) []string {
+	var result []string
+
+	for i := 0; i < num; i++ {
+		line = strings.TrimLeft(line, " \t")
+		index := strings.IndexAny(line, " \t")
+
+		if index == -1 {
+			if line != "" {
+				result = append(result, line)
+			}
+
+			return result
+		}
+
+		result = append(result, line[:index])
+		line = line[index:]
+	}
+
+	line = strings.TrimSpace(line)
+
+	if line != "" {
+		result = append(result, line)
+	}
+
+	return result
+}
+
+// getUserHint return hint about user field for error message
+func getUserHint(system bool) string {
+	if system {
+		return ", user"
+	}
+
+	return ""
+}
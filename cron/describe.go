@@ -0,0 +1,339 @@
+package cron
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"pkg.re/essentialkaos/ek.v9/pluralize"
+	"pkg.re/essentialkaos/ek.v9/timeutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var ordinals = []string{"", "first", "second", "third", "fourth", "fifth"}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Description return English description of expression
+// (e.g. "at 04:05 on every Monday")
+func (expr *Expr) Description() string {
+	var result string
+
+	switch {
+	case expr.reboot:
+		result = "at startup"
+	case expr.every != 0:
+		result = "every " + timeutil.PrettyDuration(expr.every)
+	default:
+		result = expr.describeTime()
+
+		for _, desc := range []string{expr.describeDays(), expr.describeMonths()} {
+			if desc != "" {
+				result += " " + desc
+			}
+		}
+	}
+
+	if expr.location != nil {
+		result += " (" + expr.location.String() + ")"
+	}
+
+	return result
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// describeTime return description of seconds, minutes and hours fields
+func (expr *Expr) describeTime() string {
+	tokens := expr.tokens
+	second := "0"
+
+	if expr.seconds != nil {
+		second, tokens = tokens[0], tokens[1:]
+	}
+
+	minute, hour := tokens[0], tokens[1]
+
+	if isNumberToken(second) && isNumberToken(minute) && isNumberListToken(hour) {
+		var times []string
+
+		for _, h := range strings.Split(hour, _SYMBOL_ENUM) {
+			t := fmt.Sprintf("%02d:%02d", str2uint(h), str2uint(minute))
+
+			if expr.seconds != nil && str2uint(second) != 0 {
+				t += fmt.Sprintf(":%02d", str2uint(second))
+			}
+
+			times = append(times, t)
+		}
+
+		return "at " + joinList(times)
+	}
+
+	var result []string
+	var prevIsValue bool
+
+	fields := [][2]string{{minute, "minute"}, {hour, "hour"}}
+
+	if expr.seconds != nil && second != "0" {
+		fields = append([][2]string{{second, "second"}}, fields...)
+	}
+
+	for index, field := range fields {
+		isWildcard := isAnyToken(field[0]) || isIntervalToken(field[0])
+
+		// Skip wildcard fields if previous field is wildcard too
+		// ("every 15 minutes" instead of "every 15 minutes of every hour")
+		if index != 0 && isAnyToken(field[0]) && !prevIsValue {
+			continue
+		}
+
+		desc := describeField(field[0], field[1], _NAMES_NONE)
+
+		if index != 0 {
+			desc = strings.TrimPrefix(desc, "at ")
+		}
+
+		result = append(result, desc)
+		prevIsValue = !isWildcard
+	}
+
+	return strings.Join(result, " of ")
+}
+
+// describeDays return description of days of month and days of week fields
+func (expr *Expr) describeDays() string {
+	var result []string
+
+	tokens := expr.tokens[len(expr.tokens)-5:]
+	dom, dow := tokens[2], tokens[4]
+
+	if !isAnyToken(dom) {
+		result = append(result, describeDoms(dom))
+	}
+
+	if !isAnyToken(dow) {
+		result = append(result, describeDows(dow))
+	}
+
+	return strings.Join(result, " and ")
+}
+
+// describeMonths return description of months field
+func (expr *Expr) describeMonths() string {
+	month := expr.tokens[len(expr.tokens)-2]
+
+	switch {
+	case isAnyToken(month):
+		return ""
+	case isIntervalToken(month):
+		return describeInterval(month, "month", "months", _NAMES_MONTHS)
+	}
+
+	return "in " + joinList(describeItems(month, _NAMES_MONTHS))
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// describeField return description of seconds, minutes or hours field
+func describeField(token, unit string, nt uint8) string {
+	switch {
+	case isAnyToken(token):
+		return "every " + unit
+	case isIntervalToken(token):
+		return describeInterval(token, unit, unit+"s", nt)
+	case !isEnumToken(token) && isPeriodToken(token):
+		ts, te := getPeriodFromToken(token, nt)
+		return fmt.Sprintf("every %s from %d through %d", unit, ts, te)
+	case isEnumToken(token):
+		return "at " + unit + "s " + joinList(describeItems(token, nt))
+	}
+
+	return "at " + unit + " " + token
+}
+
+// describeDoms return description of days of month field
+func describeDoms(token string) string {
+	if isIntervalToken(token) {
+		return describeInterval(token, "day", "days", _NAMES_NONE) + " of the month"
+	}
+
+	var days, items []string
+
+	for _, t := range strings.Split(token, _SYMBOL_ENUM) {
+		rule, ok, _ := parseDayRule(t, _FIELD_DOMS)
+
+		if !ok {
+			days = append(days, describeItems(t, _NAMES_NONE)...)
+			continue
+		}
+
+		switch rule.kind {
+		case _RULE_LAST_DAY:
+			if rule.day == 0 {
+				items = append(items, "the last day")
+			} else {
+				items = append(items, pluralize.Pluralize(int(rule.day), "day", "days")+" before the last day")
+			}
+		case _RULE_LAST_WEEKDAY:
+			items = append(items, "the last weekday")
+		case _RULE_NEAREST_WEEKDAY:
+			items = append(items, fmt.Sprintf("the weekday nearest to day %d", rule.day))
+		}
+	}
+
+	switch len(days) {
+	case 0:
+		// only rules
+	case 1:
+		if strings.Contains(days[0], " ") {
+			items = append([]string{"days " + days[0]}, items...)
+		} else {
+			items = append([]string{"day " + days[0]}, items...)
+		}
+	default:
+		items = append([]string{"days " + joinList(days)}, items...)
+	}
+
+	return "on " + joinList(items) + " of the month"
+}
+
+// describeDows return description of days of week field
+func describeDows(token string) string {
+	if isIntervalToken(token) {
+		return describeInterval(token, "day", "days", _NAMES_DAYS) + " of the week"
+	}
+
+	var days, rules []string
+
+	for _, t := range strings.Split(token, _SYMBOL_ENUM) {
+		if strings.ToUpper(t) == _SYMBOL_LAST {
+			t = "6"
+		}
+
+		rule, ok, _ := parseDayRule(t, _FIELD_DOWS)
+
+		if !ok {
+			days = append(days, describeItems(t, _NAMES_DAYS)...)
+			continue
+		}
+
+		switch rule.kind {
+		case _RULE_LAST_DOW:
+			rules = append(rules, "the last "+getDayName(rule.day))
+		case _RULE_NTH_DOW:
+			rules = append(rules, "the "+ordinals[rule.num]+" "+getDayName(rule.day))
+		}
+	}
+
+	var result []string
+
+	if len(days) != 0 {
+		result = append(result, "on every "+joinList(days))
+	}
+
+	if len(rules) != 0 {
+		result = append(result, "on "+joinList(rules)+" of the month")
+	}
+
+	return strings.Join(result, " and ")
+}
+
+// describeInterval return description of interval with optional range or
+// start value (*/n, a-b/n or a/n)
+func describeInterval(token, unit, units string, nt uint8) string {
+	ts := strings.Split(token, _SYMBOL_INTERVAL)
+	result := pluralizeEvery(getIntervalFromToken(token), unit, units)
+
+	switch {
+	case isAnyToken(ts[0]):
+		return result
+	case isPeriodToken(ts[0]):
+		start, end := getPeriodFromToken(ts[0], nt)
+		return result + " from " + getValueName(start, nt) + " through " + getValueName(end, nt)
+	}
+
+	return result + " starting at " + getValueName(parseToken(ts[0], nt), nt)
+}
+
+// describeItems return descriptions of values and periods in field
+func describeItems(token string, nt uint8) []string {
+	var result []string
+
+	for _, t := range strings.Split(token, _SYMBOL_ENUM) {
+		if isPeriodToken(t) {
+			ts, te := getPeriodFromToken(t, nt)
+			result = append(result, getValueName(ts, nt)+" through "+getValueName(te, nt))
+		} else {
+			result = append(result, getValueName(parseToken(t, nt), nt))
+		}
+	}
+
+	return result
+}
+
+// getValueName return name of day of week or month
+func getValueName(value, nt uint8) string {
+	switch nt {
+	case _NAMES_DAYS:
+		return getDayName(value)
+	case _NAMES_MONTHS:
+		if value >= 1 && value <= 12 {
+			return time.Month(value).String()
+		}
+	}
+
+	return strconv.Itoa(int(value))
+}
+
+// getDayName return name of day of week
+func getDayName(day uint8) string {
+	return time.Weekday(day % 7).String()
+}
+
+// pluralizeEvery return "every unit" or "every N units"
+func pluralizeEvery(n uint8, single, plural string) string {
+	if n == 1 {
+		return "every " + single
+	}
+
+	return "every " + pluralize.Pluralize(int(n), single, plural)
+}
+
+// joinList join items as English list ("a, b and c")
+func joinList(items []string) string {
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	}
+
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
+
+// isNumberToken return true if token is a number
+func isNumberToken(t string) bool {
+	_, err := strconv.ParseUint(t, 10, 8)
+	return err == nil
+}
+
+// isNumberListToken return true if token is a number or list of numbers
+func isNumberListToken(t string) bool {
+	for _, tt := range strings.Split(t, _SYMBOL_ENUM) {
+		if !isNumberToken(tt) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,518 @@
+package cron
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"errors"
+	"math/rand"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"pkg.re/essentialkaos/ek.v9/log"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// OVERLAP_SKIP skip run if previous run of job is still running
+// OVERLAP_QUEUE run job after previous run is finished
+// OVERLAP_ALLOW run job concurrently with previous runs
+const (
+	OVERLAP_SKIP  = 0
+	OVERLAP_QUEUE = 1
+	OVERLAP_ALLOW = 2
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Clock is time source used by scheduler
+type Clock interface {
+	// Now return current time
+	Now() time.Time
+
+	// NewTimer create new timer which will send the current time on its
+	// channel after given duration
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is timer created by clock
+type Timer interface {
+	// C return channel for timer events
+	C() <-chan time.Time
+
+	// Reset change timer to expire after given duration, events of previous
+	// timer run are dropped
+	Reset(d time.Duration)
+
+	// Stop prevent the timer from firing
+	Stop()
+}
+
+// Job contains job info
+type Job struct {
+	Name    string        // Unique job name
+	Expr    *Expr         // Cron expression
+	Func    func()        // Job function
+	Overlap int           // Overlap policy (OVERLAP_SKIP by default)
+	Jitter  time.Duration // Maximum random delay before run
+}
+
+// Scheduler is cron jobs scheduler
+type Scheduler struct {
+	Clock        Clock                                    // Time source (system clock if nil)
+	PanicHandler func(name string, panicData interface{}) // Handler for panics in job functions (panics are logged to global logger if nil)
+
+	jobs     map[string]*jobInfo
+	wake     chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	running  bool
+	runWG    sync.WaitGroup
+	initOnce sync.Once
+	mu       sync.Mutex
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type jobInfo struct {
+	job     *Job
+	next    time.Time // time of next run (zero if there is no next run)
+	runs    int       // number of running job instances
+	pending int       // number of queued runs
+	reboot  bool      // true if @reboot job must be run
+}
+
+type systemClock struct{}
+
+type systemTimer struct {
+	timer *time.Timer
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Errors
+var (
+	ErrEmptyJobName     = errors.New("Job name can't be empty")
+	ErrNilJobExpr       = errors.New("Job expression is nil")
+	ErrNilJobFunc       = errors.New("Job function is nil")
+	ErrUnknownOverlap   = errors.New("Unknown overlap policy")
+	ErrJobExist         = errors.New("Job with same name already exist")
+	ErrSchedulerRunning = errors.New("Scheduler is already running")
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// NewScheduler create new scheduler
+func NewScheduler() *Scheduler {
+	s := &Scheduler{}
+	s.init()
+
+	return s
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Add add job with given name, expression and function
+func (s *Scheduler) Add(name, expr string, fn func()) error {
+	e, err := Parse(expr)
+
+	if err != nil {
+		return err
+	}
+
+	return s.AddJob(&Job{Name: name, Expr: e, Func: fn})
+}
+
+// AddJob add job to scheduler
+func (s *Scheduler) AddJob(job *Job) error {
+	switch {
+	case job.Name == "":
+		return ErrEmptyJobName
+	case job.Expr == nil:
+		return ErrNilJobExpr
+	case job.Func == nil:
+		return ErrNilJobFunc
+	}
+
+	switch job.Overlap {
+	case OVERLAP_SKIP, OVERLAP_QUEUE, OVERLAP_ALLOW:
+		// ok
+	default:
+		return ErrUnknownOverlap
+	}
+
+	s.init()
+	s.mu.Lock()
+
+	if s.jobs[job.Name] != nil {
+		s.mu.Unlock()
+		return ErrJobExist
+	}
+
+	s.jobs[job.Name] = &jobInfo{
+		job:    job,
+		next:   getNextRun(job.Expr, s.now()),
+		reboot: job.Expr.reboot,
+	}
+
+	s.mu.Unlock()
+
+	s.wakeUp()
+
+	return nil
+}
+
+// Remove remove job with given name, running instances of job are not
+// interrupted
+func (s *Scheduler) Remove(name string) bool {
+	s.mu.Lock()
+
+	_, ok := s.jobs[name]
+
+	delete(s.jobs, name)
+
+	s.mu.Unlock()
+
+	if ok {
+		s.wakeUp()
+	}
+
+	return ok
+}
+
+// Jobs return sorted slice with names of all jobs
+func (s *Scheduler) Jobs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []string
+
+	for name := range s.jobs {
+		result = append(result, name)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// NextRun return time of next run of job with given name (zero time if job
+// doesn't exist or will never run)
+func (s *Scheduler) NextRun(name string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ji := s.jobs[name]
+
+	if ji == nil {
+		return time.Time{}
+	}
+
+	return ji.next
+}
+
+// Start start scheduler
+func (s *Scheduler) Start() error {
+	s.init()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return ErrSchedulerRunning
+	}
+
+	s.running = true
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	// Drop notifications about jobs added before start
+	select {
+	case <-s.wake:
+	default:
+	}
+
+	// Skip runs missed while scheduler was stopped
+	now := s.now()
+
+	for _, ji := range s.jobs {
+		ji.next = getNextRun(ji.job.Expr, now)
+	}
+
+	go s.loop(s.stop, s.done)
+
+	return nil
+}
+
+// Stop stop scheduler and wait until all running jobs will be finished,
+// queued and delayed (by jitter) runs are cancelled
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+
+	s.running = false
+	close(s.stop)
+	done := s.done
+
+	s.mu.Unlock()
+
+	<-done
+
+	s.runWG.Wait()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Now return current system time
+func (c systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer create new system timer
+func (c systemClock) NewTimer(d time.Duration) Timer {
+	return &systemTimer{time.NewTimer(d)}
+}
+
+// C return channel for timer events
+func (t *systemTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+// Reset change timer to expire after given duration
+func (t *systemTimer) Reset(d time.Duration) {
+	t.Stop()
+	t.timer.Reset(d)
+}
+
+// Stop prevent the timer from firing
+func (t *systemTimer) Stop() {
+	if !t.timer.Stop() {
+		select {
+		case <-t.timer.C:
+		default:
+		}
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// loop run jobs until scheduler is stopped
+func (s *Scheduler) loop(stop, done chan struct{}) {
+	var timer Timer
+
+	defer close(done)
+
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		s.mu.Lock()
+
+		now := s.now()
+
+		var nearest time.Time
+
+		for _, ji := range s.jobs {
+			// @reboot jobs are run only once after scheduler start
+			if ji.reboot {
+				s.runJob(ji, stop)
+				ji.reboot = false
+			}
+
+			if ji.next.IsZero() {
+				continue
+			}
+
+			if !ji.next.After(now) {
+				s.runJob(ji, stop)
+				ji.next = getNextRun(ji.job.Expr, now)
+
+				if ji.next.IsZero() {
+					continue
+				}
+			}
+
+			if nearest.IsZero() || ji.next.Before(nearest) {
+				nearest = ji.next
+			}
+		}
+
+		var timerC <-chan time.Time
+
+		switch {
+		case !nearest.IsZero() && timer == nil:
+			timer = s.clock().NewTimer(nearest.Sub(now))
+			timerC = timer.C()
+		case !nearest.IsZero():
+			timer.Reset(nearest.Sub(now))
+			timerC = timer.C()
+		case timer != nil:
+			timer.Stop()
+		}
+
+		s.mu.Unlock()
+
+		select {
+		case <-timerC:
+		case <-s.wake:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// runJob start job in a separate goroutine according to job overlap
+// policy (scheduler must be locked)
+func (s *Scheduler) runJob(ji *jobInfo, stop chan struct{}) {
+	if ji.runs != 0 {
+		switch ji.job.Overlap {
+		case OVERLAP_SKIP:
+			return
+		case OVERLAP_QUEUE:
+			ji.pending++
+			return
+		}
+	}
+
+	var delay time.Duration
+
+	if ji.job.Jitter > 0 {
+		delay = time.Duration(rand.Int63n(int64(ji.job.Jitter)))
+	}
+
+	ji.runs++
+	s.runWG.Add(1)
+
+	go s.execJob(ji, delay, stop)
+}
+
+// execJob execute job function
+func (s *Scheduler) execJob(ji *jobInfo, delay time.Duration, stop chan struct{}) {
+	defer s.runWG.Done()
+
+	if delay > 0 {
+		timer := s.clock().NewTimer(delay)
+
+		select {
+		case <-timer.C():
+		case <-stop:
+			timer.Stop()
+			s.finishRun(ji, stop)
+			return
+		}
+	}
+
+	for {
+		s.callJob(ji.job)
+
+		if !s.finishRun(ji, stop) {
+			return
+		}
+	}
+}
+
+// finishRun mark job run as finished and return true if there is queued run
+// which must be executed right now
+func (s *Scheduler) finishRun(ji *jobInfo, stop chan struct{}) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if isClosed(stop) {
+		ji.pending = 0
+	}
+
+	if ji.pending != 0 {
+		ji.pending--
+		return true
+	}
+
+	// Runs counter must be decreased in the same critical section where we
+	// check queue, otherwise tick which happens in between will be lost
+	ji.runs--
+
+	return false
+}
+
+// callJob call job function and recover panic if it happens
+func (s *Scheduler) callJob(job *Job) {
+	defer func() {
+		r := recover()
+
+		switch {
+		case r == nil:
+			return
+		case s.PanicHandler != nil:
+			s.PanicHandler(job.Name, r)
+		default:
+			log.Error("Job %s panicked: %v\n%s", job.Name, r, debug.Stack())
+		}
+	}()
+
+	job.Func()
+}
+
+// init initialize scheduler internal data, it allows using scheduler created
+// without NewScheduler
+func (s *Scheduler) init() {
+	s.initOnce.Do(func() {
+		s.jobs = make(map[string]*jobInfo)
+		s.wake = make(chan struct{}, 1)
+	})
+}
+
+// wakeUp notify scheduler loop about jobs changes
+func (s *Scheduler) wakeUp() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// now return current time
+func (s *Scheduler) now() time.Time {
+	return s.clock().Now()
+}
+
+// clock return scheduler clock
+func (s *Scheduler) clock() Clock {
+	if s.Clock == nil {
+		return systemClock{}
+	}
+
+	return s.Clock
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getNextRun return time of next run or zero time if there is no next run
+func getNextRun(expr *Expr, t time.Time) time.Time {
+	next := expr.Next(t)
+
+	if next.Equal(time.Unix(0, 0)) {
+		return time.Time{}
+	}
+
+	return next
+}
+
+// isClosed return true if given channel is closed
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,220 @@
+package fmtc
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"pkg.re/essentialkaos/ek.v9/color"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Color depths
+const (
+	DEPTH_NONE      uint8 = 0 // No colors
+	DEPTH_16              = 1 // 16 basic colors
+	DEPTH_256             = 2 // 256-color palette
+	DEPTH_TRUECOLOR       = 3 // 24-bit colors
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// basicPalette contains RGB values of 16 basic colors (xterm defaults)
+var basicPalette = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// cubeLevels contains values of color components used in 6x6x6 color cube
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// detectColorDepth return color depth supported by terminal based on
+// environment variables
+func detectColorDepth() uint8 {
+	if os.Getenv("NO_COLOR") != "" {
+		return DEPTH_NONE
+	}
+
+	term := strings.ToLower(os.Getenv("TERM"))
+	colorTerm := strings.ToLower(os.Getenv("COLORTERM"))
+
+	switch {
+	case term == "dumb":
+		return DEPTH_NONE
+	case colorTerm == "truecolor", colorTerm == "24bit",
+		strings.Contains(term, "truecolor"),
+		strings.Contains(term, "24bit"),
+		strings.HasSuffix(term, "-direct"):
+		return DEPTH_TRUECOLOR
+	case strings.Contains(term, "256color"):
+		return DEPTH_256
+	}
+
+	return DEPTH_16
+}
+
+// isValidExtColor return true if given string is palette index (0-255) or
+// 24-bit color in hex format
+func isValidExtColor(value string) bool {
+	_, _, ok := parseExtColor(value)
+	return ok
+}
+
+// parseExtColor parse extended color and return palette index (-1 for 24-bit
+// colors) and 24-bit color
+func parseExtColor(value string) (int, int, bool) {
+	switch len(value) {
+	case 1, 2, 3:
+		index, err := strconv.Atoi(value)
+
+		if err != nil || index < 0 || index > 255 || value[0] == '+' {
+			return 0, 0, false
+		}
+
+		return index, 0, true
+
+	case 6:
+		hex, err := strconv.ParseUint(value, 16, 32)
+
+		if err != nil {
+			return 0, 0, false
+		}
+
+		return -1, int(hex), true
+	}
+
+	return 0, 0, false
+}
+
+// getExtColorCode return ANSI code for extended color downgraded to given depth
+func getExtColorCode(value string, bg bool, depth uint8) string {
+	index, hex, _ := parseExtColor(value)
+
+	prefix := "38"
+
+	if bg {
+		prefix = "48"
+	}
+
+	if index == -1 {
+		r, g, b := color.Hex2RGB(hex)
+
+		if depth == DEPTH_TRUECOLOR {
+			return fmt.Sprintf("%s;2;%d;%d;%d", prefix, r, g, b)
+		}
+
+		index = rgb2Palette(r, g, b)
+	}
+
+	if depth != DEPTH_16 {
+		return fmt.Sprintf("%s;5;%d", prefix, index)
+	}
+
+	index = palette2Basic(index)
+
+	code := 30 + index
+
+	if index >= 8 {
+		code = 90 + index - 8
+	}
+
+	if bg {
+		code += 10
+	}
+
+	return strconv.Itoa(code)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// rgb2Palette return index of the nearest color from 256-color palette
+func rgb2Palette(r, g, b int) int {
+	ri, gi, bi := getCubeIndex(r), getCubeIndex(g), getCubeIndex(b)
+	cubeIndex := 16 + 36*ri + 6*gi + bi
+	cubeDist := getDistance(r, g, b, cubeLevels[ri], cubeLevels[gi], cubeLevels[bi])
+
+	gray := (r + g + b) / 3
+	grayIndex := 23
+
+	if gray < 238 {
+		grayIndex = (gray - 3) / 10
+
+		if grayIndex < 0 {
+			grayIndex = 0
+		}
+	}
+
+	grayLevel := 8 + grayIndex*10
+	grayDist := getDistance(r, g, b, grayLevel, grayLevel, grayLevel)
+
+	if grayDist < cubeDist {
+		return 232 + grayIndex
+	}
+
+	return cubeIndex
+}
+
+// palette2RGB return RGB values for color from 256-color palette
+func palette2RGB(index int) (int, int, int) {
+	switch {
+	case index < 16:
+		return basicPalette[index][0], basicPalette[index][1], basicPalette[index][2]
+	case index < 232:
+		index -= 16
+		return cubeLevels[index/36], cubeLevels[(index/6)%6], cubeLevels[index%6]
+	}
+
+	level := 8 + (index-232)*10
+
+	return level, level, level
+}
+
+// palette2Basic return index of the nearest basic color for color from
+// 256-color palette
+func palette2Basic(index int) int {
+	if index < 16 {
+		return index
+	}
+
+	r, g, b := palette2RGB(index)
+
+	var result, minDist int
+
+	for i, c := range basicPalette {
+		dist := getDistance(r, g, b, c[0], c[1], c[2])
+
+		if i == 0 || dist < minDist {
+			result, minDist = i, dist
+		}
+	}
+
+	return result
+}
+
+func getCubeIndex(v int) int {
+	switch {
+	case v < 48:
+		return 0
+	case v < 115:
+		return 1
+	}
+
+	return (v - 35) / 40
+}
+
+func getDistance(r1, g1, b1, r2, g2, b2 int) int {
+	return (r1-r2)*(r1-r2) + (g1-g2)*(g1-g2) + (b1-b2)*(b1-b2)
+}
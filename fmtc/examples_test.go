@@ -65,6 +65,15 @@ func ExamplePrintln() {
 	// many tags at once
 	// underline, cyan text with the red background
 	Println("{cR_}text{!}")
+
+	// colors from 256-color palette (foreground and background)
+	Println("{#214}orange{!}")
+	Println("{%17}dark blue background{!}")
+
+	// 24-bit colors in hex format (converted to the nearest supported
+	// color if terminal doesn't support 24-bit colors)
+	Println("{*#ff5f00}bold orange{!}")
+	Println("{#ffffff%005f87}white text on blue background{!}")
 }
 
 func ExamplePrintf() {
@@ -80,6 +89,33 @@ func ExamplePrintf() {
 	}
 }
 
+func ExampleAddStyle() {
+	// add custom style
+	AddStyle("important", "{*_r}")
+
+	// override default style used for hints
+	AddStyle(STYLE_HINT, "{#244}")
+
+	Println("{?important}Important message{!}")
+	Println("{?hint}Some hint{!}")
+}
+
+func ExampleLink() {
+	// print hyperlink (if output isn't a terminal, URL will be printed
+	// after text)
+	Printf("Visit our {*}%s{!}\n", Link("https://essentialkaos.com", "website"))
+
+	// the same using tags
+	Println("Visit our {=https://essentialkaos.com}website{=}")
+}
+
+func ExampleSetTitle() {
+	SetTitle("My App")
+
+	// restore original title before exit
+	defer RestoreTitle()
+}
+
 func ExampleBell() {
 	// terminal bell
 	Bell()
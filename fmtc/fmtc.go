@@ -13,6 +13,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"strings"
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -30,6 +32,15 @@ type T struct {
 	size int
 }
 
+// parserState contains state of tags parsing
+type parserState struct {
+	link      string // URL of opened hyperlink
+	linkStart int    // Position of hyperlink text in output
+	depth     uint8
+	closed    bool // There are no color codes without reset
+	clean     bool // Tags must be removed without any replacements
+}
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 // codes map tag -> escape code
@@ -71,6 +82,13 @@ var codes = map[rune]int{
 // DisableColors disable all colors and modificators in output
 var DisableColors = false
 
+// ColorDepth is maximum color depth supported by terminal. By default, depth is
+// detected using NO_COLOR, TERM and COLORTERM environment variables. Colors which
+// are not supported are replaced by the nearest supported colors. Output to
+// targets which are not terminals (pipes, files, buffers) is always printed
+// without colors.
+var ColorDepth = detectColorDepth()
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 // NewT create new struct for working with temporary output
@@ -114,8 +132,21 @@ func NewT() *T {
 //  S Gray (Smokey)
 //  W White
 //
+// Extended colors (must be placed after other codes in tag, e.g. {*#ff5f00}):
+//  #123    Foreground color from 256-color palette (0-255)
+//  %123    Background color from 256-color palette (0-255)
+//  #ff5f00 24-bit foreground color
+//  %ff5f00 24-bit background color
+//
+// Named styles (see AddStyle):
+//  ?error  Style with name "error"
+//
+// Hyperlinks (see Link):
+//  =https://domain.com Start of hyperlink
+//  =                   End of hyperlink
+//
 func Println(a ...interface{}) (int, error) {
-	applyColors(&a, DisableColors)
+	applyColors(&a, getDepth(os.Stdout))
 	return fmt.Println(a...)
 }
 
@@ -154,15 +185,28 @@ func Println(a ...interface{}) (int, error) {
 //  S Gray (Smokey)
 //  W White
 //
+// Extended colors (must be placed after other codes in tag, e.g. {*#ff5f00}):
+//  #123    Foreground color from 256-color palette (0-255)
+//  %123    Background color from 256-color palette (0-255)
+//  #ff5f00 24-bit foreground color
+//  %ff5f00 24-bit background color
+//
+// Named styles (see AddStyle):
+//  ?error  Style with name "error"
+//
+// Hyperlinks (see Link):
+//  =https://domain.com Start of hyperlink
+//  =                   End of hyperlink
+//
 func Printf(f string, a ...interface{}) (int, error) {
-	return fmt.Printf(searchColors(f, DisableColors), a...)
+	return fmt.Printf(searchColors(f, getDepth(os.Stdout)), a...)
 }
 
 // Fprint formats using the default formats for its operands and writes to w.
 // Spaces are added between operands when neither is a string. It returns the
 // number of bytes written and any write error encountered.
 func Fprint(w io.Writer, a ...interface{}) (int, error) {
-	applyColors(&a, DisableColors)
+	applyColors(&a, getDepth(w))
 	return fmt.Fprint(w, a...)
 }
 
@@ -170,27 +214,30 @@ func Fprint(w io.Writer, a ...interface{}) (int, error) {
 // Spaces are always added between operands and a newline is appended. It returns
 // the number of bytes written and any write error encountered.
 func Fprintln(w io.Writer, a ...interface{}) (int, error) {
-	applyColors(&a, DisableColors)
+	applyColors(&a, getDepth(w))
 	return fmt.Fprintln(w, a...)
 }
 
 // Fprintf formats according to a format specifier and writes to w. It returns
 // the number of bytes written and any write error encountered.
 func Fprintf(w io.Writer, f string, a ...interface{}) (int, error) {
-	return fmt.Fprintf(w, searchColors(f, DisableColors), a...)
+	return fmt.Fprintf(w, searchColors(f, getDepth(w)), a...)
 }
 
 // Sprint formats using the default formats for its operands and returns the
 // resulting string. Spaces are added between operands when neither is a string.
+// Colors are limited by ColorDepth only, result doesn't depend on whether the
+// output is a terminal.
 func Sprint(a ...interface{}) string {
-	applyColors(&a, DisableColors)
+	applyColors(&a, getDepth(nil))
 	return fmt.Sprint(a...)
 }
 
 // Sprintf formats according to a format specifier and returns the resulting
-// string.
+// string. Colors are limited by ColorDepth only, result doesn't depend on
+// whether the output is a terminal.
 func Sprintf(f string, a ...interface{}) string {
-	return fmt.Sprintf(searchColors(f, DisableColors), a...)
+	return fmt.Sprintf(searchColors(f, getDepth(nil)), a...)
 }
 
 // Errorf formats according to a format specifier and returns the string as a
@@ -204,9 +251,10 @@ func NewLine() (int, error) {
 	return fmt.Println("")
 }
 
-// Clean return string without color tags
+// Clean return string without color tags. Hyperlink tags are removed too,
+// only link text is kept.
 func Clean(s string) string {
-	return searchColors(s, true)
+	return parseText(s, &parserState{depth: DEPTH_NONE, closed: true, clean: true})
 }
 
 // Bell print alert symbol
@@ -214,6 +262,17 @@ func Bell() {
 	fmt.Printf(_CODE_BELL)
 }
 
+// IsTerminal return true if given writer is a terminal
+func IsTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+
+	if !ok || file == nil {
+		return false
+	}
+
+	return isTerminalFile(file)
+}
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 // Printf remove the previous message (if printed) and print new message
@@ -222,9 +281,9 @@ func (t *T) Printf(f string, a ...interface{}) (int, error) {
 		fmt.Printf(getSymbols(_CODE_BACKSPACE, t.size) + "\033[0K")
 	}
 
-	t.size = len(fmt.Sprintf(searchColors(f, true), a...))
+	t.size = len(fmt.Sprintf(searchColors(f, DEPTH_NONE), a...))
 
-	return fmt.Printf(searchColors(f, DisableColors), a...)
+	return fmt.Printf(searchColors(f, getDepth(os.Stdout)), a...)
 }
 
 // Println remove the previous message (if printed) and print new message
@@ -240,8 +299,8 @@ func (t *T) Println(a ...interface{}) (int, error) {
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
-func tag2ANSI(tag string, clean bool) string {
-	if clean {
+func tag2ANSI(tag string, depth uint8) string {
+	if depth == DEPTH_NONE {
 		return ""
 	}
 
@@ -252,6 +311,8 @@ func tag2ANSI(tag string, clean bool) string {
 		light       = false
 	)
 
+	tag, extColors := splitTag(tag)
+
 	for _, key := range tag {
 		code := codes[key]
 
@@ -278,10 +339,20 @@ func tag2ANSI(tag string, clean bool) string {
 		}
 	}
 
-	return fmt.Sprintf("\033[%d;%d;%dm", modificator, charColor, bgColor)
+	charCode, bgCode := fmt.Sprintf("%d", charColor), fmt.Sprintf("%d", bgColor)
+
+	for _, extColor := range extColors {
+		if extColor[0] == '#' {
+			charCode = getExtColorCode(extColor[1:], false, depth)
+		} else {
+			bgCode = getExtColorCode(extColor[1:], true, depth)
+		}
+	}
+
+	return fmt.Sprintf("\033[%d;%s;%sm", modificator, charCode, bgCode)
 }
 
-func replaceColorTags(input, output *bytes.Buffer, clean bool) bool {
+func replaceColorTags(input, output *bytes.Buffer, state *parserState) {
 	tag := bytes.NewBufferString("")
 
 LOOP:
@@ -290,7 +361,8 @@ LOOP:
 
 		if err != nil {
 			output.WriteString("{" + tag.String())
-			return true
+			state.closed = true
+			return
 		}
 
 		switch i {
@@ -306,30 +378,62 @@ LOOP:
 
 	tagStr := tag.String()
 
+	if strings.HasPrefix(tagStr, "=") {
+		if !isValidLink(tagStr[1:]) {
+			output.WriteString("{" + tagStr + "}")
+			return
+		}
+
+		if tagStr == "=" {
+			closeHyperlink(output, state)
+		} else {
+			openHyperlink(output, state, tagStr[1:])
+		}
+
+		return
+	}
+
+	if strings.HasPrefix(tagStr, "?") {
+		styleTag, ok := getStyleTag(tagStr[1:])
+
+		if !ok {
+			output.WriteString("{" + tagStr + "}")
+			state.closed = true
+			return
+		}
+
+		tagStr = styleTag
+	}
+
 	if !isValidTag(tagStr) {
 		output.WriteString("{" + tagStr + "}")
-		return true
+		state.closed = true
+		return
 	}
 
 	if tagStr == "!" {
-		if !clean {
+		if state.depth != DEPTH_NONE {
 			output.WriteString(_CODE_RESET)
 		}
 
-		return true
+		state.closed = true
+		return
 	}
 
-	output.WriteString(tag2ANSI(tagStr, clean))
+	output.WriteString(tag2ANSI(tagStr, state.depth))
+
+	state.closed = false
+}
 
-	return false
+func searchColors(text string, depth uint8) string {
+	return parseText(text, &parserState{depth: depth, closed: true})
 }
 
-func searchColors(text string, clean bool) string {
+func parseText(text string, state *parserState) string {
 	if text == "" {
 		return ""
 	}
 
-	closed := true
 	input := bytes.NewBufferString(text)
 	output := bytes.NewBufferString("")
 
@@ -342,7 +446,7 @@ func searchColors(text string, clean bool) string {
 
 		switch i {
 		case '{':
-			closed = replaceColorTags(input, output, clean)
+			replaceColorTags(input, output, state)
 		case rune(65533):
 			continue
 		default:
@@ -350,17 +454,21 @@ func searchColors(text string, clean bool) string {
 		}
 	}
 
-	if !closed {
+	if state.link != "" {
+		closeHyperlink(output, state)
+	}
+
+	if !state.closed && state.depth != DEPTH_NONE {
 		output.WriteString(_CODE_RESET)
 	}
 
 	return output.String()
 }
 
-func applyColors(a *[]interface{}, clean bool) {
+func applyColors(a *[]interface{}, depth uint8) {
 	for i, x := range *a {
 		if s, ok := x.(string); ok {
-			(*a)[i] = searchColors(s, clean)
+			(*a)[i] = searchColors(s, depth)
 		}
 	}
 }
@@ -376,6 +484,8 @@ func getSymbols(symbol string, count int) string {
 }
 
 func isValidTag(tag string) bool {
+	tag, extColors := splitTag(tag)
+
 	for _, r := range tag {
 		_, hasCode := codes[r]
 
@@ -384,7 +494,54 @@ func isValidTag(tag string) bool {
 		}
 	}
 
+	for _, extColor := range extColors {
+		if !isValidExtColor(extColor[1:]) {
+			return false
+		}
+	}
+
 	return true
 }
 
+// splitTag split tag to basic codes and extended colors (#… and %…)
+func splitTag(tag string) (string, []string) {
+	index := strings.IndexAny(tag, "#%")
+
+	if index == -1 {
+		return tag, nil
+	}
+
+	var extColors []string
+
+	basic, tag := tag[:index], tag[index:]
+
+	for tag != "" {
+		index = strings.IndexAny(tag[1:], "#%")
+
+		if index == -1 {
+			extColors = append(extColors, tag)
+			break
+		}
+
+		extColors = append(extColors, tag[:index+1])
+		tag = tag[index+1:]
+	}
+
+	return basic, extColors
+}
+
+// getDepth return color depth for output to given writer (nil writer is used
+// for formatting strings)
+func getDepth(w io.Writer) uint8 {
+	if DisableColors {
+		return DEPTH_NONE
+	}
+
+	if w != nil && !IsTerminal(w) {
+		return DEPTH_NONE
+	}
+
+	return ColorDepth
+}
+
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -10,6 +10,8 @@ package fmtc
 import (
 	"bytes"
 	"errors"
+	"io/ioutil"
+	"os"
 	"testing"
 
 	. "pkg.re/check.v1"
@@ -19,12 +21,25 @@ import (
 
 func Test(t *testing.T) { TestingT(t) }
 
-type FormatSuite struct{}
+type FormatSuite struct {
+	depth         uint8
+	disableColors bool
+}
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 var _ = Suite(&FormatSuite{})
 
+func (s *FormatSuite) SetUpSuite(c *C) {
+	// Tests must not depend on NO_COLOR and TERM used for running tests
+	s.depth, s.disableColors = ColorDepth, DisableColors
+	ColorDepth, DisableColors = DEPTH_TRUECOLOR, false
+}
+
+func (s *FormatSuite) TearDownSuite(c *C) {
+	ColorDepth, DisableColors = s.depth, s.disableColors
+}
+
 func (s *FormatSuite) TestColors(c *C) {
 	c.Assert(Sprint("{r}W{!}"), Equals, "\x1b[0;31;49mW\x1b[0m")
 	c.Assert(Sprint("{g}W{!}"), Equals, "\x1b[0;32;49mW\x1b[0m")
@@ -71,6 +86,227 @@ func (s *FormatSuite) TestParsing(c *C) {
 	c.Assert(Sprint("Test"+string(rune(65533))), Equals, "Test")
 }
 
+func (s *FormatSuite) TestExtendedColors(c *C) {
+	depth := ColorDepth
+	ColorDepth = DEPTH_TRUECOLOR
+
+	c.Assert(Sprint("{#214}W{!}"), Equals, "\x1b[0;38;5;214;49mW\x1b[0m")
+	c.Assert(Sprint("{%17}W{!}"), Equals, "\x1b[0;39;48;5;17mW\x1b[0m")
+	c.Assert(Sprint("{*#ff5f00}W{!}"), Equals, "\x1b[1;38;2;255;95;0;49mW\x1b[0m")
+	c.Assert(Sprint("{r%00005f}W{!}"), Equals, "\x1b[0;31;48;2;0;0;95mW\x1b[0m")
+	c.Assert(Sprint("{#1%2}W{!}"), Equals, "\x1b[0;38;5;1;48;5;2mW\x1b[0m")
+	c.Assert(Sprint("{#256}W"), Equals, "{#256}W")
+	c.Assert(Sprint("{#+1}W"), Equals, "{#+1}W")
+	c.Assert(Sprint("{#1234}W"), Equals, "{#1234}W")
+	c.Assert(Sprint("{#ff5f0g}W"), Equals, "{#ff5f0g}W")
+	c.Assert(Sprint("{#}W"), Equals, "{#}W")
+	c.Assert(Sprint("{#1 issue}"), Equals, "{#1 issue}")
+
+	ColorDepth = DEPTH_256
+
+	c.Assert(Sprint("{#214}W{!}"), Equals, "\x1b[0;38;5;214;49mW\x1b[0m")
+	c.Assert(Sprint("{#ff5f00}W{!}"), Equals, "\x1b[0;38;5;202;49mW\x1b[0m")
+	c.Assert(Sprint("{%808080}W{!}"), Equals, "\x1b[0;39;48;5;244mW\x1b[0m")
+
+	ColorDepth = DEPTH_16
+
+	c.Assert(Sprint("{#1}W{!}"), Equals, "\x1b[0;31;49mW\x1b[0m")
+	c.Assert(Sprint("{#9}W{!}"), Equals, "\x1b[0;91;49mW\x1b[0m")
+	c.Assert(Sprint("{%4}W{!}"), Equals, "\x1b[0;39;44mW\x1b[0m")
+	c.Assert(Sprint("{%ffffff}W{!}"), Equals, "\x1b[0;39;107mW\x1b[0m")
+	c.Assert(Sprint("{#ee0000}W{!}"), Equals, "\x1b[0;91;49mW\x1b[0m")
+	c.Assert(Sprint("{#240}W{!}"), Equals, "\x1b[0;90;49mW\x1b[0m")
+
+	ColorDepth = DEPTH_NONE
+
+	c.Assert(Sprint("{#214}W{!}"), Equals, "W")
+	c.Assert(Sprint("{r}W{!}"), Equals, "W")
+	c.Assert(Sprint("{r}W"), Equals, "W")
+	c.Assert(Sprintf("{r}%s", "W"), Equals, "W")
+
+	c.Assert(Clean("{#214%ff5f00}W{!}"), Equals, "W")
+
+	ColorDepth = depth
+}
+
+func (s *FormatSuite) TestDepthDetection(c *C) {
+	noColor, term, colorTerm := os.Getenv("NO_COLOR"), os.Getenv("TERM"), os.Getenv("COLORTERM")
+
+	os.Setenv("NO_COLOR", "")
+	os.Setenv("COLORTERM", "")
+
+	os.Setenv("TERM", "dumb")
+	c.Assert(detectColorDepth(), Equals, uint8(DEPTH_NONE))
+	os.Setenv("TERM", "xterm")
+	c.Assert(detectColorDepth(), Equals, uint8(DEPTH_16))
+	os.Setenv("TERM", "xterm-256color")
+	c.Assert(detectColorDepth(), Equals, uint8(DEPTH_256))
+	os.Setenv("TERM", "xterm-direct")
+	c.Assert(detectColorDepth(), Equals, uint8(DEPTH_TRUECOLOR))
+
+	os.Setenv("TERM", "xterm-256color")
+	os.Setenv("COLORTERM", "truecolor")
+	c.Assert(detectColorDepth(), Equals, uint8(DEPTH_TRUECOLOR))
+	os.Setenv("COLORTERM", "24bit")
+	c.Assert(detectColorDepth(), Equals, uint8(DEPTH_TRUECOLOR))
+
+	os.Setenv("NO_COLOR", "1")
+	c.Assert(detectColorDepth(), Equals, uint8(DEPTH_NONE))
+
+	os.Setenv("NO_COLOR", noColor)
+	os.Setenv("TERM", term)
+	os.Setenv("COLORTERM", colorTerm)
+}
+
+func (s *FormatSuite) TestOutputDepth(c *C) {
+	depth := ColorDepth
+	ColorDepth = DEPTH_256
+
+	c.Assert(getDepth(nil), Equals, uint8(DEPTH_256))
+	c.Assert(getDepth(&bytes.Buffer{}), Equals, uint8(DEPTH_NONE))
+
+	fd, err := os.Create(c.MkDir() + "/output")
+
+	c.Assert(err, IsNil)
+	c.Assert(getDepth(fd), Equals, uint8(DEPTH_NONE))
+
+	Fprint(fd, "{r}TEST{!}")
+	Fprintf(fd, "{#214}%s{!}", "TEST")
+	fd.Close()
+
+	data, err := ioutil.ReadFile(fd.Name())
+
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "TESTTEST")
+
+	var nilFile *os.File
+
+	c.Assert(IsTerminal(nilFile), Equals, false)
+
+	devNull, err := os.Open(os.DevNull)
+
+	c.Assert(err, IsNil)
+	c.Assert(IsTerminal(devNull), Equals, false)
+
+	devNull.Close()
+
+	DisableColors = true
+	c.Assert(getDepth(nil), Equals, uint8(DEPTH_NONE))
+	DisableColors = false
+
+	ColorDepth = depth
+}
+
+func (s *FormatSuite) TestPaletteConversion(c *C) {
+	c.Assert(rgb2Palette(0, 0, 0), Equals, 16)
+	c.Assert(rgb2Palette(255, 255, 255), Equals, 231)
+	c.Assert(rgb2Palette(128, 128, 128), Equals, 244)
+	c.Assert(rgb2Palette(5, 5, 5), Equals, 232)
+
+	r, g, b := palette2RGB(9)
+	c.Assert([]int{r, g, b}, DeepEquals, []int{255, 0, 0})
+	r, g, b = palette2RGB(196)
+	c.Assert([]int{r, g, b}, DeepEquals, []int{255, 0, 0})
+	r, g, b = palette2RGB(255)
+	c.Assert([]int{r, g, b}, DeepEquals, []int{238, 238, 238})
+
+	c.Assert(palette2Basic(5), Equals, 5)
+	c.Assert(palette2Basic(196), Equals, 9)
+	c.Assert(palette2Basic(255), Equals, 7)
+}
+
+func (s *FormatSuite) TestStyles(c *C) {
+	defer ResetStyles()
+
+	c.Assert(Sprint("{?error}W{!}"), Equals, "\x1b[0;31;49mW\x1b[0m")
+	c.Assert(Sprint("{?hint}W{!}"), Equals, "\x1b[0;90;49mW\x1b[0m")
+	c.Assert(Sprint("{?unknown}W"), Equals, "{?unknown}W")
+	c.Assert(Sprint("{?}W"), Equals, "{?}W")
+	c.Assert(Clean("{?error}W{!}"), Equals, "W")
+
+	c.Assert(AddStyle("", "{r}"), Equals, ErrInvalidStyleName)
+	c.Assert(AddStyle("my style", "{r}"), Equals, ErrInvalidStyleName)
+	c.Assert(AddStyle("test", ""), Equals, ErrInvalidStyleTag)
+	c.Assert(AddStyle("test", "{J}"), Equals, ErrInvalidStyleTag)
+	c.Assert(AddStyle("test", "{?error}"), Equals, ErrInvalidStyleTag)
+
+	c.Assert(AddStyle("error", "{*#ff0000}"), IsNil)
+	c.Assert(AddStyle("my-style_1", "y_"), IsNil)
+	c.Assert(GetStyle("error"), Equals, "{*#ff0000}")
+	c.Assert(GetStyle("my-style_1"), Equals, "{y_}")
+	c.Assert(GetStyle("unknown"), Equals, "")
+
+	c.Assert(Sprint("{?my-style_1}W{!}"), Equals, "\x1b[4;33;49mW\x1b[0m")
+
+	c.Assert(AddStyles(map[string]string{"a": "{r}", "b": "{J}"}), Equals, ErrInvalidStyleTag)
+	c.Assert(GetStyle("a"), Equals, "")
+
+	RemoveStyle("my-style_1")
+	c.Assert(GetStyle("my-style_1"), Equals, "")
+
+	ResetStyles()
+	c.Assert(GetStyle("error"), Equals, "{r}")
+}
+
+func (s *FormatSuite) TestHyperlinks(c *C) {
+	depth := ColorDepth
+	ColorDepth = DEPTH_16
+
+	c.Assert(Sprint("{=https://domain.com}Test{=}"), Equals, "\x1b]8;;https://domain.com\x1b\\Test\x1b]8;;\x1b\\")
+	c.Assert(Sprint("{r}{=https://domain.com}Test{=}{!}"), Equals, "\x1b[0;31;49m\x1b]8;;https://domain.com\x1b\\Test\x1b]8;;\x1b\\\x1b[0m")
+	c.Assert(Sprint("{r}{=https://domain.com}Test"), Equals, "\x1b[0;31;49m\x1b]8;;https://domain.com\x1b\\Test\x1b]8;;\x1b\\\x1b[0m")
+	c.Assert(Sprint(Link("mailto:john@domain.com", "John")), Equals, "\x1b]8;;mailto:john@domain.com\x1b\\John\x1b]8;;\x1b\\")
+	c.Assert(Sprint("{=}Test"), Equals, "Test")
+	c.Assert(Sprint("{=domain}Test"), Equals, "{=domain}Test")
+	c.Assert(Sprint("{=:domain}Test"), Equals, "{=:domain}Test")
+	c.Assert(Sprint("{=http:}Test"), Equals, "{=http:}Test")
+	c.Assert(Sprint("{=ht_tp://domain.com}Test"), Equals, "{=ht_tp://domain.com}Test")
+	c.Assert(Sprint("{=https://domain.com/a b}Test"), Equals, "{=https://domain.com/a b}Test")
+
+	c.Assert(Clean("{=https://domain.com}Test{=}"), Equals, "Test")
+	c.Assert(Clean("{=https://domain.com}{=}"), Equals, "")
+	c.Assert(Clean("{=https://a.com}A{=https://b.com}B"), Equals, "AB")
+	c.Assert(Clean("{r}{=https://domain.com}Test{!}{=}"), Equals, "Test")
+
+	w := &bytes.Buffer{}
+	Fprintf(w, "{=https://domain.com}Test{=} %s", "OK")
+	c.Assert(w.String(), Equals, "Test (https://domain.com) OK")
+
+	w.Reset()
+	Fprint(w, "{=https://domain.com}https://domain.com{=}")
+	c.Assert(w.String(), Equals, "https://domain.com")
+
+	w.Reset()
+	Fprint(w, "{=https://domain.com}{=}")
+	c.Assert(w.String(), Equals, "https://domain.com")
+
+	w.Reset()
+	Fprint(w, "{=https://a.com}A{=https://b.com}B")
+	c.Assert(w.String(), Equals, "A (https://a.com)B (https://b.com)")
+
+	ColorDepth = depth
+}
+
+func (s *FormatSuite) TestTitle(c *C) {
+	c.Assert(sanitizeTitle("{r}My\x1b]0;App\a{!}"), Equals, "My]0;App")
+
+	w := &bytes.Buffer{}
+	titleWriter = w
+
+	titleSaved = true
+	RestoreTitle()
+	c.Assert(titleSaved, Equals, false)
+	c.Assert(w.String(), Equals, _CODE_TITLE_LOAD)
+
+	w.Reset()
+
+	RestoreTitle()
+	SetTitle("Test")
+	c.Assert(w.String(), Equals, "")
+
+	titleWriter = os.Stdout
+}
+
 func (s *FormatSuite) TestZDisable(c *C) {
 	DisableColors = true
 
@@ -111,6 +347,7 @@ func (s *FormatSuite) TestClean(c *C) {
 	c.Assert(Clean("{C}W{!}"), Equals, "W")
 	c.Assert(Clean("{S}W{!}"), Equals, "W")
 	c.Assert(Clean("{S*_}W{!}"), Equals, "W")
+	c.Assert(Clean("{r}W"), Equals, "W")
 }
 
 func (s *FormatSuite) TestMethods(c *C) {
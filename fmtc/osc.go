@@ -0,0 +1,145 @@
+package fmtc
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	_CODE_LINK_START = "\033]8;;"
+	_CODE_LINK_END   = "\033\\"
+	_CODE_TITLE      = "\033]0;"
+	_CODE_TITLE_SAVE = "\033[22;0t"
+	_CODE_TITLE_LOAD = "\033[23;0t"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// titleSaved is true if original terminal title was saved
+var titleSaved bool
+
+// titleWriter is writer used for title escape sequences
+var titleWriter io.Writer = os.Stdout
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Link return text with hyperlink tags. If output doesn't support colors or
+// isn't a terminal, URL will be printed after text.
+func Link(url, text string) string {
+	return "{=" + url + "}" + text + "{=}"
+}
+
+// SetTitle set terminal window title. Title is not changed if colors are disabled
+// or output isn't a terminal.
+func SetTitle(title string) {
+	if getDepth(titleWriter) == DEPTH_NONE {
+		return
+	}
+
+	if !titleSaved {
+		fmt.Fprint(titleWriter, _CODE_TITLE_SAVE)
+		titleSaved = true
+	}
+
+	fmt.Fprint(titleWriter, _CODE_TITLE+sanitizeTitle(title)+_CODE_BELL)
+}
+
+// RestoreTitle restore terminal window title changed by SetTitle
+func RestoreTitle() {
+	if !titleSaved {
+		return
+	}
+
+	fmt.Fprint(titleWriter, _CODE_TITLE_LOAD)
+	titleSaved = false
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// openHyperlink write hyperlink start sequence
+func openHyperlink(output *bytes.Buffer, state *parserState, url string) {
+	if state.link != "" {
+		closeHyperlink(output, state)
+	}
+
+	state.link, state.linkStart = url, output.Len()
+
+	if state.depth != DEPTH_NONE {
+		output.WriteString(_CODE_LINK_START + url + _CODE_LINK_END)
+	}
+}
+
+// closeHyperlink write hyperlink end sequence or URL if output doesn't support
+// hyperlinks
+func closeHyperlink(output *bytes.Buffer, state *parserState) {
+	if state.link == "" {
+		return
+	}
+
+	text := output.String()[state.linkStart:]
+
+	switch {
+	case state.depth != DEPTH_NONE:
+		output.WriteString(_CODE_LINK_START + _CODE_LINK_END)
+	case state.clean:
+		// Clean must only remove tags
+	case strings.TrimSpace(text) == "":
+		output.WriteString(state.link)
+	case text != state.link:
+		output.WriteString(" (" + state.link + ")")
+	}
+
+	state.link = ""
+}
+
+// isValidLink return true if given string is empty (end of hyperlink) or
+// URL with scheme
+func isValidLink(url string) bool {
+	if url == "" {
+		return true
+	}
+
+	index := strings.Index(url, ":")
+
+	if index < 1 || index == len(url)-1 {
+		return false
+	}
+
+	for _, r := range url[:index] {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("+-.", r) {
+			return false
+		}
+	}
+
+	for _, r := range url {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sanitizeTitle remove tags and control symbols from title
+func sanitizeTitle(title string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+
+		return r
+	}, Clean(title))
+}
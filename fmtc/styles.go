@@ -0,0 +1,167 @@
+package fmtc
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Names of default styles
+const (
+	STYLE_ERROR   = "error"   // Error messages
+	STYLE_WARNING = "warning" // Warning messages
+	STYLE_SUCCESS = "success" // Messages about successfully completed actions
+	STYLE_HINT    = "hint"    // Hints and secondary information
+	STYLE_ACTION  = "action"  // Actions in progress
+	STYLE_HEADER  = "header"  // Headers and section titles
+	STYLE_BORDER  = "border"  // Borders and separators
+	STYLE_APP     = "app"     // Application name
+	STYLE_VERSION = "version" // Application version
+	STYLE_COMMAND = "command" // Commands in usage info
+	STYLE_OPTION  = "option"  // Options in usage info
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ErrInvalidStyleName is returned if style name is empty or contains unsupported symbols
+var ErrInvalidStyleName = errors.New("Style name is empty or contains unsupported symbols")
+
+// ErrInvalidStyleTag is returned if style tag is empty or not valid
+var ErrInvalidStyleTag = errors.New("Style tag is empty or not valid")
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// defaultStyles contains default styles
+var defaultStyles = map[string]string{
+	STYLE_ERROR:   "r",
+	STYLE_WARNING: "y",
+	STYLE_SUCCESS: "g",
+	STYLE_HINT:    "s-",
+	STYLE_ACTION:  "*",
+	STYLE_HEADER:  "*",
+	STYLE_BORDER:  "s",
+	STYLE_APP:     "*c",
+	STYLE_VERSION: "c",
+	STYLE_COMMAND: "y",
+	STYLE_OPTION:  "g",
+}
+
+var (
+	styles     = copyStyles(defaultStyles)
+	stylesLock = &sync.RWMutex{}
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// AddStyle add new or replace existing named style. Style can be used in text as
+// tag with question mark before name (e.g. {?error}). Tag can be defined with
+// or without curly brackets ("{*r}" or "*r").
+func AddStyle(name, tag string) error {
+	return AddStyles(map[string]string{name: tag})
+}
+
+// AddStyles add several styles at once. If any of styles is not valid, no
+// styles will be added.
+func AddStyles(newStyles map[string]string) error {
+	tags := make(map[string]string, len(newStyles))
+
+	for name, tag := range newStyles {
+		if !isValidStyleName(name) {
+			return ErrInvalidStyleName
+		}
+
+		tag = strings.TrimSuffix(strings.TrimPrefix(tag, "{"), "}")
+
+		if tag == "" || strings.HasPrefix(tag, "?") || !isValidTag(tag) {
+			return ErrInvalidStyleTag
+		}
+
+		tags[name] = tag
+	}
+
+	stylesLock.Lock()
+
+	for name, tag := range tags {
+		styles[name] = tag
+	}
+
+	stylesLock.Unlock()
+
+	return nil
+}
+
+// RemoveStyle remove named style
+func RemoveStyle(name string) {
+	stylesLock.Lock()
+	delete(styles, name)
+	stylesLock.Unlock()
+}
+
+// GetStyle return tag for named style or empty string if there is no style with
+// given name
+func GetStyle(name string) string {
+	tag, ok := getStyleTag(name)
+
+	if !ok {
+		return ""
+	}
+
+	return "{" + tag + "}"
+}
+
+// ResetStyles remove all custom styles and restore default styles
+func ResetStyles() {
+	stylesLock.Lock()
+	styles = copyStyles(defaultStyles)
+	stylesLock.Unlock()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getStyleTag return tag (without curly brackets) for named style
+func getStyleTag(name string) (string, bool) {
+	stylesLock.RLock()
+	tag, ok := styles[name]
+	stylesLock.RUnlock()
+
+	return tag, ok
+}
+
+// isValidStyleName return true if style name contains only letters, digits,
+// underscores and hyphens
+func isValidStyleName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z',
+			r >= '0' && r <= '9', r == '_', r == '-':
+			continue
+		}
+
+		return false
+	}
+
+	return true
+}
+
+func copyStyles(source map[string]string) map[string]string {
+	result := make(map[string]string, len(source))
+
+	for name, tag := range source {
+		result[name] = tag
+	}
+
+	return result
+}
@@ -0,0 +1,102 @@
+// Package theme provides methods for loading fmtc named styles from files and
+// environment variables
+package theme
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"pkg.re/essentialkaos/ek.v9/fmtc"
+	"pkg.re/essentialkaos/ek.v9/knf"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// SECTION is name of knf section with styles
+const SECTION = "styles"
+
+// ENV_NAME is default name of environment variable with theme
+const ENV_NAME = "FMTC_THEME"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Load load styles from knf file. Styles must be defined in section "styles":
+//
+//   [styles]
+//     error: {*r}
+//     hint: {s-}
+//
+func Load(file string) error {
+	config, err := knf.Read(file)
+
+	if err != nil {
+		return err
+	}
+
+	if !config.HasSection(SECTION) {
+		return fmt.Errorf("Theme file %s doesn't contain section \"%s\"", file, SECTION)
+	}
+
+	styles := make(map[string]string)
+
+	for _, name := range config.Props(SECTION) {
+		styles[name] = config.GetS(SECTION + ":" + name)
+	}
+
+	return fmtc.AddStyles(styles)
+}
+
+// LoadEnv load styles from environment variable (FMTC_THEME is used if name
+// is empty). Styles must be defined as name:tag pairs separated by semicolons
+// (e.g. "error:{*r};hint:{s-}"). If variable is empty or not set, no styles will
+// be loaded.
+func LoadEnv(name string) error {
+	if name == "" {
+		name = ENV_NAME
+	}
+
+	theme := strings.TrimSpace(os.Getenv(name))
+
+	if theme == "" {
+		return nil
+	}
+
+	styles, err := Parse(theme)
+
+	if err != nil {
+		return err
+	}
+
+	return fmtc.AddStyles(styles)
+}
+
+// Parse parse styles defined as name:tag pairs separated by semicolons
+func Parse(theme string) (map[string]string, error) {
+	styles := make(map[string]string)
+
+	for _, record := range strings.Split(theme, ";") {
+		record = strings.TrimSpace(record)
+
+		if record == "" {
+			continue
+		}
+
+		index := strings.Index(record, ":")
+
+		if index == -1 {
+			return nil, fmt.Errorf("Style definition \"%s\" is malformed", record)
+		}
+
+		styles[strings.TrimSpace(record[:index])] = strings.TrimSpace(record[index+1:])
+	}
+
+	return styles, nil
+}
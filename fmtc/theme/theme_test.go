@@ -0,0 +1,106 @@
+package theme
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	. "pkg.re/check.v1"
+
+	"pkg.re/essentialkaos/ek.v9/fmtc"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const _THEME_DATA = `
+[styles]
+  error: {*r}
+  hint: {#244}
+  important: {_y}
+`
+
+const _THEME_BROKEN = `
+[styles]
+  error: {J}
+`
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func Test(t *testing.T) { TestingT(t) }
+
+type ThemeSuite struct {
+	TmpDir string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var _ = Suite(&ThemeSuite{})
+
+func (s *ThemeSuite) SetUpSuite(c *C) {
+	s.TmpDir = c.MkDir()
+}
+
+func (s *ThemeSuite) TearDownTest(c *C) {
+	fmtc.ResetStyles()
+}
+
+func (s *ThemeSuite) TestLoad(c *C) {
+	themeFile := s.TmpDir + "/theme.knf"
+	brokenFile := s.TmpDir + "/broken.knf"
+	emptyFile := s.TmpDir + "/empty.knf"
+
+	c.Assert(ioutil.WriteFile(themeFile, []byte(_THEME_DATA), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(brokenFile, []byte(_THEME_BROKEN), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(emptyFile, []byte("[main]\n  test: 1\n"), 0644), IsNil)
+
+	c.Assert(Load(s.TmpDir+"/unknown.knf"), NotNil)
+	c.Assert(Load(emptyFile), NotNil)
+	c.Assert(Load(brokenFile), NotNil)
+	c.Assert(fmtc.GetStyle("error"), Equals, "{r}")
+
+	c.Assert(Load(themeFile), IsNil)
+	c.Assert(fmtc.GetStyle("error"), Equals, "{*r}")
+	c.Assert(fmtc.GetStyle("hint"), Equals, "{#244}")
+	c.Assert(fmtc.GetStyle("important"), Equals, "{_y}")
+}
+
+func (s *ThemeSuite) TestLoadEnv(c *C) {
+	c.Assert(LoadEnv("EK_TEST_THEME"), IsNil)
+
+	os.Setenv("EK_TEST_THEME", "error")
+	c.Assert(LoadEnv("EK_TEST_THEME"), NotNil)
+
+	os.Setenv("EK_TEST_THEME", "error:{J}")
+	c.Assert(LoadEnv("EK_TEST_THEME"), NotNil)
+
+	os.Setenv("EK_TEST_THEME", " error: {*r} ; hint:{s} ;")
+	c.Assert(LoadEnv("EK_TEST_THEME"), IsNil)
+	c.Assert(fmtc.GetStyle("error"), Equals, "{*r}")
+	c.Assert(fmtc.GetStyle("hint"), Equals, "{s}")
+
+	os.Unsetenv("EK_TEST_THEME")
+
+	os.Setenv(ENV_NAME, "warning:{*y}")
+	c.Assert(LoadEnv(""), IsNil)
+	c.Assert(fmtc.GetStyle("warning"), Equals, "{*y}")
+
+	os.Unsetenv(ENV_NAME)
+}
+
+func (s *ThemeSuite) TestParse(c *C) {
+	styles, err := Parse("a:{r};b:{g}")
+
+	c.Assert(err, IsNil)
+	c.Assert(styles, DeepEquals, map[string]string{"a": "{r}", "b": "{g}"})
+
+	_, err = Parse("a:{r};b")
+
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,18 @@
+// +build darwin freebsd openbsd netbsd dragonfly
+
+package fmtc
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"syscall"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const _IOCTL_GET_TERMIOS = syscall.TIOCGETA
@@ -0,0 +1,27 @@
+// +build !linux,!darwin,!freebsd,!openbsd,!netbsd,!dragonfly,!windows
+
+package fmtc
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"os"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// isTerminalFile return true if file is a character device
+func isTerminalFile(file *os.File) bool {
+	fi, err := file.Stat()
+
+	if err != nil {
+		return false
+	}
+
+	return fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,32 @@
+// +build linux darwin freebsd openbsd netbsd dragonfly
+
+package fmtc
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// isTerminalFile return true if file is a terminal (file is a terminal only if
+// we can read its termios settings, character devices like /dev/null are not)
+func isTerminalFile(file *os.File) bool {
+	var state syscall.Termios
+
+	_, _, errno := syscall.Syscall(
+		syscall.SYS_IOCTL, file.Fd(),
+		uintptr(_IOCTL_GET_TERMIOS),
+		uintptr(unsafe.Pointer(&state)),
+	)
+
+	return errno == 0
+}
@@ -16,8 +16,8 @@ import (
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
-// SeparatorColorTag is fmtc color tag used for separator (light gray by default)
-var SeparatorColorTag string = "{s}"
+// SeparatorColorTag is fmtc color tag used for separator (border style by default)
+var SeparatorColorTag string = "{?border}"
 
 // SeparatorTitleColorTag is fmtc color tag used for separator title (light gray by default)
 var SeparatorTitleColorTag = "{s}"
@@ -0,0 +1,242 @@
+package table
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"pkg.re/essentialkaos/ek.v9/fmtc"
+	"pkg.re/essentialkaos/ek.v9/fmtutil"
+	"pkg.re/essentialkaos/ek.v9/mathutil"
+	"pkg.re/essentialkaos/ek.v9/sortutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Sorting modes
+const (
+	SORT_NATURAL uint8 = 0 // Natural order (e.g. "file2" < "file10")
+	SORT_NUMERIC       = 1 // Numeric order, non-numeric values are placed after numbers
+)
+
+// Footer aggregate functions
+const (
+	AGGREGATE_NONE  uint8 = 0
+	AGGREGATE_SUM         = 1 // Sum of numeric values
+	AGGREGATE_AVG         = 2 // Average of numeric values
+	AGGREGATE_COUNT       = 3 // Number of non-empty values
+	AGGREGATE_MIN         = 4 // Minimal numeric value
+	AGGREGATE_MAX         = 5 // Maximal numeric value
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type sortInfo struct {
+	column int
+	mode   uint8
+	desc   bool
+}
+
+type rowSorter struct {
+	rows [][]string
+	sortInfo
+}
+
+type rowGrouper struct {
+	rows   [][]string
+	order  map[string]int
+	column int
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s rowSorter) Len() int      { return len(s.rows) }
+func (s rowSorter) Swap(i, j int) { s.rows[i], s.rows[j] = s.rows[j], s.rows[i] }
+
+func (s rowSorter) Less(i, j int) bool {
+	v1, v2 := getCell(s.rows[i], s.column), getCell(s.rows[j], s.column)
+
+	if s.mode == SORT_NUMERIC {
+		n1, ok1 := parseNumber(v1)
+		n2, ok2 := parseNumber(v2)
+
+		switch {
+		case ok1 && ok2 && s.desc:
+			return n1 > n2
+		case ok1 && ok2:
+			return n1 < n2
+		case ok1 != ok2:
+			return ok1
+		}
+	}
+
+	if s.desc {
+		return sortutil.NaturalLess(v2, v1)
+	}
+
+	return sortutil.NaturalLess(v1, v2)
+}
+
+func (g rowGrouper) Len() int      { return len(g.rows) }
+func (g rowGrouper) Swap(i, j int) { g.rows[i], g.rows[j] = g.rows[j], g.rows[i] }
+
+func (g rowGrouper) Less(i, j int) bool {
+	return g.order[getCell(g.rows[i], g.column)] < g.order[getCell(g.rows[j], g.column)]
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// SortBy set column for sorting rows before rendering
+func (t *Table) SortBy(columnIndex int, mode uint8, desc bool) *Table {
+	if t == nil {
+		return nil
+	}
+
+	t.sorting = &sortInfo{columnIndex, mode, desc}
+
+	return t
+}
+
+// GroupBy set column for grouping rows before rendering. Rows with the same
+// value in this column are rendered together under subheading with this value
+// (groups are ordered by first occurrence of value).
+func (t *Table) GroupBy(columnIndex int) *Table {
+	if t == nil {
+		return nil
+	}
+
+	t.grouping, t.groupColumn = true, columnIndex
+
+	return t
+}
+
+// SetFooter allow to set aggregate functions for footer row. Footer is rendered
+// only in text and markdown formats.
+func (t *Table) SetFooter(aggregates ...uint8) *Table {
+	if t == nil {
+		return nil
+	}
+
+	t.Footer = aggregates
+
+	return t
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// prepareData sort and group data and calculate footer values
+func prepareData(t *Table) {
+	if t.sorting != nil {
+		sort.Stable(rowSorter{t.data, *t.sorting})
+	}
+
+	if t.grouping {
+		order := make(map[string]int)
+
+		for _, row := range t.data {
+			value := getCell(row, t.groupColumn)
+
+			if _, ok := order[value]; !ok {
+				order[value] = len(order)
+			}
+		}
+
+		sort.Stable(rowGrouper{t.data, order, t.groupColumn})
+	}
+
+	if len(t.Footer) != 0 {
+		t.footerData = getFooterData(t)
+	}
+}
+
+// getFooterData return footer row with aggregated values
+func getFooterData(t *Table) []string {
+	result := make([]string, len(t.Footer))
+
+	for columnIndex, aggregate := range t.Footer {
+		if aggregate == AGGREGATE_NONE {
+			continue
+		}
+
+		var count, numCount int
+		var sum, min, max float64
+
+		for _, row := range t.data {
+			value := getCell(row, columnIndex)
+
+			if value == "" {
+				continue
+			}
+
+			count++
+
+			num, ok := parseNumber(value)
+
+			if !ok {
+				continue
+			}
+
+			if numCount == 0 || num < min {
+				min = num
+			}
+
+			if numCount == 0 || num > max {
+				max = num
+			}
+
+			sum += num
+			numCount++
+		}
+
+		switch {
+		case aggregate == AGGREGATE_COUNT:
+			result[columnIndex] = strconv.Itoa(count)
+		case numCount == 0:
+			// no numeric values
+		case aggregate == AGGREGATE_SUM:
+			result[columnIndex] = formatNumber(sum)
+		case aggregate == AGGREGATE_AVG:
+			result[columnIndex] = formatNumber(sum / float64(numCount))
+		case aggregate == AGGREGATE_MIN:
+			result[columnIndex] = formatNumber(min)
+		case aggregate == AGGREGATE_MAX:
+			result[columnIndex] = formatNumber(max)
+		}
+	}
+
+	return result
+}
+
+// getCell return cell value without color tags
+func getCell(row []string, columnIndex int) string {
+	if columnIndex < 0 || columnIndex >= len(row) {
+		return ""
+	}
+
+	return fmtc.Clean(row[columnIndex])
+}
+
+// parseNumber parse cell value as number (order separators are ignored)
+func parseNumber(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+
+	if fmtutil.OrderSeparator != "" {
+		value = strings.Replace(value, fmtutil.OrderSeparator, "", -1)
+	}
+
+	num, err := strconv.ParseFloat(value, 64)
+
+	return num, err == nil
+}
+
+// formatNumber format aggregated value
+func formatNumber(num float64) string {
+	return strconv.FormatFloat(mathutil.Round(num, 2), 'f', -1, 64)
+}
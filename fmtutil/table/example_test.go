@@ -7,6 +7,12 @@ package table
 //                                                                                    //
 // ////////////////////////////////////////////////////////////////////////////////// //
 
+import (
+	"os"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
 func ExampleNewTable() {
 	t := NewTable()
 
@@ -20,3 +26,19 @@ func ExampleNewTable() {
 
 	t.Render()
 }
+
+func ExampleTable_RenderTo() {
+	t := NewTable("id", "user", "balance")
+
+	t.SetFormat(FORMAT_CSV)
+
+	t.Add(1, "{g}Bob{!}", 1.42)
+	t.Add(2, "John", 73.1)
+
+	t.RenderTo(os.Stdout)
+
+	// Output:
+	// id,user,balance
+	// 1,Bob,1.42
+	// 2,John,73.1
+}
@@ -0,0 +1,247 @@
+package table
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"pkg.re/essentialkaos/ek.v9/fmtc"
+	"pkg.re/essentialkaos/ek.v9/mathutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ParseFormat return output format for given name (text, plain, csv, tsv,
+// markdown/md or json), it can be used for handling --format option
+func ParseFormat(name string) (uint8, error) {
+	switch strings.ToLower(name) {
+	case "", "text":
+		return FORMAT_TEXT, nil
+	case "plain":
+		return FORMAT_PLAIN, nil
+	case "csv":
+		return FORMAT_CSV, nil
+	case "tsv":
+		return FORMAT_TSV, nil
+	case "markdown", "md":
+		return FORMAT_MARKDOWN, nil
+	case "json":
+		return FORMAT_JSON, nil
+	}
+
+	return FORMAT_TEXT, fmt.Errorf("Unknown output format \"%s\"", name)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// renderCSV render table as CSV (or TSV) document
+func renderCSV(t *Table, comma rune) []byte {
+	var buf bytes.Buffer
+
+	w := csv.NewWriter(&buf)
+	w.Comma = comma
+
+	if len(t.Headers) != 0 {
+		w.Write(cleanRow(t.Headers))
+	}
+
+	for _, row := range t.data {
+		w.Write(cleanRow(row))
+	}
+
+	w.Flush()
+
+	return buf.Bytes()
+}
+
+// renderMarkdown render table as markdown table
+func renderMarkdown(t *Table) []byte {
+	var buf bytes.Buffer
+
+	totalColumns := getTotalColumns(t)
+
+	buf.WriteString("|")
+
+	for columnIndex := 0; columnIndex < totalColumns; columnIndex++ {
+		var header string
+
+		if columnIndex < len(t.Headers) {
+			header = fmtc.Clean(t.Headers[columnIndex])
+		}
+
+		buf.WriteString(" " + escapeMarkdownCell(header) + " |")
+	}
+
+	buf.WriteString("\n|")
+
+	for columnIndex := 0; columnIndex < totalColumns; columnIndex++ {
+		switch getAlignment(t, columnIndex) {
+		case ALIGN_CENTER:
+			buf.WriteString(":---:|")
+		case ALIGN_RIGHT:
+			buf.WriteString("---:|")
+		default:
+			buf.WriteString("---|")
+		}
+	}
+
+	buf.WriteString("\n")
+
+	var group string
+
+	for rowIndex, row := range t.data {
+		if t.grouping && (rowIndex == 0 || getCell(row, t.groupColumn) != group) {
+			group = getCell(row, t.groupColumn)
+			writeMarkdownRow(&buf, []string{"**" + escapeMarkdownCell(group) + "**"}, totalColumns, false)
+		}
+
+		writeMarkdownRow(&buf, row, totalColumns, true)
+	}
+
+	if t.footerData != nil {
+		writeMarkdownRow(&buf, t.footerData, totalColumns, true)
+	}
+
+	return buf.Bytes()
+}
+
+// renderJSON render table as JSON array. If table has headers, rows are
+// rendered as objects with headers as keys (columns without headers use
+// column number as key, repeated keys get numeric suffix), otherwise rows
+// are rendered as arrays.
+func renderJSON(t *Table) []byte {
+	var buf bytes.Buffer
+
+	keys := getJSONKeys(t)
+
+	buf.WriteString("[")
+
+	for rowIndex, row := range t.data {
+		if rowIndex != 0 {
+			buf.WriteString(",")
+		}
+
+		buf.WriteString("\n  ")
+
+		row = cleanRow(row)
+
+		if len(t.Headers) == 0 {
+			data, _ := json.Marshal(row)
+			buf.Write(data)
+			continue
+		}
+
+		buf.WriteString("{")
+
+		for columnIndex, cell := range row {
+			if columnIndex != 0 {
+				buf.WriteString(",")
+			}
+
+			keyData, _ := json.Marshal(keys[columnIndex])
+			valueData, _ := json.Marshal(cell)
+
+			buf.Write(keyData)
+			buf.WriteString(":")
+			buf.Write(valueData)
+		}
+
+		buf.WriteString("}")
+	}
+
+	if len(t.data) != 0 {
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("]\n")
+
+	return buf.Bytes()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// writeMarkdownRow write row of markdown table
+func writeMarkdownRow(buf *bytes.Buffer, row []string, totalColumns int, escape bool) {
+	buf.WriteString("|")
+
+	for columnIndex := 0; columnIndex < totalColumns; columnIndex++ {
+		var cell string
+
+		if columnIndex < len(row) {
+			cell = fmtc.Clean(row[columnIndex])
+		}
+
+		if escape {
+			cell = escapeMarkdownCell(cell)
+		}
+
+		buf.WriteString(" " + cell + " |")
+	}
+
+	buf.WriteString("\n")
+}
+
+// cleanRow return row data without color tags
+func cleanRow(row []string) []string {
+	result := make([]string, len(row))
+
+	for index, cell := range row {
+		result[index] = fmtc.Clean(cell)
+	}
+
+	return result
+}
+
+// getJSONKeys return unique keys of columns for JSON objects
+func getJSONKeys(t *Table) []string {
+	totalColumns := getTotalColumns(t)
+	result := make([]string, totalColumns)
+	used := make(map[string]bool)
+
+	for columnIndex := 0; columnIndex < totalColumns; columnIndex++ {
+		var key string
+
+		if columnIndex < len(t.Headers) {
+			key = fmtc.Clean(t.Headers[columnIndex])
+		}
+
+		if key == "" {
+			key = strconv.Itoa(columnIndex + 1)
+		}
+
+		unique := key
+
+		for num := 2; used[unique]; num++ {
+			unique = key + "_" + strconv.Itoa(num)
+		}
+
+		used[unique] = true
+		result[columnIndex] = unique
+	}
+
+	return result
+}
+
+// getTotalColumns return number of columns including columns with headers
+// but without data
+func getTotalColumns(t *Table) int {
+	return mathutil.Max(getColumnsNum(t), len(t.Headers))
+}
+
+// escapeMarkdownCell escape symbols which break markdown table cell
+func escapeMarkdownCell(text string) string {
+	text = strings.Replace(text, "\\", "\\\\", -1)
+	text = strings.Replace(text, "|", "\\|", -1)
+
+	return strings.Replace(text, "\n", "<br>", -1)
+}
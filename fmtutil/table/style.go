@@ -0,0 +1,162 @@
+package table
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"strings"
+
+	"pkg.re/essentialkaos/ek.v9/strutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Style contains symbols used for rendering table borders
+type Style struct {
+	Top    StyleLine // Line above table
+	Header StyleLine // Line between headers and data
+	Middle StyleLine // Line between groups and before footer
+	Bottom StyleLine // Line below table
+
+	Vertical string // Columns separator
+	Left     string // Left border (empty if table doesn't have outer borders)
+	Right    string // Right border (empty if table doesn't have outer borders)
+
+	ColorTag string // fmtc tag used for borders
+}
+
+// StyleLine contains symbols for horizontal line, line is not rendered if
+// horizontal symbol is empty
+type StyleLine struct {
+	Left       string
+	Middle     string
+	Right      string
+	Horizontal string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// StyleASCII is style with outer borders drawn by ASCII symbols
+var StyleASCII = &Style{
+	Top:    StyleLine{"+", "+", "+", "-"},
+	Header: StyleLine{"+", "+", "+", "="},
+	Middle: StyleLine{"+", "+", "+", "-"},
+	Bottom: StyleLine{"+", "+", "+", "-"},
+
+	Vertical: "|", Left: "|", Right: "|",
+	ColorTag: "{?border}",
+}
+
+// StyleLight is style with light Unicode box drawing symbols
+var StyleLight = &Style{
+	Top:    StyleLine{"┌", "┬", "┐", "─"},
+	Header: StyleLine{"├", "┼", "┤", "─"},
+	Middle: StyleLine{"├", "┼", "┤", "─"},
+	Bottom: StyleLine{"└", "┴", "┘", "─"},
+
+	Vertical: "│", Left: "│", Right: "│",
+	ColorTag: "{?border}",
+}
+
+// StyleHeavy is style with heavy Unicode box drawing symbols
+var StyleHeavy = &Style{
+	Top:    StyleLine{"┏", "┳", "┓", "━"},
+	Header: StyleLine{"┣", "╋", "┫", "━"},
+	Middle: StyleLine{"┣", "╋", "┫", "━"},
+	Bottom: StyleLine{"┗", "┻", "┛", "━"},
+
+	Vertical: "┃", Left: "┃", Right: "┃",
+	ColorTag: "{?border}",
+}
+
+// StyleDouble is style with double Unicode box drawing symbols
+var StyleDouble = &Style{
+	Top:    StyleLine{"╔", "╦", "╗", "═"},
+	Header: StyleLine{"╠", "╬", "╣", "═"},
+	Middle: StyleLine{"╠", "╬", "╣", "═"},
+	Bottom: StyleLine{"╚", "╩", "╝", "═"},
+
+	Vertical: "║", Left: "║", Right: "║",
+	ColorTag: "{?border}",
+}
+
+// StyleBorderless is style without any borders and separators
+var StyleBorderless = &Style{}
+
+// StyleCompact is style without outer borders and column separators, only
+// headers are underlined
+var StyleCompact = &Style{
+	Header: StyleLine{"", " ", "", "-"},
+
+	Vertical: " ",
+	ColorTag: "{?border}",
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// SetStyle allow to set table style
+func (t *Table) SetStyle(style *Style) *Table {
+	if t == nil {
+		return nil
+	}
+
+	t.Style = style
+
+	return t
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getStyle return table style (default style is based on SeparatorSymbol and
+// ColumnSeparatorSymbol)
+func getStyle(t *Table) *Style {
+	if t.Style != nil {
+		return t.Style
+	}
+
+	line := StyleLine{"", SeparatorSymbol, "", SeparatorSymbol}
+
+	return &Style{
+		Top: line, Header: line, Middle: line, Bottom: line,
+
+		Vertical: ColumnSeparatorSymbol,
+		ColorTag: "{?border}",
+	}
+}
+
+// colorBorder add style color tag to border symbols
+func colorBorder(style *Style, border string) string {
+	if border == "" || style.ColorTag == "" {
+		return border
+	}
+
+	return style.ColorTag + border + "{!}"
+}
+
+// getBordersWidth return width of borders, separators and paddings for
+// given number of columns
+func getBordersWidth(style *Style, columns int) int {
+	if columns == 0 {
+		return 0
+	}
+
+	return columns*2 + (columns-1)*strutil.Width(style.Vertical) +
+		strutil.Width(style.Left) + strutil.Width(style.Right)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// repeatToWidth repeat symbol to fill given width
+func repeatToWidth(symbol string, width int) string {
+	symbolWidth := strutil.Width(symbol)
+
+	if symbolWidth == 0 || width <= 0 {
+		return ""
+	}
+
+	return strings.Repeat(symbol, width/symbolWidth)
+}
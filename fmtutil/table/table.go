@@ -9,10 +9,15 @@ package table
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
 	"pkg.re/essentialkaos/ek.v9/fmtc"
+	"pkg.re/essentialkaos/ek.v9/fmtutil"
 	"pkg.re/essentialkaos/ek.v9/mathutil"
 	"pkg.re/essentialkaos/ek.v9/strutil"
 	"pkg.re/essentialkaos/ek.v9/terminal/window"
@@ -27,38 +32,85 @@ const (
 	ALIGN_RIGHT        = 2
 )
 
+// Output formats
+const (
+	FORMAT_TEXT     uint8 = 0 // Colored fixed-width text
+	FORMAT_PLAIN          = 1 // Fixed-width text without colors
+	FORMAT_CSV            = 2
+	FORMAT_TSV            = 3
+	FORMAT_MARKDOWN       = 4
+	FORMAT_JSON           = 5
+)
+
+// Column overflow policies
+const (
+	OVERFLOW_ELLIPSIS        uint8 = 0 // Cut text and add ellipsis at the end
+	OVERFLOW_ELLIPSIS_MIDDLE       = 1 // Cut text and add ellipsis in the middle
+	OVERFLOW_WRAP                  = 2 // Wrap text onto multiple lines
+	OVERFLOW_HIDE                  = 3 // Hide column if table doesn't fit window
+)
+
+const _MIN_COLUMN_SIZE = 5
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 type Table struct {
 	Sizes     []int    // Custom columns sizes
 	Headers   []string // Slice with headers
 	Alignment []uint8  // Columns alignment
+	Format    uint8    // Output format
+	Style     *Style   // Borders style (nil for default style)
+
+	// Columns overflow policies
+	Overflow []uint8
+
+	// Columns priorities, if table doesn't fit window width, columns with
+	// OVERFLOW_HIDE policy and lowest priority are hidden first
+	Priority []int
+
+	// Footer aggregate functions
+	Footer []uint8
 
 	// Slice with data
 	data [][]string
 
-	// Separator cache
-	separator string
+	// Sorting settings (nil if sorting is disabled)
+	sorting *sortInfo
+
+	// Grouping settings
+	grouping    bool
+	groupColumn int
+
+	// Footer row with aggregated values
+	footerData []string
 
 	// Flag will be set if header is rendered
 	headerShown bool
 
 	// Slice with auto calculated sizes
 	columnSizes []int
+
+	// Slice with indexes of visible columns
+	visibleColumns []int
 }
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
+// ErrTableIsNil is returned if table struct is nil
+var ErrTableIsNil = errors.New("Table is nil")
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
 // HeaderCapitalize is flag for capitalizing headers by default
 var HeaderCapitalize = false
 
 // HeaderColorTag is fmtc tag used for headers by default for all tables
-var HeaderColorTag = "{*}"
+var HeaderColorTag = "{?header}"
 
-// SeparatorSymbol used for separator generation
+// SeparatorSymbol used for separator generation in default style
 var SeparatorSymbol = "-"
 
-// ColumnSeparatorSymbol is column separator symbol
+// ColumnSeparatorSymbol is column separator symbol in default style
 var ColumnSeparatorSymbol = "|"
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -103,6 +155,39 @@ func (t *Table) SetAlignments(align ...uint8) *Table {
 	return t
 }
 
+// SetOverflows allow to set columns overflow policies
+func (t *Table) SetOverflows(policies ...uint8) *Table {
+	if t == nil {
+		return nil
+	}
+
+	t.Overflow = policies
+
+	return t
+}
+
+// SetPriorities allow to set columns priorities
+func (t *Table) SetPriorities(priorities ...int) *Table {
+	if t == nil {
+		return nil
+	}
+
+	t.Priority = priorities
+
+	return t
+}
+
+// SetFormat allow to set output format
+func (t *Table) SetFormat(format uint8) *Table {
+	if t == nil {
+		return nil
+	}
+
+	t.Format = format
+
+	return t
+}
+
 // Add add given data to stack
 func (t *Table) Add(data ...interface{}) *Table {
 	if t == nil {
@@ -128,8 +213,12 @@ func (t *Table) Print(data ...interface{}) *Table {
 		return t
 	}
 
-	prepareRender(t)
-	renderRowData(t, convertSlice(data), len(t.columnSizes))
+	var buf bytes.Buffer
+
+	prepareRender(t, &buf)
+	renderRowData(t, &buf, convertSlice(data))
+
+	fmtc.Fprint(os.Stdout, buf.String())
 
 	return t
 }
@@ -145,126 +234,252 @@ func (t *Table) Separator() *Table {
 		return nil
 	}
 
-	if t.separator == "" {
-		t.separator = strings.Repeat(SeparatorSymbol, getSeparatorSize(t))
-	}
+	var buf bytes.Buffer
+
+	renderLine(t, &buf, getStyle(t).Middle)
 
-	fmtc.Println("{s}" + t.separator + "{!}")
+	fmtc.Fprint(os.Stdout, buf.String())
 
 	return t
 }
 
-// Render render data
+// Render render data to stdout
 func (t *Table) Render() *Table {
 	if t == nil {
 		return nil
 	}
 
+	t.RenderTo(os.Stdout)
+
+	return t
+}
+
+// RenderTo render data to given writer using table output format. In text format,
+// colors are used only if writer is a terminal.
+func (t *Table) RenderTo(w io.Writer) error {
+	if t == nil {
+		return ErrTableIsNil
+	}
+
 	// Nothing to render
 	if len(t.Headers) == 0 && len(t.data) == 0 {
-		return t
+		return nil
 	}
 
-	prepareRender(t)
+	var data []byte
 
-	if len(t.Headers) == 0 {
-		t.Separator()
+	prepareData(t)
+
+	switch t.Format {
+	case FORMAT_PLAIN:
+		data = []byte(fmtc.Clean(renderText(t)))
+	case FORMAT_CSV:
+		data = renderCSV(t, ',')
+	case FORMAT_TSV:
+		data = renderCSV(t, '\t')
+	case FORMAT_MARKDOWN:
+		data = renderMarkdown(t)
+	case FORMAT_JSON:
+		data = renderJSON(t)
 	}
 
-	if t.data != nil {
-		renderData(t)
+	var err error
+
+	// Colors are used only if writer is a terminal
+	if data == nil {
+		_, err = fmtc.Fprint(w, renderText(t))
+	} else {
+		_, err = w.Write(data)
 	}
 
 	// Remove data after rendering
-	t.separator = ""
 	t.data = nil
+	t.footerData = nil
 	t.columnSizes = nil
+	t.visibleColumns = nil
 	t.headerShown = false
 
-	return t
+	return err
 }
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
+// renderText render table as text with color tags
+func renderText(t *Table) string {
+	var buf bytes.Buffer
+
+	prepareRender(t, &buf)
+
+	if len(t.Headers) == 0 {
+		renderLine(t, &buf, getStyle(t).Top)
+	}
+
+	if t.data != nil {
+		renderData(t, &buf)
+	}
+
+	return buf.String()
+}
+
 // prepareRender prepare table for render
-func prepareRender(t *Table) {
+func prepareRender(t *Table, buf *bytes.Buffer) {
 	if len(t.columnSizes) == 0 {
 		calculateColumnSizes(t)
 	}
 
 	if !t.headerShown {
-		renderHeaders(t)
+		renderHeaders(t, buf)
 	}
 }
 
-// renderHeaders render headers
-func renderHeaders(t *Table) {
-	t.headerShown = true
+// renderLine render horizontal line
+func renderLine(t *Table, buf *bytes.Buffer, line StyleLine) {
+	if line.Horizontal == "" {
+		return
+	}
 
-	if len(t.Headers) == 0 {
+	if len(t.visibleColumns) == 0 {
+		buf.WriteString(colorBorder(getStyle(t), repeatToWidth(line.Horizontal, getWindowWidth())) + "\n")
 		return
 	}
 
-	t.Separator()
+	data := line.Left
 
-	totalHeaders := len(t.Headers)
-	totalColumns := len(t.columnSizes)
+	for index, columnIndex := range t.visibleColumns {
+		if index != 0 {
+			data += line.Middle
+		}
 
-	var headerText string
+		data += repeatToWidth(line.Horizontal, t.columnSizes[columnIndex]+2)
+	}
 
-	for columnIndex, columnSize := range t.columnSizes {
-		if columnIndex >= totalHeaders {
-			headerText = strings.Repeat(" ", columnSize)
-		} else {
-			headerText = t.Headers[columnIndex]
-		}
+	buf.WriteString(colorBorder(getStyle(t), data+line.Right) + "\n")
+}
+
+// renderHeaders render headers
+func renderHeaders(t *Table, buf *bytes.Buffer) {
+	t.headerShown = true
+
+	if len(t.Headers) == 0 {
+		return
+	}
+
+	style := getStyle(t)
+	headers := make([]string, len(t.Headers))
 
+	for index, headerText := range t.Headers {
 		if HeaderCapitalize {
 			headerText = strings.ToUpper(headerText)
 		}
 
-		fmtc.Printf(" " + HeaderColorTag + formatText(headerText, t.columnSizes[columnIndex], getAlignment(t, columnIndex)) + "{!} ")
-
-		if columnIndex+1 != totalColumns {
-			fmtc.Printf("{s}%s{!}", ColumnSeparatorSymbol)
-		} else {
-			fmtc.NewLine()
-		}
+		headers[index] = HeaderColorTag + headerText + "{!}"
 	}
 
-	t.Separator()
+	renderLine(t, buf, style.Top)
+	renderRowData(t, buf, headers)
+	renderLine(t, buf, style.Header)
 }
 
 // renderData render table data
-func renderData(t *Table) {
-	totalColumns := len(t.columnSizes)
+func renderData(t *Table, buf *bytes.Buffer) {
+	var group string
+
+	style := getStyle(t)
+
+	for rowIndex, rowData := range t.data {
+		if t.grouping && (rowIndex == 0 || getCell(rowData, t.groupColumn) != group) {
+			if rowIndex != 0 {
+				renderLine(t, buf, style.Middle)
+			}
+
+			group = getCell(rowData, t.groupColumn)
+			renderGroupHeader(t, buf, group)
+		}
 
-	for _, rowData := range t.data {
-		renderRowData(t, rowData, totalColumns)
+		renderRowData(t, buf, rowData)
 	}
 
-	t.Separator()
+	if t.footerData != nil {
+		renderLine(t, buf, style.Middle)
+		renderRowData(t, buf, t.footerData)
+	}
+
+	renderLine(t, buf, style.Bottom)
 }
 
-// renderRowData render data in row
-func renderRowData(t *Table, rowData []string, totalColumns int) {
-	for columnIndex, columnData := range rowData {
-		if columnIndex == totalColumns {
-			break
+// renderGroupHeader render group subheading
+func renderGroupHeader(t *Table, buf *bytes.Buffer, group string) {
+	style := getStyle(t)
+	width := getTableWidth(t) - strutil.Width(style.Left) - strutil.Width(style.Right) - 2
+
+	buf.WriteString(colorBorder(style, style.Left))
+	buf.WriteString(" " + HeaderColorTag + formatText(strutil.EllipsisWidth(group, width), width, ALIGN_LEFT) + "{!} ")
+	buf.WriteString(colorBorder(style, style.Right) + "\n")
+}
+
+// renderRowData render data in row, cells with long or multi-line text can
+// take several lines
+func renderRowData(t *Table, buf *bytes.Buffer, rowData []string) {
+	style := getStyle(t)
+	totalColumns := len(t.visibleColumns)
+	cells := make([][]string, totalColumns)
+	rowHeight := 1
+
+	for index, columnIndex := range t.visibleColumns {
+		if columnIndex >= len(rowData) {
+			continue
+		}
+
+		cells[index] = getCellLines(t, rowData[columnIndex], columnIndex)
+		rowHeight = mathutil.Max(rowHeight, len(cells[index]))
+	}
+
+	for line := 0; line < rowHeight; line++ {
+		buf.WriteString(colorBorder(style, style.Left))
+
+		for index, columnIndex := range t.visibleColumns {
+			var text string
+
+			if line < len(cells[index]) {
+				text = cells[index][line]
+			}
+
+			if index != 0 {
+				buf.WriteString(colorBorder(style, style.Vertical))
+			}
+
+			buf.WriteString(" " + formatText(text, t.columnSizes[columnIndex], getAlignment(t, columnIndex)) + " ")
 		}
 
-		if strutil.Len(fmtc.Clean(columnData)) > t.columnSizes[columnIndex] {
-			fmtc.Printf(" " + strutil.Ellipsis(columnData, t.columnSizes[columnIndex]) + " ")
-		} else {
-			fmtc.Printf(" " + formatText(columnData, t.columnSizes[columnIndex], getAlignment(t, columnIndex)) + " ")
+		buf.WriteString(colorBorder(style, style.Right) + "\n")
+	}
+}
+
+// getCellLines return lines of cell text which fit column size
+func getCellLines(t *Table, text string, columnIndex int) []string {
+	var result []string
+
+	size := t.columnSizes[columnIndex]
+
+	for _, line := range strings.Split(text, "\n") {
+		if getTextSize(line) <= size {
+			result = append(result, line)
+			continue
 		}
 
-		if columnIndex+1 != totalColumns {
-			fmtc.Printf("{s}%s{!}", ColumnSeparatorSymbol)
+		line = fmtc.Clean(line)
+
+		switch getOverflow(t, columnIndex) {
+		case OVERFLOW_WRAP:
+			result = append(result, wrapText(line, size)...)
+		case OVERFLOW_ELLIPSIS_MIDDLE:
+			result = append(result, strutil.EllipsisMiddleWidth(line, size))
+		default:
+			result = append(result, strutil.EllipsisWidth(line, size))
 		}
 	}
 
-	fmtc.NewLine()
+	return result
 }
 
 // convertSlice convert slice with interface{} to slice with strings
@@ -294,7 +509,7 @@ func calculateColumnSizes(t *Table) {
 	if len(t.data) > 0 {
 		for _, row := range t.data {
 			for index, item := range row {
-				itemSizes := strutil.Len(fmtc.Clean(item))
+				itemSizes := getTextSize(item)
 
 				if itemSizes > t.columnSizes[index] {
 					t.columnSizes[index] = itemSizes
@@ -303,9 +518,19 @@ func calculateColumnSizes(t *Table) {
 		}
 	}
 
+	for index, item := range t.footerData {
+		if index < totalColumns && getTextSize(item) > t.columnSizes[index] {
+			t.columnSizes[index] = getTextSize(item)
+		}
+	}
+
 	if len(t.Headers) > 0 {
 		for index, header := range t.Headers {
-			headerSize := strutil.Len(header)
+			if index == totalColumns {
+				break
+			}
+
+			headerSize := strutil.Width(header)
 
 			if headerSize > t.columnSizes[index] {
 				t.columnSizes[index] = headerSize
@@ -313,16 +538,82 @@ func calculateColumnSizes(t *Table) {
 		}
 	}
 
-	var fullSize int
+	t.visibleColumns = make([]int, totalColumns)
+
+	for columnIndex := range t.visibleColumns {
+		t.visibleColumns[columnIndex] = columnIndex
+	}
+
+	if totalColumns == 0 {
+		return
+	}
 
 	windowWidth := getWindowWidth()
 
-	for columnIndex, columnSize := range t.columnSizes {
-		if columnIndex+1 == totalColumns {
-			t.columnSizes[columnIndex] = ((windowWidth - fullSize) - (totalColumns * 3)) + 1
+	hideColumns(t, windowWidth)
+	shrinkColumns(t, windowWidth)
+
+	// Last column takes all free space, but if table doesn't fit the window
+	// even after shrinking, table overflows
+	var fullSize int
+
+	for _, columnIndex := range t.visibleColumns[:len(t.visibleColumns)-1] {
+		fullSize += t.columnSizes[columnIndex]
+	}
+
+	lastColumn := t.visibleColumns[len(t.visibleColumns)-1]
+	minSize := mathutil.Min(t.columnSizes[lastColumn], _MIN_COLUMN_SIZE)
+	freeSize := windowWidth - fullSize - getBordersWidth(getStyle(t), len(t.visibleColumns))
+
+	t.columnSizes[lastColumn] = mathutil.Max(freeSize, minSize)
+}
+
+// hideColumns hide columns with OVERFLOW_HIDE policy and lowest priority
+// until table fits given width
+func hideColumns(t *Table, width int) {
+	for getTableWidth(t) > width && len(t.visibleColumns) > 1 {
+		hideIndex := -1
+
+		for index, columnIndex := range t.visibleColumns {
+			if getOverflow(t, columnIndex) != OVERFLOW_HIDE {
+				continue
+			}
+
+			priority := getPriority(t, columnIndex)
+
+			if hideIndex == -1 || priority <= getPriority(t, t.visibleColumns[hideIndex]) {
+				hideIndex = index
+			}
+		}
+
+		if hideIndex == -1 {
+			return
 		}
 
-		fullSize += columnSize
+		t.visibleColumns = append(t.visibleColumns[:hideIndex], t.visibleColumns[hideIndex+1:]...)
+	}
+}
+
+// shrinkColumns reduce size of the widest columns until table fits given width
+func shrinkColumns(t *Table, width int) {
+	for excess := getTableWidth(t) - width; excess > 0; excess-- {
+		widestColumn := -1
+
+		for _, columnIndex := range t.visibleColumns {
+			if t.columnSizes[columnIndex] <= _MIN_COLUMN_SIZE {
+				continue
+			}
+
+			if widestColumn == -1 || t.columnSizes[columnIndex] > t.columnSizes[widestColumn] {
+				widestColumn = columnIndex
+			}
+		}
+
+		if widestColumn == -1 {
+			return
+		}
+
+		t.columnSizes[widestColumn]--
 	}
 }
 
@@ -351,13 +642,7 @@ func getColumnsNum(t *Table) int {
 
 // formatText align text with color tags
 func formatText(data string, size int, align uint8) string {
-	var dataSize int
-
-	if strings.Contains(data, "{") {
-		dataSize = strutil.Len(fmtc.Clean(data))
-	} else {
-		dataSize = strutil.Len(data)
-	}
+	dataSize := getTextSize(data)
 
 	if dataSize >= size {
 		return data
@@ -376,6 +661,55 @@ func formatText(data string, size int, align uint8) string {
 	return data + strings.Repeat(" ", size-dataSize)
 }
 
+// getTextSize return width of text without color tags (width of the longest
+// line for multi-line text)
+func getTextSize(text string) int {
+	if strings.Contains(text, "{") {
+		text = fmtc.Clean(text)
+	}
+
+	if !strings.Contains(text, "\n") {
+		return strutil.Width(text)
+	}
+
+	var size int
+
+	for _, line := range strings.Split(text, "\n") {
+		size = mathutil.Max(size, strutil.Width(line))
+	}
+
+	return size
+}
+
+// wrapText wrap text onto lines with given max size
+func wrapText(text string, size int) []string {
+	var result []string
+
+	for _, line := range strings.Split(fmtutil.Wrap(text, "", size), "\n") {
+		line = strings.TrimRight(line, " ")
+
+		if line == "" {
+			continue
+		}
+
+		// Cut words which are longer than column
+		for strutil.Width(line) > size {
+			part := strutil.HeadWidth(line, size)
+
+			if part == "" {
+				break
+			}
+
+			result = append(result, part)
+			line = line[len(part):]
+		}
+
+		result = append(result, line)
+	}
+
+	return result
+}
+
 // getAlignment return align for given column
 func getAlignment(t *Table, columnIndex int) uint8 {
 	l := len(t.Alignment)
@@ -387,19 +721,37 @@ func getAlignment(t *Table, columnIndex int) uint8 {
 	return t.Alignment[columnIndex]
 }
 
-// getSeparatorSize return separator size based on size of all columns
-func getSeparatorSize(t *Table) int {
-	if len(t.columnSizes) == 0 {
+// getOverflow return overflow policy for given column
+func getOverflow(t *Table, columnIndex int) uint8 {
+	if columnIndex >= len(t.Overflow) {
+		return OVERFLOW_ELLIPSIS
+	}
+
+	return t.Overflow[columnIndex]
+}
+
+// getPriority return priority of given column
+func getPriority(t *Table, columnIndex int) int {
+	if columnIndex >= len(t.Priority) {
+		return 0
+	}
+
+	return t.Priority[columnIndex]
+}
+
+// getTableWidth return table width based on size of all visible columns
+func getTableWidth(t *Table) int {
+	if len(t.visibleColumns) == 0 {
 		return getWindowWidth()
 	}
 
 	var size int
 
-	for _, columnSize := range t.columnSizes {
-		size += columnSize
+	for _, columnIndex := range t.visibleColumns {
+		size += t.columnSizes[columnIndex]
 	}
 
-	return size + (len(t.columnSizes) * 3) - 1
+	return size + getBordersWidth(getStyle(t), len(t.visibleColumns))
 }
 
 // getWindowWidth return window width
@@ -8,10 +8,14 @@ package table
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 import (
+	"bytes"
+	"fmt"
 	"strings"
 	"testing"
 
 	. "pkg.re/check.v1"
+
+	"pkg.re/essentialkaos/ek.v9/strutil"
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -140,6 +144,318 @@ func (s *TableSuite) TestRender(c *C) {
 	HeaderCapitalize = false
 }
 
+func (s *TableSuite) TestRenderTo(c *C) {
+	var t *Table
+
+	c.Assert(t.RenderTo(&bytes.Buffer{}), Equals, ErrTableIsNil)
+	c.Assert(t.SetFormat(FORMAT_CSV), IsNil)
+
+	buf := &bytes.Buffer{}
+	t = NewTable()
+
+	c.Assert(t.RenderTo(buf), IsNil)
+	c.Assert(buf.Len(), Equals, 0)
+
+	t = NewTable("id", "name", "price").SetAlignments(ALIGN_LEFT, ALIGN_CENTER, ALIGN_RIGHT)
+
+	t.Add(1, "{g}Bob{!}", 1.42).Add(2, "John \"Doe\"", "a|b")
+
+	c.Assert(t.RenderTo(buf), IsNil)
+	c.Assert(buf.String(), Matches, "(?s).*Bob.*John \"Doe\".*")
+	c.Assert(buf.String(), Not(Matches), "(?s).*\x1b.*")
+	c.Assert(t.HasData(), Equals, false)
+
+	testFormats := map[uint8]string{
+		FORMAT_CSV:      "id,name,price\n1,Bob,1.42\n2,\"John \"\"Doe\"\"\",a|b\n",
+		FORMAT_TSV:      "id\tname\tprice\n1\tBob\t1.42\n2\t\"John \"\"Doe\"\"\"\ta|b\n",
+		FORMAT_MARKDOWN: "| id | name | price |\n|---|:---:|---:|\n| 1 | Bob | 1.42 |\n| 2 | John \"Doe\" | a\\|b |\n",
+		FORMAT_JSON:     "[\n  {\"id\":\"1\",\"name\":\"Bob\",\"price\":\"1.42\"},\n  {\"id\":\"2\",\"name\":\"John \\\"Doe\\\"\",\"price\":\"a|b\"}\n]\n",
+	}
+
+	for format, expected := range testFormats {
+		buf.Reset()
+
+		t.SetFormat(format)
+		t.Add(1, "{g}Bob{!}", 1.42).Add(2, "John \"Doe\"", "a|b")
+
+		c.Assert(t.RenderTo(buf), IsNil)
+		c.Assert(buf.String(), Equals, expected)
+	}
+
+	buf.Reset()
+
+	t = NewTable().SetFormat(FORMAT_JSON)
+	t.Add(1, "{g}Bob{!}")
+
+	c.Assert(t.RenderTo(buf), IsNil)
+	c.Assert(buf.String(), Equals, "[\n  [\"1\",\"Bob\"]\n]\n")
+
+	testFormats = map[uint8]string{
+		FORMAT_CSV:      "id,name,name,,name\n1,Bob,Smith,2,x\n",
+		FORMAT_MARKDOWN: "| id | name | name |  | name |\n|---|---|---|---|---|\n| 1 | Bob | Smith | 2 | x |\n",
+		FORMAT_JSON:     "[\n  {\"id\":\"1\",\"name\":\"Bob\",\"name_2\":\"Smith\",\"4\":\"2\",\"name_3\":\"x\"}\n]\n",
+	}
+
+	// Headers with color tags, duplicate and empty headers
+	for format, expected := range testFormats {
+		buf.Reset()
+
+		t = NewTable("{*}id{!}", "{g}name{!}", "name", "", "name").SetFormat(format)
+		t.Add(1, "Bob", "Smith", 2, "x")
+
+		c.Assert(t.RenderTo(buf), IsNil)
+		c.Assert(buf.String(), Equals, expected, Commentf("Format: %d", format))
+	}
+
+	buf.Reset()
+
+	t = NewTable("id", "name").SetFormat(FORMAT_PLAIN)
+	t.Add(1, "{g}Bob{!}")
+
+	c.Assert(t.RenderTo(buf), IsNil)
+	c.Assert(buf.String(), Not(Matches), "(?s).*\x1b.*")
+	c.Assert(buf.String(), Matches, "(?s).* Bob .*")
+}
+
+func (s *TableSuite) TestParseFormat(c *C) {
+	formats := map[string]uint8{
+		"": FORMAT_TEXT, "text": FORMAT_TEXT, "plain": FORMAT_PLAIN, "CSV": FORMAT_CSV,
+		"tsv": FORMAT_TSV, "markdown": FORMAT_MARKDOWN, "md": FORMAT_MARKDOWN, "json": FORMAT_JSON,
+	}
+
+	for name, expected := range formats {
+		format, err := ParseFormat(name)
+
+		c.Assert(err, IsNil)
+		c.Assert(format, Equals, expected)
+	}
+
+	_, err := ParseFormat("xml")
+
+	c.Assert(err, NotNil)
+}
+
+func (s *TableSuite) TestOverflow(c *C) {
+	var t *Table
+
+	c.Assert(t.SetOverflows(OVERFLOW_WRAP), IsNil)
+	c.Assert(t.SetPriorities(1), IsNil)
+
+	buf := &bytes.Buffer{}
+	longText := strings.Repeat("lorem ipsum ", 20)
+	longPath := "/" + strings.Repeat("directory/", 15) + "file.txt"
+
+	t = NewTable("id", "path", "desc").SetFormat(FORMAT_PLAIN)
+	t.SetOverflows(OVERFLOW_ELLIPSIS, OVERFLOW_ELLIPSIS_MIDDLE, OVERFLOW_WRAP)
+	t.Add(1, longPath, longText)
+	t.Add(2, "/tmp", "line1\nline2")
+
+	c.Assert(t.RenderTo(buf), IsNil)
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+
+	for _, line := range lines {
+		c.Assert(len(line) <= 88, Equals, true, Commentf("Line: %q", line))
+	}
+
+	c.Assert(buf.String(), Matches, "(?s).* /directory/directo\\.\\.\\.directory/file\\.txt .*")
+	c.Assert(buf.String(), Matches, "(?s).*\\| line1 +\n.*\\| line2 +\n.*")
+	c.Assert(strings.Count(buf.String(), "lorem"), Equals, 20)
+	c.Assert(lines, HasLen, 13)
+
+	buf.Reset()
+
+	t = NewTable("id", "path", "desc", "size").SetFormat(FORMAT_PLAIN)
+	t.SetOverflows(OVERFLOW_ELLIPSIS, OVERFLOW_HIDE, OVERFLOW_WRAP, OVERFLOW_HIDE)
+	t.SetPriorities(0, 1, 0, 2)
+	t.Add(1, longPath, longText, "10 KB")
+
+	c.Assert(t.RenderTo(buf), IsNil)
+	c.Assert(buf.String(), Not(Matches), "(?s).*path.*")
+	c.Assert(buf.String(), Not(Matches), "(?s).*size.*")
+	c.Assert(buf.String(), Matches, "(?s).*desc.*")
+
+	buf.Reset()
+
+	t = NewTable("id", "path", "desc", "size").SetFormat(FORMAT_PLAIN)
+	t.SetOverflows(OVERFLOW_ELLIPSIS, OVERFLOW_HIDE, OVERFLOW_WRAP, OVERFLOW_HIDE)
+	t.SetPriorities(0, 1, 0, 2)
+	t.Add(1, longPath, "test", "10 KB")
+
+	c.Assert(t.RenderTo(buf), IsNil)
+	c.Assert(buf.String(), Not(Matches), "(?s).*path.*")
+	c.Assert(buf.String(), Matches, "(?s).*size.*")
+
+	buf.Reset()
+
+	t = NewTable("id", "path", "desc").SetFormat(FORMAT_PLAIN)
+	t.SetOverflows(OVERFLOW_ELLIPSIS, OVERFLOW_HIDE)
+	t.Add(1, "/tmp", "test")
+
+	c.Assert(t.RenderTo(buf), IsNil)
+	c.Assert(buf.String(), Matches, "(?s).*path.*")
+	buf.Reset()
+
+	var headers []string
+	var row []interface{}
+
+	for i := 1; i <= 18; i++ {
+		headers = append(headers, fmt.Sprintf("col-%02d", i))
+		row = append(row, fmt.Sprintf("val-%02d", i))
+	}
+
+	t = NewTable(headers...).SetFormat(FORMAT_PLAIN)
+	t.Add(row...)
+
+	c.Assert(t.RenderTo(buf), IsNil)
+
+	c.Assert(strings.Count(buf.String(), "co..."), Equals, 18)
+	c.Assert(strings.Count(buf.String(), "va..."), Equals, 18)
+}
+
+func (s *TableSuite) TestTextHelpers(c *C) {
+	c.Assert(wrapText("abcd efgh ijkl", 9), DeepEquals, []string{"abcd efgh", "ijkl"})
+	c.Assert(wrapText("abcdefghijkl mn", 5), DeepEquals, []string{"abcde", "fghij", "kl", "mn"})
+	c.Assert(getTextSize("{g}abc{!}\nabcde"), Equals, 5)
+	c.Assert(getTextSize("{=https://domain.com}abc{=}"), Equals, 3)
+}
+
+func (s *TableSuite) TestSorting(c *C) {
+	var t *Table
+
+	c.Assert(t.SortBy(0, SORT_NATURAL, false), IsNil)
+	c.Assert(t.GroupBy(0), IsNil)
+	c.Assert(t.SetFooter(AGGREGATE_SUM), IsNil)
+
+	buf := &bytes.Buffer{}
+
+	t = NewTable("name", "size").SetFormat(FORMAT_CSV)
+	t.Add("file10", "1,024").Add("file2", 5).Add("{g}file1{!}", "-").Add("file3", 100.5)
+	t.SortBy(0, SORT_NATURAL, false)
+
+	c.Assert(t.RenderTo(buf), IsNil)
+	c.Assert(buf.String(), Equals, "name,size\nfile1,-\nfile2,5\nfile3,100.5\nfile10,\"1,024\"\n")
+
+	buf.Reset()
+
+	t.Add("file10", "1,024").Add("file2", 5).Add("{g}file1{!}", "-").Add("file3", 100.5)
+	t.SortBy(1, SORT_NUMERIC, false)
+
+	c.Assert(t.RenderTo(buf), IsNil)
+	c.Assert(buf.String(), Equals, "name,size\nfile2,5\nfile3,100.5\nfile10,\"1,024\"\nfile1,-\n")
+
+	buf.Reset()
+
+	t.Add("file10", "1,024").Add("file2", 5).Add("{g}file1{!}", "-").Add("file3", 100.5)
+	t.SortBy(1, SORT_NUMERIC, true)
+
+	c.Assert(t.RenderTo(buf), IsNil)
+	c.Assert(buf.String(), Equals, "name,size\nfile10,\"1,024\"\nfile3,100.5\nfile2,5\nfile1,-\n")
+
+	buf.Reset()
+
+	t.Add("file10", "1,024").Add("file2", 5).Add("{g}file1{!}", "-").Add("file3", 100.5)
+	t.SortBy(0, SORT_NATURAL, true)
+
+	c.Assert(t.RenderTo(buf), IsNil)
+	c.Assert(buf.String(), Equals, "name,size\nfile10,\"1,024\"\nfile3,100.5\nfile2,5\nfile1,-\n")
+}
+
+func (s *TableSuite) TestGroupingAndFooter(c *C) {
+	buf := &bytes.Buffer{}
+
+	t := NewTable("group", "name", "size").SetFormat(FORMAT_MARKDOWN)
+	t.SortBy(1, SORT_NATURAL, false).GroupBy(0)
+	t.SetFooter(AGGREGATE_NONE, AGGREGATE_COUNT, AGGREGATE_SUM)
+	t.Add("b", "file3", 3).Add("a", "file2", 2).Add("b", "file1", 1.5).Add("a", "file4", "")
+
+	c.Assert(t.RenderTo(buf), IsNil)
+	c.Assert(buf.String(), Equals, "| group | name | size |\n|---|---|---|\n"+
+		"| **b** |  |  |\n| b | file1 | 1.5 |\n| b | file3 | 3 |\n"+
+		"| **a** |  |  |\n| a | file2 | 2 |\n| a | file4 |  |\n"+
+		"|  | 4 | 6.5 |\n")
+
+	buf.Reset()
+
+	t.SetFormat(FORMAT_PLAIN)
+	t.Add("b", "file3", 3).Add("a", "file2", 2).Add("b", "file1", 1.5).Add("a", "file4", "")
+
+	c.Assert(t.RenderTo(buf), IsNil)
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+
+	c.Assert(lines, HasLen, 13)
+	c.Assert(lines[3], Matches, " b +")
+	c.Assert(lines[7], Matches, " a +")
+	c.Assert(lines[11], Matches, " +\\| 4 +\\| 6.5 +")
+
+	t = NewTable("name", "size")
+	t.SetFooter(AGGREGATE_COUNT, AGGREGATE_AVG, AGGREGATE_MIN, AGGREGATE_MAX, AGGREGATE_SUM)
+	t.Add("a", 1, 1, 1, "x").Add("b", 2, 2, 2, "y").Add("c", 6, 3, 3)
+
+	prepareData(t)
+
+	c.Assert(t.footerData, DeepEquals, []string{"3", "3", "1", "3", ""})
+}
+
+func (s *TableSuite) TestStyles(c *C) {
+	var t *Table
+
+	c.Assert(t.SetStyle(StyleLight), IsNil)
+
+	buf := &bytes.Buffer{}
+
+	styles := []*Style{nil, StyleASCII, StyleLight, StyleHeavy, StyleDouble, StyleBorderless, StyleCompact}
+
+	for _, style := range styles {
+		buf.Reset()
+
+		t = NewTable("id", "name", "desc").SetFormat(FORMAT_PLAIN).SetStyle(style)
+		t.SetFooter(AGGREGATE_COUNT).GroupBy(2)
+		t.Add(1, "日本語テキスト", "ＡＢＣ").Add(2, "Ünïcödé", "한국어").Add(3, "abc", strings.Repeat("漢字", 50))
+
+		c.Assert(t.RenderTo(buf), IsNil)
+
+		for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+			c.Assert(strutil.Width(line), Equals, 88, Commentf("Line: %q", line))
+		}
+	}
+
+	buf.Reset()
+
+	t = NewTable("id", "name").SetFormat(FORMAT_PLAIN).SetStyle(StyleLight)
+	t.Add(1, "abc")
+
+	c.Assert(t.RenderTo(buf), IsNil)
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+
+	c.Assert(lines, HasLen, 5)
+	c.Assert(lines[0], Matches, "┌────┬─+┐")
+	c.Assert(lines[1], Matches, "│ id │ name +│")
+	c.Assert(lines[2], Matches, "├────┼─+┤")
+	c.Assert(lines[3], Matches, "│ 1  │ abc +│")
+	c.Assert(lines[4], Matches, "└────┴─+┘")
+
+	buf.Reset()
+
+	t = NewTable("id", "name").SetFormat(FORMAT_PLAIN).SetStyle(StyleCompact)
+	t.Add(1, "abc")
+
+	c.Assert(t.RenderTo(buf), IsNil)
+
+	lines = strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+
+	c.Assert(lines, HasLen, 3)
+	c.Assert(lines[1], Matches, "---- -+")
+}
+
+func (s *TableSuite) TestTextWidth(c *C) {
+	c.Assert(wrapText("日本語テキスト", 6), DeepEquals, []string{"日本語", "テキス", "ト"})
+	c.Assert(repeatToWidth("─", 3), Equals, "───")
+	c.Assert(repeatToWidth("", 3), Equals, "")
+}
+
 func (s *TableSuite) TestAuxi(c *C) {
 	t := &Table{Sizes: []int{1, 2, 3, 4}}
 
@@ -0,0 +1,311 @@
+package log
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"errors"
+	"sync"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// OVERFLOW_BLOCK block caller until queue has free space
+// OVERFLOW_DROP_NEWEST drop new message if queue is full
+// OVERFLOW_DROP_OLDEST drop oldest message in queue if queue is full
+const (
+	OVERFLOW_BLOCK       = 0
+	OVERFLOW_DROP_NEWEST = 1
+	OVERFLOW_DROP_OLDEST = 2
+)
+
+// DEFAULT_QUEUE_SIZE is default size of async mode queue
+const DEFAULT_QUEUE_SIZE = 1024
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// AsyncConfig contains async mode settings
+type AsyncConfig struct {
+	QueueSize int // Maximum number of messages in queue (DEFAULT_QUEUE_SIZE by default)
+	Overflow  int // Policy for full queue (OVERFLOW_BLOCK by default)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type asyncQueue struct {
+	records  []*Record
+	size     int
+	overflow int
+	dropped  uint64
+	closed   bool
+	busy     bool
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	idle     *sync.Cond
+	done     chan struct{}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Errors
+var (
+	ErrAsyncEnabled    = errors.New("Async mode is already enabled")
+	ErrUnknownOverflow = errors.New("Unknown queue overflow policy")
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// EnableAsync enable async mode for global logger
+func EnableAsync(config AsyncConfig) error {
+	return Global.EnableAsync(config)
+}
+
+// Dropped return number of messages dropped by global logger
+func Dropped() uint64 {
+	return Global.Dropped()
+}
+
+// Close write all queued and buffered data and close global logger output
+func Close() error {
+	return Global.Close()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// EnableAsync enable async mode. In async mode messages are added to bounded
+// queue and written by background goroutine, so Print and other output methods
+// always return 0 and nil error.
+func (l *Logger) EnableAsync(config AsyncConfig) error {
+	if l == nil {
+		return ErrLoggerIsNil
+	}
+
+	switch config.Overflow {
+	case OVERFLOW_BLOCK, OVERFLOW_DROP_NEWEST, OVERFLOW_DROP_OLDEST:
+		// ok
+	default:
+		return ErrUnknownOverflow
+	}
+
+	if config.QueueSize <= 0 {
+		config.QueueSize = DEFAULT_QUEUE_SIZE
+	}
+
+	l = l.root()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.queue != nil && !l.queue.isClosed() {
+		return ErrAsyncEnabled
+	}
+
+	l.queue = newAsyncQueue(config)
+
+	go l.queue.run(l)
+
+	return nil
+}
+
+// Dropped return number of messages dropped since async mode was enabled
+func (l *Logger) Dropped() uint64 {
+	if l == nil {
+		return 0
+	}
+
+	l = l.root()
+
+	l.mu.Lock()
+	queue := l.queue
+	l.mu.Unlock()
+
+	if queue == nil {
+		return 0
+	}
+
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	return queue.dropped
+}
+
+// Close write all queued and buffered data, disable async mode and buffered I/O,
+// close output file and all sinks. After closing logger writes messages to
+// stdout and stderr.
+func (l *Logger) Close() error {
+	if l == nil {
+		return ErrLoggerIsNil
+	}
+
+	l = l.root()
+
+	l.mu.Lock()
+	queue := l.queue
+	l.mu.Unlock()
+
+	if queue != nil {
+		queue.close()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var result error
+
+	if l.flushStop != nil {
+		close(l.flushStop)
+		l.flushStop = nil
+	}
+
+	if l.w != nil {
+		result = l.w.Flush()
+	}
+
+	// Wait until rotated file will be compressed
+	l.compWG.Wait()
+
+	if l.fd != nil {
+		err := l.fd.Close()
+
+		if result == nil {
+			result = err
+		}
+	}
+
+	// Wait until sinks writing will be finished
+	l.sinkMu.Lock()
+
+	for _, si := range l.sinks {
+		err := si.sink.Close()
+
+		if result == nil {
+			result = err
+		}
+	}
+
+	l.sinkMu.Unlock()
+
+	l.fd, l.w, l.sinks, l.useBufIO = nil, nil, nil, false
+
+	return result
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// newAsyncQueue create new queue
+func newAsyncQueue(config AsyncConfig) *asyncQueue {
+	q := &asyncQueue{
+		size:     config.QueueSize,
+		overflow: config.Overflow,
+		done:     make(chan struct{}),
+	}
+
+	q.notEmpty = sync.NewCond(&q.mu)
+	q.notFull = sync.NewCond(&q.mu)
+	q.idle = sync.NewCond(&q.mu)
+
+	return q
+}
+
+// push add record to queue, returns false if queue is closed
+func (q *asyncQueue) push(r *Record) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.overflow == OVERFLOW_BLOCK {
+		for len(q.records) >= q.size && !q.closed {
+			q.notFull.Wait()
+		}
+	}
+
+	if q.closed {
+		return false
+	}
+
+	if len(q.records) >= q.size {
+		q.dropped++
+
+		if q.overflow == OVERFLOW_DROP_NEWEST {
+			return true
+		}
+
+		q.records[0] = nil
+		q.records = q.records[1:]
+	}
+
+	q.records = append(q.records, r)
+	q.notEmpty.Signal()
+
+	return true
+}
+
+// run write queued records to logger output until queue is closed
+func (q *asyncQueue) run(l *Logger) {
+	for {
+		q.mu.Lock()
+
+		for len(q.records) == 0 && !q.closed {
+			q.notEmpty.Wait()
+		}
+
+		if len(q.records) == 0 {
+			q.mu.Unlock()
+			close(q.done)
+			return
+		}
+
+		records := q.records
+		q.records, q.busy = nil, true
+		q.notFull.Broadcast()
+		q.mu.Unlock()
+
+		for _, r := range records {
+			l.writeRecord(r)
+		}
+
+		q.mu.Lock()
+		q.busy = false
+		q.idle.Broadcast()
+		q.mu.Unlock()
+	}
+}
+
+// wait wait until all queued records will be written
+func (q *asyncQueue) wait() {
+	q.mu.Lock()
+
+	for len(q.records) != 0 || q.busy {
+		q.idle.Wait()
+	}
+
+	q.mu.Unlock()
+}
+
+// close close queue and wait until all queued records will be written
+func (q *asyncQueue) close() {
+	q.mu.Lock()
+
+	if !q.closed {
+		q.closed = true
+		q.notEmpty.Broadcast()
+		q.notFull.Broadcast()
+	}
+
+	q.mu.Unlock()
+
+	<-q.done
+}
+
+// isClosed return true if queue is closed
+func (q *asyncQueue) isClosed() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.closed
+}
@@ -0,0 +1,127 @@
+package log
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Caller contains info about place where message was logged
+type Caller struct {
+	File string // Source file with parent directory (e.g. "app/main.go")
+	Line int    // Line number
+	Func string // Function name with package name (e.g. "main.run")
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// pkgDir is path to directory with logger sources
+var pkgDir string
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func init() {
+	_, file, _, ok := runtime.Caller(0)
+
+	if ok {
+		pkgDir = filepath.Dir(file)
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ShowCaller enable or disable caller info for global logger
+func ShowCaller(flag bool) {
+	Global.ShowCaller(flag)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ShowCaller enable or disable adding info about file, line and function where
+// message was logged
+func (l *Logger) ShowCaller(flag bool) {
+	if l == nil {
+		return
+	}
+
+	l = l.root()
+
+	l.mu.Lock()
+	l.caller = flag
+	l.mu.Unlock()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// String return caller info as "file:line"
+func (c *Caller) String() string {
+	if c == nil {
+		return ""
+	}
+
+	return c.File + ":" + strconv.Itoa(c.Line)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getCaller return info about first function outside of logger package
+func getCaller() *Caller {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+
+		if !isLoggerFrame(frame.File) {
+			return &Caller{
+				File: getShortFileName(frame.File),
+				Line: frame.Line,
+				Func: getShortFuncName(frame.Function),
+			}
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	return nil
+}
+
+// isLoggerFrame return true if given file is logger source file
+func isLoggerFrame(file string) bool {
+	return filepath.Dir(file) == pkgDir && !strings.HasSuffix(file, "_test.go")
+}
+
+// getShortFileName return file name with parent directory
+func getShortFileName(file string) string {
+	index := strings.LastIndex(file, "/")
+
+	if index == -1 {
+		return file
+	}
+
+	index = strings.LastIndex(file[:index], "/")
+
+	if index == -1 {
+		return file
+	}
+
+	return file[index+1:]
+}
+
+// getShortFuncName return function name without package path
+func getShortFuncName(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
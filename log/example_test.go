@@ -42,7 +42,21 @@ func Example_Logger() {
 	// any minimum level
 	logger.Aux("This is aux message")
 
-	// For log rotation we provide method Reopen
+	// You can write messages with key-value fields
+	logger.Log(INFO, "User logged in", F{"user", "bob"}, F{"id", 1234})
+
+	// Child loggers add context fields to every message
+	reqLogger := logger.With(F{"request", "8ba2c3f1"})
+	reqLogger.Info("Request processed")
+
+	// Fields can be written in logfmt or JSON format
+	logger.SetFormatter(&JSONFormatter{})
+
+	// Logger can rotate output file by itself, for example, when file size
+	// is greater than 10 MB, with keeping 5 compressed rotated files
+	logger.EnableRotation(RotationConfig{MaxSize: 10 * 1024 * 1024, Keep: 5, Compress: true})
+
+	// For log rotation with external tools (like logrotate) we provide method Reopen
 	logger.Reopen()
 
 	// If buffered IO is used, you should flush data before exit
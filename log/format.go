@@ -0,0 +1,253 @@
+package log
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// F is key-value field for structured logging
+type F struct {
+	Key   string
+	Value interface{}
+}
+
+// Record contains data of one log message
+type Record struct {
+	Time    time.Time // Time when message was created
+	Level   int       // Message level
+	Prefix  string    // Level prefix (empty if prefix must not be shown)
+	Logger  string    // Name of named logger
+	Message string    // Message text without trailing newline
+	Fields  []F       // Message fields
+	Caller  *Caller   // Caller info (nil if caller info is disabled)
+}
+
+// Formatter is interface for log records formatters
+type Formatter interface {
+	// Format return formatted record data with trailing newline
+	Format(r *Record) []byte
+}
+
+// TextFormatter is default formatter which writes records as
+// "[ time ] [PREFIX] [logger] (file:line func) message key=value"
+type TextFormatter struct{}
+
+// LogfmtFormatter is formatter which writes records in logfmt format
+type LogfmtFormatter struct{}
+
+// JSONFormatter is formatter which writes records as JSON objects
+type JSONFormatter struct{}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// RecordTimeFormat contains format string for time in logfmt and JSON records
+var RecordTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var levelNames = map[int]string{
+	DEBUG: "debug",
+	INFO:  "info",
+	WARN:  "warn",
+	ERROR: "error",
+	CRIT:  "crit",
+	AUX:   "aux",
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Format return record formatted as text
+func (f *TextFormatter) Format(r *Record) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString("[ " + r.Time.Format(TimeFormat) + " ] ")
+
+	if r.Prefix != "" {
+		buf.WriteString(r.Prefix + " ")
+	}
+
+	if r.Logger != "" {
+		buf.WriteString("[" + r.Logger + "] ")
+	}
+
+	if r.Caller != nil {
+		buf.WriteString("(" + r.Caller.String() + " " + r.Caller.Func + ") ")
+	}
+
+	buf.WriteString(r.Message)
+
+	for _, field := range r.Fields {
+		buf.WriteString(" ")
+		writeLogfmtField(&buf, field.Key, field.Value)
+	}
+
+	buf.WriteString("\n")
+
+	return buf.Bytes()
+}
+
+// Format return record formatted as logfmt line
+func (f *LogfmtFormatter) Format(r *Record) []byte {
+	var buf bytes.Buffer
+
+	writeLogfmtField(&buf, "time", r.Time.Format(RecordTimeFormat))
+	buf.WriteString(" ")
+	writeLogfmtField(&buf, "level", getLevelName(r.Level))
+	buf.WriteString(" ")
+
+	if r.Logger != "" {
+		writeLogfmtField(&buf, "logger", r.Logger)
+		buf.WriteString(" ")
+	}
+
+	if r.Caller != nil {
+		writeLogfmtField(&buf, "caller", r.Caller.String())
+		buf.WriteString(" ")
+		writeLogfmtField(&buf, "func", r.Caller.Func)
+		buf.WriteString(" ")
+	}
+
+	writeLogfmtField(&buf, "msg", r.Message)
+
+	for _, field := range r.Fields {
+		buf.WriteString(" ")
+		writeLogfmtField(&buf, getFieldKey(r, field.Key), field.Value)
+	}
+
+	buf.WriteString("\n")
+
+	return buf.Bytes()
+}
+
+// Format return record formatted as JSON object
+func (f *JSONFormatter) Format(r *Record) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString("{")
+	writeJSONField(&buf, "time", r.Time.Format(RecordTimeFormat))
+	buf.WriteString(",")
+	writeJSONField(&buf, "level", getLevelName(r.Level))
+	buf.WriteString(",")
+
+	if r.Logger != "" {
+		writeJSONField(&buf, "logger", r.Logger)
+		buf.WriteString(",")
+	}
+
+	if r.Caller != nil {
+		writeJSONField(&buf, "caller", r.Caller.String())
+		buf.WriteString(",")
+		writeJSONField(&buf, "func", r.Caller.Func)
+		buf.WriteString(",")
+	}
+
+	writeJSONField(&buf, "msg", r.Message)
+
+	for _, field := range r.Fields {
+		buf.WriteString(",")
+		writeJSONField(&buf, getFieldKey(r, field.Key), field.Value)
+	}
+
+	buf.WriteString("}\n")
+
+	return buf.Bytes()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getLevelName return name of level
+func getLevelName(level int) string {
+	name, ok := levelNames[level]
+
+	if !ok {
+		return strconv.Itoa(level)
+	}
+
+	return name
+}
+
+// getFieldKey return key for user field. Keys which collide with keys of record
+// data (time, level, msg, etc.) get "fields." prefix, so output doesn't contain
+// duplicate keys.
+func getFieldKey(r *Record, key string) string {
+	switch key {
+	case "time", "level", "msg":
+		return "fields." + key
+	case "logger":
+		if r.Logger != "" {
+			return "fields." + key
+		}
+	case "caller", "func":
+		if r.Caller != nil {
+			return "fields." + key
+		}
+	}
+
+	return key
+}
+
+// writeLogfmtField write key-value pair in logfmt format
+func writeLogfmtField(buf *bytes.Buffer, key string, value interface{}) {
+	buf.WriteString(quoteLogfmtValue(key))
+	buf.WriteString("=")
+	buf.WriteString(quoteLogfmtValue(formatFieldValue(value)))
+}
+
+// writeJSONField write key-value pair as JSON object member
+func writeJSONField(buf *bytes.Buffer, key string, value interface{}) {
+	keyData, _ := json.Marshal(key)
+
+	buf.Write(keyData)
+	buf.WriteString(":")
+
+	switch value.(type) {
+	case error, fmt.Stringer:
+		value = formatFieldValue(value)
+	}
+
+	valueData, err := json.Marshal(value)
+
+	if err != nil {
+		valueData, _ = json.Marshal(fmt.Sprint(value))
+	}
+
+	buf.Write(valueData)
+}
+
+// formatFieldValue convert field value to string
+func formatFieldValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	return fmt.Sprint(value)
+}
+
+// quoteLogfmtValue quote value if it contains spaces or special symbols
+func quoteLogfmtValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " =\"\t\r\n\\") {
+		return strconv.Quote(value)
+	}
+
+	return value
+}
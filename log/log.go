@@ -15,6 +15,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -37,7 +38,8 @@ const (
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
-// Logger is a basic logger struct
+// Logger is a basic logger struct. Logger is safe for concurrent use, but
+// prefix flags must be set before logger is used from several goroutines.
 type Logger struct {
 	PrefixDebug bool // Prefix for debug messages
 	PrefixInfo  bool // Prefix for info messages
@@ -45,12 +47,30 @@ type Logger struct {
 	PrefixError bool // Prefix for error messages
 	PrefixCrit  bool // Prefix for critical error messages
 
-	file     string
-	fd       *os.File
-	w        *bufio.Writer
-	level    int
-	perms    os.FileMode
-	useBufIO bool
+	file      string
+	fd        *os.File
+	w         *bufio.Writer
+	level     int
+	perms     os.FileMode
+	useBufIO  bool
+	formatter Formatter
+	caller    bool
+
+	rotation  *RotationConfig // rotation settings
+	size      int64           // current output file size
+	rotatedAt time.Time       // date of last rotation (or output opening)
+	compWG    sync.WaitGroup  // wait group for compression goroutines
+	flushStop chan struct{}   // channel for stopping flush daemon
+	sinks     []*sinkInfo     // additional outputs
+	queue     *asyncQueue     // queue for async mode
+	levels    map[string]int  // minimal levels of named loggers
+	sampler   *sampler        // messages sampler
+	mu        sync.Mutex      // mutex for output and settings
+	sinkMu    sync.Mutex      // mutex for writing to sinks
+
+	parent *Logger // parent logger for child loggers created by With and Named
+	fields []F     // context fields
+	name   string  // name of named logger
 }
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -78,6 +98,9 @@ var PrefixMap = map[int]string{
 // TimeFormat contains format string for time in logs
 var TimeFormat = "2006/01/02 15:04:05.000"
 
+// defaultFormatter is formatter used if logger formatter is not set
+var defaultFormatter = &TextFormatter{}
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 // Errors
@@ -131,6 +154,11 @@ func MinLevel(level interface{}) error {
 	return Global.MinLevel(level)
 }
 
+// MinLevelFor defines minimal logging level for named logger
+func MinLevelFor(name string, level interface{}) error {
+	return Global.MinLevelFor(name, level)
+}
+
 // Set change global logger output target
 func Set(file string, perms os.FileMode) error {
 	return Global.Set(file, perms)
@@ -146,11 +174,31 @@ func Flush() error {
 	return Global.Flush()
 }
 
+// SetFormatter set records formatter for global logger
+func SetFormatter(f Formatter) {
+	Global.SetFormatter(f)
+}
+
+// With create child logger of global logger with given context fields
+func With(fields ...F) *Logger {
+	return Global.With(fields...)
+}
+
+// Named create named child logger of global logger
+func Named(name string) *Logger {
+	return Global.Named(name)
+}
+
 // Print write message to global logger output
 func Print(level int, f string, a ...interface{}) (int, error) {
 	return Global.Print(level, f, a...)
 }
 
+// Log write message with fields to global logger output
+func Log(level int, msg string, fields ...F) (int, error) {
+	return Global.Log(level, msg, fields...)
+}
+
 // Debug write debug message to global logger output
 func Debug(f string, a ...interface{}) (int, error) {
 	return Global.Debug(f, a...)
@@ -190,80 +238,163 @@ func (l *Logger) Reopen() error {
 		return ErrLoggerIsNil
 	}
 
-	if l.fd == nil {
+	l = l.root()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.fd == nil && len(l.sinks) == 0 {
 		return ErrOutputNotSet
 	}
 
-	if l.w != nil {
-		l.w.Flush()
+	for _, si := range l.sinks {
+		if rs, ok := si.sink.(reopener); ok {
+			rs.Reopen()
+		}
 	}
 
-	l.fd.Close()
+	if l.fd == nil {
+		return nil
+	}
 
-	return l.Set(l.file, l.perms)
+	return l.set(l.file, l.perms)
 }
 
-// MinLevel defines minimal logging level
+// MinLevel defines minimal logging level (for named logger it's the same as
+// MinLevelFor with logger name)
 func (l *Logger) MinLevel(level interface{}) error {
 	if l == nil {
 		return ErrLoggerIsNil
 	}
 
+	if l.name != "" {
+		return l.root().MinLevelFor(l.name, level)
+	}
+
+	l = l.root()
+
 	levelCode, err := convertMinLevelValue(level)
 
 	if err != nil {
 		return err
 	}
 
-	switch {
-	case levelCode < DEBUG:
-		levelCode = DEBUG
-	case levelCode > CRIT:
-		levelCode = CRIT
+	l.mu.Lock()
+	l.level = normalizeLevel(levelCode)
+	l.mu.Unlock()
+
+	return nil
+}
+
+// MinLevelFor defines minimal logging level for named logger and all its
+// named children (e.g. level for "db" is also used by "db.pool")
+func (l *Logger) MinLevelFor(name string, level interface{}) error {
+	if l == nil {
+		return ErrLoggerIsNil
+	}
+
+	l = l.root()
+
+	if name == "" {
+		return l.MinLevel(level)
+	}
+
+	levelCode, err := convertMinLevelValue(level)
+
+	if err != nil {
+		return err
 	}
 
-	l.level = levelCode
+	l.mu.Lock()
+
+	if l.levels == nil {
+		l.levels = make(map[string]int)
+	}
+
+	l.levels[name] = normalizeLevel(levelCode)
+
+	l.mu.Unlock()
 
 	return nil
 }
 
 // EnableBufIO enable buffered I/O support
 func (l *Logger) EnableBufIO(interval time.Duration) {
+	if l == nil {
+		return
+	}
+
+	l = l.root()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
 	l.useBufIO = true
 
-	if l.fd != nil {
+	if l.fd != nil && l.w == nil {
 		l.w = bufio.NewWriter(l.fd)
 	}
 
-	go l.flushDaemon(interval)
+	// Stop previous flush daemon if buffered I/O is already enabled
+	if l.flushStop != nil {
+		close(l.flushStop)
+	}
+
+	l.flushStop = make(chan struct{})
+
+	go l.flushDaemon(interval, l.flushStop)
 }
 
 // Set change logger output target
 func (l *Logger) Set(file string, perms os.FileMode) error {
-	fd, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, perms)
-
-	if err != nil {
-		return err
+	if l == nil {
+		return ErrLoggerIsNil
 	}
 
-	// Flush data if writer exist
-	if l.w != nil {
-		l.w.Flush()
-		l.w = nil
+	l = l.root()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.set(file, perms)
+}
+
+// SetFormatter set records formatter (TextFormatter is used by default)
+func (l *Logger) SetFormatter(f Formatter) {
+	if l == nil {
+		return
 	}
 
-	if l.fd != nil {
-		l.fd.Close()
-		l.fd = nil
+	l = l.root()
+
+	l.mu.Lock()
+	l.formatter = f
+	l.mu.Unlock()
+}
+
+// With create child logger with given context fields, child logger uses
+// output, level and formatter of parent logger
+func (l *Logger) With(fields ...F) *Logger {
+	if l == nil {
+		return nil
 	}
 
-	l.fd, l.file, l.perms = fd, file, perms
+	return &Logger{parent: l.root(), fields: mergeFields(l.fields, fields), name: l.name}
+}
 
-	if l.useBufIO {
-		l.w = bufio.NewWriter(l.fd)
+// Named create named child logger, minimal level of named logger can be
+// changed by MinLevelFor. Name of child of named logger is joined with
+// parent name by dot (e.g. "db.pool").
+func (l *Logger) Named(name string) *Logger {
+	if l == nil {
+		return nil
 	}
 
-	return nil
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+
+	return &Logger{parent: l.root(), fields: l.fields, name: name}
 }
 
 // Print write message to logger output
@@ -272,60 +403,43 @@ func (l *Logger) Print(level int, f string, a ...interface{}) (int, error) {
 		return -1, ErrLoggerIsNil
 	}
 
-	if l.level > level {
+	if !l.isEnabled(level, f) {
 		return 0, nil
 	}
 
-	var w io.Writer
-
-	if l.fd == nil {
-		switch level {
-		case ERROR, CRIT:
-			w = os.Stderr
-		default:
-			w = os.Stdout
-		}
-	} else {
-		if l.w != nil {
-			w = l.w
-		} else {
-			w = l.fd
-		}
-	}
-
-	var showPrefixes bool
-
-	switch {
-	case level == DEBUG && l.PrefixDebug,
-		level == INFO && l.PrefixInfo,
-		level == WARN && l.PrefixWarn,
-		level == ERROR && l.PrefixError,
-		level == CRIT && l.PrefixCrit:
-		showPrefixes = true
-	}
+	return l.write(level, fmt.Sprintf(f, a...), nil)
+}
 
-	if f == "" || f[len(f)-1:] != "\n" {
-		f += "\n"
+// Log write message with fields to logger output
+func (l *Logger) Log(level int, msg string, fields ...F) (int, error) {
+	if l == nil {
+		return -1, ErrLoggerIsNil
 	}
 
-	if showPrefixes {
-		return fmt.Fprintf(w, "%s %s %s", getTime(), PrefixMap[level], fmt.Sprintf(f, a...))
+	if !l.isEnabled(level, msg) {
+		return 0, nil
 	}
 
-	return fmt.Fprintf(w, "%s %s", getTime(), fmt.Sprintf(f, a...))
+	return l.write(level, msg, fields)
 }
 
-// Flush write buffered data to file
+// Flush write queued (in async mode) and buffered data to file
 func (l *Logger) Flush() error {
 	if l == nil {
 		return ErrLoggerIsNil
 	}
 
-	if l.w == nil {
-		return nil
+	l = l.root()
+
+	l.mu.Lock()
+	queue := l.queue
+	l.mu.Unlock()
+
+	if queue != nil {
+		queue.wait()
 	}
 
-	return l.w.Flush()
+	return l.flushBuffer()
 }
 
 // Debug write debug message to logger output
@@ -384,16 +498,273 @@ func (l *Logger) Aux(f string, a ...interface{}) (int, error) {
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
-func (l *Logger) flushDaemon(interval time.Duration) {
-	for range time.NewTicker(interval).C {
-		l.Flush()
+// root return logger which holds output and settings
+func (l *Logger) root() *Logger {
+	if l.parent != nil {
+		return l.parent
+	}
+
+	return l
+}
+
+// isEnabled return true if message with given level and text (format string)
+// must be written
+func (l *Logger) isEnabled(level int, text string) bool {
+	root := l.root()
+
+	root.mu.Lock()
+	enabled := root.isOutputRequired(level, l.name) || root.hasSinksFor(level, l.name)
+	sampler := root.sampler
+	root.mu.Unlock()
+
+	if !enabled || sampler == nil {
+		return enabled
+	}
+
+	return sampler.allow(level, text)
+}
+
+// write create record for message and write it to logger output or add it
+// to async queue
+func (l *Logger) write(level int, msg string, fields []F) (int, error) {
+	root := l.root()
+
+	root.mu.Lock()
+	queue, showCaller := root.queue, root.caller
+	root.mu.Unlock()
+
+	record := &Record{
+		Time:    time.Now(),
+		Level:   level,
+		Logger:  l.name,
+		Message: strings.TrimSuffix(msg, "\n"),
+		Fields:  mergeFields(l.fields, fields),
+	}
+
+	if showCaller {
+		record.Caller = getCaller()
+	}
+
+	// If queue is closed, record will be written synchronously
+	if queue != nil && queue.push(record) {
+		return 0, nil
+	}
+
+	return root.writeRecord(record)
+}
+
+// writeRecord format record and write it to output and sinks. Sinks are
+// written without holding logger lock, so slow sinks don't block other
+// goroutines which use logger.
+func (l *Logger) writeRecord(record *Record) (int, error) {
+	l.mu.Lock()
+
+	useOutput := l.isOutputRequired(record.Level, record.Logger)
+	sinks := l.getSinksFor(record.Level, record.Logger)
+
+	if !useOutput && len(sinks) == 0 {
+		l.mu.Unlock()
+		return 0, nil
+	}
+
+	data := l.formatRecord(record)
+
+	var n int
+	var err error
+
+	if useOutput {
+		n, err = l.writeOutput(record.Level, data)
+	}
+
+	l.mu.Unlock()
+
+	sinksErr := l.writeToSinks(sinks, record, data)
+
+	if !useOutput {
+		return len(data), sinksErr
+	}
+
+	return n, err
+}
+
+// formatRecord set record prefix and return formatted record data (logger
+// must be locked)
+func (l *Logger) formatRecord(record *Record) []byte {
+	level := record.Level
+
+	switch {
+	case level == DEBUG && l.PrefixDebug,
+		level == INFO && l.PrefixInfo,
+		level == WARN && l.PrefixWarn,
+		level == ERROR && l.PrefixError,
+		level == CRIT && l.PrefixCrit:
+		record.Prefix = PrefixMap[level]
+	}
+
+	formatter := l.formatter
+
+	if formatter == nil {
+		formatter = defaultFormatter
+	}
+
+	return formatter.Format(record)
+}
+
+// writeOutput write formatted record data to output file (or stdout/stderr)
+// (logger must be locked)
+func (l *Logger) writeOutput(level int, data []byte) (int, error) {
+	if l.isRotationRequired(len(data)) {
+		// Rotation error is not critical, we will try to write data anyway
+		l.rotate()
+	}
+
+	var w io.Writer
+
+	if l.fd == nil {
+		switch level {
+		case ERROR, CRIT:
+			w = os.Stderr
+		default:
+			w = os.Stdout
+		}
+	} else {
+		if l.w != nil {
+			w = l.w
+		} else {
+			w = l.fd
+		}
+	}
+
+	n, err := w.Write(data)
+
+	l.size += int64(n)
+
+	return n, err
+}
+
+// isOutputRequired return true if message with given level from logger with
+// given name must be written to output file (or stdout/stderr) (logger must
+// be locked)
+func (l *Logger) isOutputRequired(level int, name string) bool {
+	return l.getMinLevel(name) <= level && (l.fd != nil || len(l.sinks) == 0)
+}
+
+// getMinLevel return minimal level for logger with given name (logger must
+// be locked)
+func (l *Logger) getMinLevel(name string) int {
+	if level, ok := l.getNameLevel(name); ok {
+		return level
+	}
+
+	return l.level
+}
+
+// getNameLevel return minimal level defined for logger with given name or
+// its parents (logger must be locked)
+func (l *Logger) getNameLevel(name string) (int, bool) {
+	for name != "" && len(l.levels) != 0 {
+		if level, ok := l.levels[name]; ok {
+			return level, true
+		}
+
+		index := strings.LastIndex(name, ".")
+
+		if index == -1 {
+			break
+		}
+
+		name = name[:index]
+	}
+
+	return 0, false
+}
+
+// flushBuffer write buffered data to file
+func (l *Logger) flushBuffer() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.w == nil {
+		return nil
+	}
+
+	return l.w.Flush()
+}
+
+// set open output file (logger must be locked)
+func (l *Logger) set(file string, perms os.FileMode) error {
+	fd, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, perms)
+
+	if err != nil {
+		return err
+	}
+
+	// Flush data if writer exist
+	if l.w != nil {
+		l.w.Flush()
+		l.w = nil
+	}
+
+	if l.fd != nil {
+		l.fd.Close()
+		l.fd = nil
+	}
+
+	l.fd, l.file, l.perms = fd, file, perms
+	l.size, l.rotatedAt = 0, time.Now()
+
+	if fi, err := fd.Stat(); err == nil {
+		l.size = fi.Size()
+	}
+
+	if l.useBufIO {
+		l.w = bufio.NewWriter(l.fd)
+	}
+
+	return nil
+}
+
+func (l *Logger) flushDaemon(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.flushBuffer()
+		case <-stop:
+			return
+		}
 	}
 }
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
-func getTime() string {
-	return "[ " + time.Now().Format(TimeFormat) + " ]"
+// mergeFields return new slice with fields from both slices
+func mergeFields(f1, f2 []F) []F {
+	switch {
+	case len(f2) == 0:
+		return f1
+	case len(f1) == 0:
+		return f2
+	}
+
+	result := make([]F, 0, len(f1)+len(f2))
+	result = append(result, f1...)
+
+	return append(result, f2...)
+}
+
+func normalizeLevel(level int) int {
+	switch {
+	case level < DEBUG:
+		return DEBUG
+	case level > CRIT:
+		return CRIT
+	}
+
+	return level
 }
 
 func convertMinLevelValue(level interface{}) (int, error) {
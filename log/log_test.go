@@ -8,9 +8,16 @@ package log
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
 	"io/ioutil"
+	"net"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -426,3 +433,706 @@ func (ls *LogSuite) TestBufIO(c *C) {
 
 	c.Assert(fsutil.GetSize(logfile), Not(Equals), fileSize)
 }
+
+func (ls *LogSuite) TestFields(c *C) {
+	logfile := ls.TempDir + "/file5.log"
+	l, err := New(logfile, 0644)
+
+	c.Assert(err, IsNil)
+
+	l.Log(INFO, "Test message", F{"user", "bob"}, F{"id", 10})
+	l.Log(DEBUG, "Skipped message", F{"user", "bob"})
+
+	cl := l.With(F{"request", "abc 123"})
+
+	cl.Info("Test info")
+	cl.Log(WARN, "Test warn", F{"code", 503})
+	cl.With(F{"user", "john"}).Error("Test error")
+
+	var nl *Logger
+
+	c.Assert(nl.With(F{"a", 1}), IsNil)
+
+	_, err = nl.Log(INFO, "Test")
+
+	c.Assert(err, Equals, ErrLoggerIsNil)
+
+	data, err := ioutil.ReadFile(logfile)
+
+	c.Assert(err, IsNil)
+
+	dataSlice := strings.Split(string(data), "\n")
+
+	c.Assert(dataSlice, HasLen, 5)
+
+	c.Assert(dataSlice[0][28:], Equals, "Test message user=bob id=10")
+	c.Assert(dataSlice[1][28:], Equals, "Test info request=\"abc 123\"")
+	c.Assert(dataSlice[2][28:], Equals, "[WARNING] Test warn request=\"abc 123\" code=503")
+	c.Assert(dataSlice[3][28:], Equals, "[ERROR] Test error request=\"abc 123\" user=john")
+}
+
+func (ls *LogSuite) TestFormatters(c *C) {
+	r := &Record{
+		Time:    time.Date(2017, 1, 2, 3, 4, 5, 6000000, time.UTC),
+		Level:   WARN,
+		Prefix:  "[WARNING]",
+		Message: "Test \"message\"",
+		Fields: []F{
+			{"user", "bob"},
+			{"empty", ""},
+			{"code", 503},
+			{"ok", true},
+			{"err", errors.New("some error")},
+			{"nil", nil},
+			{"list", []int{1, 2}},
+		},
+	}
+
+	c.Assert(string((&TextFormatter{}).Format(r)), Equals,
+		"[ 2017/01/02 03:04:05.006 ] [WARNING] Test \"message\" user=bob empty=\"\" code=503 ok=true err=\"some error\" nil=null list=\"[1 2]\"\n",
+	)
+
+	c.Assert(string((&LogfmtFormatter{}).Format(r)), Equals,
+		"time=2017-01-02T03:04:05.006Z level=warn msg=\"Test \\\"message\\\"\" user=bob empty=\"\" code=503 ok=true err=\"some error\" nil=null list=\"[1 2]\"\n",
+	)
+
+	c.Assert(string((&JSONFormatter{}).Format(r)), Equals,
+		`{"time":"2017-01-02T03:04:05.006Z","level":"warn","msg":"Test \"message\"","user":"bob","empty":"","code":503,"ok":true,"err":"some error","nil":null,"list":[1,2]}`+"\n",
+	)
+
+	r = &Record{Time: r.Time, Level: 50, Message: "Test", Fields: []F{{"func", func() {}}}}
+
+	c.Assert(string((&JSONFormatter{}).Format(r)), Matches, `\{"time":"2017-01-02T03:04:05.006Z","level":"50","msg":"Test","func":"0x[0-9a-f]+"\}`+"\n")
+
+	r = &Record{
+		Time:    r.Time,
+		Level:   INFO,
+		Logger:  "db",
+		Message: "Test",
+		Caller:  &Caller{File: "main.go", Line: 10, Func: "main.main"},
+		Fields:  []F{{"time", 1}, {"level", "high"}, {"msg", "abc"}, {"logger", "x"}, {"func", "f"}, {"user", "bob"}},
+	}
+
+	c.Assert(string((&LogfmtFormatter{}).Format(r)), Equals,
+		"time=2017-01-02T03:04:05.006Z level=info logger=db caller=main.go:10 func=main.main msg=Test fields.time=1 fields.level=high fields.msg=abc fields.logger=x fields.func=f user=bob\n",
+	)
+
+	c.Assert(string((&JSONFormatter{}).Format(r)), Equals,
+		`{"time":"2017-01-02T03:04:05.006Z","level":"info","logger":"db","caller":"main.go:10","func":"main.main","msg":"Test","fields.time":1,"fields.level":"high","fields.msg":"abc","fields.logger":"x","fields.func":"f","user":"bob"}`+"\n",
+	)
+
+	var obj map[string]interface{}
+
+	c.Assert(json.Unmarshal((&JSONFormatter{}).Format(r), &obj), IsNil)
+	c.Assert(obj, HasLen, 12)
+
+	logfile := ls.TempDir + "/file6.log"
+	err := Set(logfile, 0644)
+
+	c.Assert(err, IsNil)
+
+	SetFormatter(&JSONFormatter{})
+	Log(INFO, "Test info", F{"id", 1})
+	With(F{"user", "bob"}).Error("Test error\n")
+
+	data, err := ioutil.ReadFile(logfile)
+
+	c.Assert(err, IsNil)
+
+	dataSlice := strings.Split(string(data), "\n")
+
+	c.Assert(dataSlice, HasLen, 3)
+	c.Assert(dataSlice[0], Matches, `\{"time":"[^"]+","level":"info","msg":"Test info","id":1\}`)
+	c.Assert(dataSlice[1], Matches, `\{"time":"[^"]+","level":"error","msg":"Test error","user":"bob"\}`)
+}
+
+func (ls *LogSuite) TestRotation(c *C) {
+	logfile := ls.TempDir + "/rotation.log"
+	l, err := New(logfile, 0644)
+
+	c.Assert(err, IsNil)
+
+	c.Assert(l.EnableRotation(RotationConfig{}), Equals, ErrRotationDisabled)
+	c.Assert(l.EnableRotation(RotationConfig{MaxSize: 256, Keep: 3}), IsNil)
+
+	for i := 0; i < 40; i++ {
+		l.Info("Test message %d", i)
+	}
+
+	c.Assert(fsutil.IsExist(logfile+".1"), Equals, true)
+	c.Assert(fsutil.IsExist(logfile+".3"), Equals, true)
+	c.Assert(fsutil.IsExist(logfile+".4"), Equals, false)
+	c.Assert(fsutil.GetSize(logfile) <= 256, Equals, true)
+	c.Assert(fsutil.GetSize(logfile+".1") <= 256, Equals, true)
+
+	data, err := ioutil.ReadFile(logfile)
+
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasSuffix(string(data), "Test message 39\n"), Equals, true)
+
+	c.Assert(l.EnableRotation(RotationConfig{Interval: time.Hour, Compress: true}), IsNil)
+	c.Assert(l.Rotate(), IsNil)
+
+	l.Info("Test message")
+
+	l.rotatedAt = time.Now().Add(-2 * time.Hour)
+
+	l.Info("Test message")
+
+	l.compWG.Wait()
+
+	c.Assert(fsutil.IsExist(logfile+".1"), Equals, false)
+	c.Assert(fsutil.IsExist(logfile+".1.gz"), Equals, true)
+	c.Assert(fsutil.IsExist(logfile+".2.gz"), Equals, true)
+	c.Assert(fsutil.IsExist(logfile+".3"), Equals, true)
+	c.Assert(fsutil.IsExist(logfile+".5"), Equals, true)
+
+	fd, err := os.Open(logfile + ".1.gz")
+
+	c.Assert(err, IsNil)
+
+	gr, err := gzip.NewReader(fd)
+
+	c.Assert(err, IsNil)
+
+	data, err = ioutil.ReadAll(gr)
+
+	c.Assert(err, IsNil)
+	c.Assert(string(data)[28:], Equals, "Test message\n")
+
+	fd.Close()
+
+	var nl *Logger
+
+	c.Assert(nl.EnableRotation(RotationConfig{MaxSize: 1}), Equals, ErrLoggerIsNil)
+	c.Assert(nl.Rotate(), Equals, ErrLoggerIsNil)
+	c.Assert((&Logger{}).Rotate(), Equals, ErrOutputNotSet)
+	c.Assert(EnableRotation(RotationConfig{MaxSize: 1}), IsNil)
+	c.Assert(Rotate(), Equals, ErrOutputNotSet)
+}
+
+func (ls *LogSuite) TestConcurrentRotation(c *C) {
+	logfile := ls.TempDir + "/rotation-concurrent.log"
+	l, err := New(logfile, 0644)
+
+	c.Assert(err, IsNil)
+	c.Assert(l.EnableRotation(RotationConfig{MaxSize: 1024}), IsNil)
+
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func(n int) {
+			for j := 0; j < 100; j++ {
+				l.Info("Test message %d-%d", n, j)
+			}
+
+			wg.Done()
+		}(i)
+	}
+
+	wg.Wait()
+
+	var lines int
+
+	for index := 0; ; index++ {
+		file := logfile
+
+		if index != 0 {
+			file += "." + strconv.Itoa(index)
+		}
+
+		if !fsutil.IsExist(file) {
+			break
+		}
+
+		data, err := ioutil.ReadFile(file)
+
+		c.Assert(err, IsNil)
+
+		lines += strings.Count(string(data), "\n")
+	}
+
+	c.Assert(lines, Equals, 800)
+}
+
+func (ls *LogSuite) TestConcurrency(c *C) {
+	logfile := ls.TempDir + "/concurrency.log"
+	l, err := New(logfile, 0644)
+
+	c.Assert(err, IsNil)
+
+	l.EnableBufIO(time.Millisecond)
+
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func(n int) {
+			cl := l.With(F{"worker", n})
+
+			for j := 0; j < 200; j++ {
+				cl.Info("Test message %d", j)
+			}
+
+			wg.Done()
+		}(i)
+	}
+
+	wg.Add(1)
+
+	go func() {
+		for i := 0; i < 20; i++ {
+			l.Reopen()
+			l.Flush()
+			l.MinLevel(INFO)
+			l.SetFormatter(&TextFormatter{})
+			l.EnableBufIO(time.Millisecond)
+		}
+
+		wg.Done()
+	}()
+
+	wg.Wait()
+
+	c.Assert(l.Flush(), IsNil)
+
+	data, err := ioutil.ReadFile(logfile)
+
+	c.Assert(err, IsNil)
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+
+	c.Assert(lines, HasLen, 1600)
+
+	for _, line := range lines {
+		c.Assert(line, Matches, `\[ [0-9/:. ]+ \] Test message [0-9]+ worker=[0-9]`)
+	}
+}
+
+func (ls *LogSuite) TestSinks(c *C) {
+	l := &Logger{PrefixWarn: true, PrefixError: true}
+	rs := NewRingSink(3)
+
+	c.Assert(l.AddSink(nil, DEBUG), Equals, ErrSinkIsNil)
+	c.Assert(l.AddSink(rs, "abcd"), NotNil)
+	c.Assert(l.AddSink(rs, WARN), IsNil)
+
+	logfile := ls.TempDir + "/sink.log"
+	fs, err := NewFileSink(logfile, 0644)
+
+	c.Assert(err, IsNil)
+	c.Assert(l.AddSink(fs, DEBUG), IsNil)
+
+	l.Debug("Debug")
+	l.Info("Info")
+	l.Warn("Warn 1")
+	l.Error("Error 1")
+	l.Warn("Warn 2")
+	l.Error("Error 2")
+
+	records := rs.Records()
+
+	c.Assert(records, HasLen, 3)
+	c.Assert(records[0].Message, Equals, "Error 1")
+	c.Assert(records[1].Message, Equals, "Warn 2")
+	c.Assert(records[2].Message, Equals, "Error 2")
+
+	var buf bytes.Buffer
+
+	_, err = rs.WriteTo(&buf)
+
+	c.Assert(err, IsNil)
+	c.Assert(buf.String(), Matches, `(?s)\[ [0-9/:. ]+ \] \[ERROR\] Error 1\n.*\[WARNING\] Warn 2\n.*\[ERROR\] Error 2\n`)
+
+	c.Assert(l.Reopen(), IsNil)
+	c.Assert(fs.Close(), IsNil)
+	c.Assert(rs.Close(), IsNil)
+	c.Assert(rs.Records(), HasLen, 0)
+
+	data, err := ioutil.ReadFile(logfile)
+
+	c.Assert(err, IsNil)
+	c.Assert(strings.Count(string(data), "\n"), Equals, 6)
+
+	ws := NewWriterSink(&buf)
+
+	c.Assert(ws.Write(&Record{}, []byte("test")), IsNil)
+	c.Assert(ws.Close(), IsNil)
+	c.Assert(NewStderrSink().Close(), IsNil)
+
+	_, err = NewFileSink("/_unknown_/sink.log", 0644)
+
+	c.Assert(err, NotNil)
+}
+
+func (ls *LogSuite) TestSyslogSink(c *C) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+
+	c.Assert(err, IsNil)
+
+	defer conn.Close()
+
+	_, err = NewSyslogSink("tcp", "127.0.0.1:514", "test")
+
+	c.Assert(err, NotNil)
+
+	ss, err := NewSyslogSink("udp", conn.LocalAddr().String(), "test app")
+
+	c.Assert(err, IsNil)
+
+	ss.Hostname = "host"
+
+	l := &Logger{}
+	l.AddSink(ss, INFO)
+
+	l.Debug("Debug")
+	l.With(F{"id", 1}, F{"q", `a"b]`}).Error("Message")
+
+	buf := make([]byte, 1024)
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+
+	c.Assert(err, IsNil)
+	c.Assert(string(buf[:n]), Matches, `<11>1 [0-9T:.+\-Z]+ host test_app [0-9]+ - \[fields@32473 id="1" q="a\\"b\\]"\] Message`)
+
+	c.Assert(ss.Close(), IsNil)
+	c.Assert(ss.Close(), IsNil)
+	c.Assert(ss.Write(&Record{Level: ERROR}, nil), Equals, ErrSyslogNotConnected)
+}
+
+func (ls *LogSuite) TestSyslogSinkReconnect(c *C) {
+	delay := syslogReconnectDelay
+	syslogReconnectDelay = 10 * time.Millisecond
+
+	defer func() { syslogReconnectDelay = delay }()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+
+	c.Assert(err, IsNil)
+
+	defer conn.Close()
+
+	ss, err := NewSyslogSink("udp", conn.LocalAddr().String(), "test")
+
+	c.Assert(err, IsNil)
+
+	defer ss.Close()
+
+	// Simulate lost connection
+	ss.conn.Close()
+
+	r := &Record{Level: ERROR, Message: "Message"}
+
+	c.Assert(ss.Write(r, nil), NotNil)
+	c.Assert(ss.Write(r, nil), Equals, ErrSyslogNotConnected)
+
+	var connected bool
+
+	for i := 0; i < 100; i++ {
+		time.Sleep(10 * time.Millisecond)
+
+		ss.mu.Lock()
+		connected = ss.conn != nil
+		ss.mu.Unlock()
+
+		if connected {
+			break
+		}
+	}
+
+	c.Assert(connected, Equals, true)
+	c.Assert(ss.Write(r, nil), IsNil)
+
+	buf := make([]byte, 1024)
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+
+	c.Assert(err, IsNil)
+	c.Assert(string(buf[:n]), Matches, `<11>1 .* Message`)
+}
+
+func (ls *LogSuite) TestAsync(c *C) {
+	logfile := ls.TempDir + "/async.log"
+	l, err := New(logfile, 0644)
+
+	c.Assert(err, IsNil)
+
+	c.Assert(l.EnableAsync(AsyncConfig{Overflow: 10}), Equals, ErrUnknownOverflow)
+	c.Assert(l.EnableAsync(AsyncConfig{QueueSize: 16}), IsNil)
+	c.Assert(l.EnableAsync(AsyncConfig{}), Equals, ErrAsyncEnabled)
+
+	l.EnableBufIO(time.Hour)
+
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			for j := 0; j < 100; j++ {
+				l.Info("Test message %d", j)
+			}
+
+			wg.Done()
+		}()
+	}
+
+	wg.Wait()
+
+	n, err := l.Info("Test message")
+
+	c.Assert(n, Equals, 0)
+	c.Assert(err, IsNil)
+	c.Assert(l.Flush(), IsNil)
+	c.Assert(l.Dropped(), Equals, uint64(0))
+
+	data, err := ioutil.ReadFile(logfile)
+
+	c.Assert(err, IsNil)
+	c.Assert(strings.Count(string(data), "\n"), Equals, 401)
+
+	l.Info("Last message")
+
+	c.Assert(l.Close(), IsNil)
+
+	data, err = ioutil.ReadFile(logfile)
+
+	c.Assert(err, IsNil)
+	c.Assert(strings.Count(string(data), "\n"), Equals, 402)
+	c.Assert(strings.HasSuffix(string(data), "Last message\n"), Equals, true)
+
+	var nilLogger *Logger
+
+	c.Assert(nilLogger.EnableAsync(AsyncConfig{}), Equals, ErrLoggerIsNil)
+	c.Assert(nilLogger.Close(), Equals, ErrLoggerIsNil)
+	c.Assert(nilLogger.Dropped(), Equals, uint64(0))
+}
+
+func (ls *LogSuite) TestAsyncOverflow(c *C) {
+	for _, overflow := range []int{OVERFLOW_DROP_NEWEST, OVERFLOW_DROP_OLDEST} {
+		l := &Logger{}
+		ws := &blockingSink{
+			started: make(chan string, 10),
+			release: make(chan struct{}),
+		}
+
+		l.AddSink(ws, DEBUG)
+
+		c.Assert(l.Dropped(), Equals, uint64(0))
+		c.Assert(l.EnableAsync(AsyncConfig{QueueSize: 2, Overflow: overflow}), IsNil)
+
+		l.Info("1")
+
+		// Wait until worker takes first message and blocks on it
+		c.Assert(<-ws.started, Equals, "1")
+
+		for _, msg := range []string{"2", "3", "4", "5"} {
+			l.Info(msg)
+		}
+
+		c.Assert(l.Dropped(), Equals, uint64(2))
+
+		close(ws.release)
+
+		c.Assert(l.Flush(), IsNil)
+		c.Assert(l.Dropped(), Equals, uint64(2))
+
+		switch overflow {
+		case OVERFLOW_DROP_NEWEST:
+			c.Assert(ws.messages, DeepEquals, []string{"1", "2", "3"})
+		case OVERFLOW_DROP_OLDEST:
+			c.Assert(ws.messages, DeepEquals, []string{"1", "4", "5"})
+		}
+
+		c.Assert(l.Close(), IsNil)
+		c.Assert(ws.closed, Equals, true)
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type blockingSink struct {
+	started  chan string
+	release  chan struct{}
+	messages []string
+	closed   bool
+}
+
+func (s *blockingSink) Write(r *Record, data []byte) error {
+	s.started <- r.Message
+	<-s.release
+	s.messages = append(s.messages, r.Message)
+	return nil
+}
+
+func (s *blockingSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func (ls *LogSuite) TestNamed(c *C) {
+	logfile := ls.TempDir + "/named.log"
+	l, err := New(logfile, 0644)
+
+	c.Assert(err, IsNil)
+
+	db := l.Named("db")
+	pool := db.Named("pool").With(F{"id", 1})
+	http := l.Named("http")
+
+	c.Assert(l.MinLevelFor("db", "debug"), IsNil)
+	c.Assert(l.MinLevelFor("db", "abcd"), NotNil)
+	c.Assert(MinLevelFor("", "info"), IsNil)
+
+	l.Debug("Root debug")
+	db.Debug("DB debug")
+	pool.Debug("Pool debug")
+	http.Debug("HTTP debug")
+	http.Info("HTTP info")
+
+	c.Assert(l.MinLevelFor("db.pool", "error"), IsNil)
+
+	pool.Warn("Pool warning")
+
+	data, err := ioutil.ReadFile(logfile)
+
+	c.Assert(err, IsNil)
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+
+	c.Assert(lines, HasLen, 3)
+	c.Assert(lines[0], Matches, `\[ [0-9/:. ]+ \] \[db\] DB debug`)
+	c.Assert(lines[1], Matches, `\[ [0-9/:. ]+ \] \[db.pool\] Pool debug id=1`)
+	c.Assert(lines[2], Matches, `\[ [0-9/:. ]+ \] \[http\] HTTP info`)
+
+	var nilLogger *Logger
+
+	c.Assert(nilLogger.Named("test"), IsNil)
+	c.Assert(nilLogger.MinLevelFor("test", DEBUG), Equals, ErrLoggerIsNil)
+}
+
+func (ls *LogSuite) TestNamedMinLevel(c *C) {
+	l := &Logger{}
+	rs := NewRingSink(10)
+
+	c.Assert(l.AddSink(rs, DEBUG), IsNil)
+
+	db := l.Named("db")
+	http := l.Named("http")
+
+	c.Assert(db.MinLevel(ERROR), IsNil)
+	c.Assert(db.With(F{"id", 1}).MinLevel("abcd"), NotNil)
+	c.Assert(db.MinLevelFor("", INFO), IsNil)
+
+	c.Assert(l.level, Equals, INFO)
+	c.Assert(l.levels, DeepEquals, map[string]int{"db": ERROR})
+
+	l.Debug("Root debug")
+	l.Info("Root info")
+	db.Warn("DB warning")
+	db.Error("DB error")
+	db.Named("pool").Info("Pool info")
+	http.Debug("HTTP debug")
+
+	records := rs.Records()
+
+	c.Assert(records, HasLen, 4)
+	c.Assert(records[0].Message, Equals, "Root debug")
+	c.Assert(records[1].Message, Equals, "Root info")
+	c.Assert(records[2].Message, Equals, "DB error")
+	c.Assert(records[3].Message, Equals, "HTTP debug")
+}
+
+func (ls *LogSuite) TestCaller(c *C) {
+	logfile := ls.TempDir + "/caller.log"
+	l, err := New(logfile, 0644)
+
+	c.Assert(err, IsNil)
+
+	l.ShowCaller(true)
+	l.Info("Test 1")
+	l.With(F{"id", 1}).Log(INFO, "Test 2")
+	l.SetFormatter(&JSONFormatter{})
+	l.Named("db").Info("Test 3")
+	l.ShowCaller(false)
+	l.Info("Test 4")
+
+	data, err := ioutil.ReadFile(logfile)
+
+	c.Assert(err, IsNil)
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+
+	c.Assert(lines, HasLen, 4)
+	c.Assert(lines[0], Matches, `\[ [0-9/:. ]+ \] \(log/log_test.go:[0-9]+ log.\(\*LogSuite\).TestCaller\) Test 1`)
+	c.Assert(lines[1], Matches, `\[ [0-9/:. ]+ \] \(log/log_test.go:[0-9]+ log.\(\*LogSuite\).TestCaller\) Test 2 id=1`)
+	c.Assert(lines[2], Matches, `\{"time":"[^"]+","level":"info","logger":"db","caller":"log/log_test.go:[0-9]+","func":"log.\(\*LogSuite\).TestCaller","msg":"Test 3"\}`)
+	c.Assert(lines[3], Matches, `\{"time":"[^"]+","level":"info","msg":"Test 4"\}`)
+
+	var caller *Caller
+
+	c.Assert(caller.String(), Equals, "")
+	c.Assert(getShortFileName("main.go"), Equals, "main.go")
+	c.Assert(getShortFileName("/main.go"), Equals, "/main.go")
+	c.Assert(getShortFuncName("main.main"), Equals, "main.main")
+}
+
+func (ls *LogSuite) TestSampling(c *C) {
+	l := &Logger{}
+	rs := NewRingSink(100)
+
+	l.AddSink(rs, DEBUG)
+
+	c.Assert(l.EnableSampling(SamplingConfig{}), Equals, ErrInvalidSampling)
+	c.Assert(l.EnableSampling(SamplingConfig{First: 2, Thereafter: 3}), IsNil)
+
+	for i := 0; i < 10; i++ {
+		l.Debug("Debug %d", i)
+		l.Info("Info %d", i)
+	}
+
+	var debug, info int
+
+	for _, r := range rs.Records() {
+		switch r.Level {
+		case DEBUG:
+			debug++
+		case INFO:
+			info++
+		}
+	}
+
+	// 1, 2, 5, 8
+	c.Assert(debug, Equals, 4)
+	c.Assert(info, Equals, 10)
+
+	c.Assert(l.EnableSampling(SamplingConfig{First: 1, Interval: time.Millisecond}), IsNil)
+
+	rs.Close()
+
+	l.Log(DEBUG, "Test")
+	l.Log(DEBUG, "Test")
+	time.Sleep(5 * time.Millisecond)
+	l.Log(DEBUG, "Test")
+
+	c.Assert(rs.Records(), HasLen, 2)
+
+	l.DisableSampling()
+
+	l.Log(DEBUG, "Test")
+	l.Log(DEBUG, "Test")
+
+	c.Assert(rs.Records(), HasLen, 4)
+
+	var nilLogger *Logger
+
+	c.Assert(nilLogger.EnableSampling(SamplingConfig{First: 1}), Equals, ErrLoggerIsNil)
+
+	nilLogger.DisableSampling()
+	nilLogger.ShowCaller(true)
+}
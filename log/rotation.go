@@ -0,0 +1,239 @@
+package log
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// RotationConfig contains log rotation settings
+type RotationConfig struct {
+	MaxSize  int64         // Maximum size of log file in bytes (0 - disabled)
+	Interval time.Duration // Rotation interval (0 - disabled)
+	Keep     int           // Number of rotated files to keep (0 - keep all)
+	Compress bool          // Compress rotated files with gzip
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ErrRotationDisabled is returned if rotation config does not contain any
+// rotation condition
+var ErrRotationDisabled = errors.New("Rotation config must contain max size or interval")
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// EnableRotation enable built-in log rotation for global logger
+func EnableRotation(config RotationConfig) error {
+	return Global.EnableRotation(config)
+}
+
+// Rotate rotate global logger output file
+func Rotate() error {
+	return Global.Rotate()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// EnableRotation enable built-in log rotation. Rotated files are renamed to
+// file.1, file.2, etc. (file.1.gz, file.2.gz if compression is enabled), where
+// file.1 is the newest one.
+func (l *Logger) EnableRotation(config RotationConfig) error {
+	if l == nil {
+		return ErrLoggerIsNil
+	}
+
+	if config.MaxSize <= 0 && config.Interval <= 0 {
+		return ErrRotationDisabled
+	}
+
+	l = l.root()
+
+	l.mu.Lock()
+	l.rotation = &config
+	l.mu.Unlock()
+
+	return nil
+}
+
+// Rotate rotate output file immediately
+func (l *Logger) Rotate() error {
+	if l == nil {
+		return ErrLoggerIsNil
+	}
+
+	l = l.root()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.fd == nil {
+		return ErrOutputNotSet
+	}
+
+	return l.rotate()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// isRotationRequired return true if output file must be rotated before
+// writing data with given size
+func (l *Logger) isRotationRequired(dataSize int) bool {
+	if l.rotation == nil || l.fd == nil {
+		return false
+	}
+
+	switch {
+	case l.rotation.MaxSize > 0 && l.size > 0 && l.size+int64(dataSize) > l.rotation.MaxSize:
+		return true
+	case l.rotation.Interval > 0 && time.Since(l.rotatedAt) >= l.rotation.Interval:
+		return true
+	}
+
+	return false
+}
+
+// rotate close current output file, shift rotated files and open new
+// output file (logger must be locked)
+func (l *Logger) rotate() error {
+	// Wait until previous rotated file will be compressed
+	l.compWG.Wait()
+
+	if l.w != nil {
+		l.w.Flush()
+	}
+
+	l.fd.Close()
+
+	var compress bool
+	var keep int
+
+	if l.rotation != nil {
+		compress, keep = l.rotation.Compress, l.rotation.Keep
+	}
+
+	shiftRotatedFiles(l.file, keep)
+
+	rotatedFile := l.file + ".1"
+	renameErr := os.Rename(l.file, rotatedFile)
+
+	fd, err := os.OpenFile(l.file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, l.perms)
+
+	if err != nil {
+		l.fd, l.w = nil, nil
+		return err
+	}
+
+	l.fd, l.size, l.rotatedAt = fd, 0, time.Now()
+
+	if l.w != nil {
+		l.w.Reset(l.fd)
+	}
+
+	if renameErr != nil {
+		return renameErr
+	}
+
+	if compress {
+		l.compWG.Add(1)
+
+		go func(perms os.FileMode) {
+			compressFile(rotatedFile, perms)
+			l.compWG.Done()
+		}(l.perms)
+	}
+
+	return nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// shiftRotatedFiles rename file.N to file.N+1 and remove files which
+// exceed the limit
+func shiftRotatedFiles(file string, keep int) {
+	var last int
+
+	for last = 1; ; last++ {
+		if getRotatedFile(file, last) == "" {
+			break
+		}
+	}
+
+	for index := last - 1; index >= 1; index-- {
+		rotatedFile := getRotatedFile(file, index)
+
+		if keep > 0 && index >= keep {
+			os.Remove(rotatedFile)
+			continue
+		}
+
+		newFile := file + "." + strconv.Itoa(index+1)
+
+		if rotatedFile != file+"."+strconv.Itoa(index) {
+			newFile += ".gz"
+		}
+
+		os.Rename(rotatedFile, newFile)
+	}
+}
+
+// getRotatedFile return path to rotated file with given index or empty string
+// if file doesn't exist
+func getRotatedFile(file string, index int) string {
+	rotatedFile := file + "." + strconv.Itoa(index)
+
+	if _, err := os.Stat(rotatedFile); err == nil {
+		return rotatedFile
+	}
+
+	if _, err := os.Stat(rotatedFile + ".gz"); err == nil {
+		return rotatedFile + ".gz"
+	}
+
+	return ""
+}
+
+// compressFile compress file with gzip and remove original file
+func compressFile(file string, perms os.FileMode) error {
+	src, err := os.Open(file)
+
+	if err != nil {
+		return err
+	}
+
+	defer src.Close()
+
+	dst, err := os.OpenFile(file+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perms)
+
+	if err != nil {
+		return err
+	}
+
+	gw := gzip.NewWriter(dst)
+
+	_, err = io.Copy(gw, src)
+
+	if err == nil {
+		err = gw.Close()
+	}
+
+	dst.Close()
+
+	if err != nil {
+		os.Remove(file + ".gz")
+		return err
+	}
+
+	return os.Remove(file)
+}
@@ -0,0 +1,129 @@
+package log
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// SamplingConfig contains sampling settings. Messages with the same level and
+// text (format string) are counted in every interval, first messages are
+// written and after that only every Nth message is written.
+type SamplingConfig struct {
+	Interval   time.Duration // Counting interval (1 second by default)
+	First      int           // Number of messages written in every interval
+	Thereafter int           // Write every Nth message after first messages (0 - drop all)
+	MaxLevel   int           // Maximum level of sampled messages (DEBUG by default)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type sampler struct {
+	config   SamplingConfig
+	counters map[sampleKey]int
+	resetAt  time.Time
+	mu       sync.Mutex
+}
+
+type sampleKey struct {
+	level int
+	text  string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ErrInvalidSampling is returned if sampling config doesn't allow any message
+var ErrInvalidSampling = errors.New("Sampling config must contain first or thereafter value")
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// EnableSampling enable sampling for global logger
+func EnableSampling(config SamplingConfig) error {
+	return Global.EnableSampling(config)
+}
+
+// DisableSampling disable sampling for global logger
+func DisableSampling() {
+	Global.DisableSampling()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// EnableSampling enable rate-limited sampling of messages
+func (l *Logger) EnableSampling(config SamplingConfig) error {
+	if l == nil {
+		return ErrLoggerIsNil
+	}
+
+	if config.First <= 0 && config.Thereafter <= 0 {
+		return ErrInvalidSampling
+	}
+
+	if config.Interval <= 0 {
+		config.Interval = time.Second
+	}
+
+	l = l.root()
+
+	l.mu.Lock()
+	l.sampler = &sampler{config: config}
+	l.mu.Unlock()
+
+	return nil
+}
+
+// DisableSampling disable sampling
+func (l *Logger) DisableSampling() {
+	if l == nil {
+		return
+	}
+
+	l = l.root()
+
+	l.mu.Lock()
+	l.sampler = nil
+	l.mu.Unlock()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// allow return true if message with given level and text must be written
+func (s *sampler) allow(level int, text string) bool {
+	if level > s.config.MaxLevel {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	if s.counters == nil || now.After(s.resetAt) {
+		s.counters = make(map[sampleKey]int)
+		s.resetAt = now.Add(s.config.Interval)
+	}
+
+	key := sampleKey{level, text}
+
+	s.counters[key]++
+
+	count := s.counters[key]
+
+	switch {
+	case count <= s.config.First:
+		return true
+	case s.config.Thereafter <= 0:
+		return false
+	}
+
+	return (count-s.config.First)%s.config.Thereafter == 0
+}
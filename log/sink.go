@@ -0,0 +1,630 @@
+package log
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Syslog facilities
+const (
+	FACILITY_KERN   = 0
+	FACILITY_USER   = 1
+	FACILITY_DAEMON = 3
+	FACILITY_LOCAL0 = 16
+	FACILITY_LOCAL1 = 17
+	FACILITY_LOCAL2 = 18
+	FACILITY_LOCAL3 = 19
+	FACILITY_LOCAL4 = 20
+	FACILITY_LOCAL5 = 21
+	FACILITY_LOCAL6 = 22
+	FACILITY_LOCAL7 = 23
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Sink is additional logger output
+type Sink interface {
+	// Write write record to sink, data contains record formatted by logger
+	// formatter
+	Write(r *Record, data []byte) error
+
+	// Close close sink
+	Close() error
+}
+
+// WriterSink is sink which writes formatted records to writer
+type WriterSink struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+// FileSink is sink which writes formatted records to file
+type FileSink struct {
+	file  string
+	perms os.FileMode
+	fd    *os.File
+	mu    sync.Mutex
+}
+
+// SyslogSink is sink which sends records to syslog daemon (or journald) in
+// RFC 5424 format
+type SyslogSink struct {
+	Facility int    // Syslog facility (FACILITY_USER by default)
+	App      string // Application name
+	Hostname string // Hostname (current hostname by default)
+
+	network      string
+	addr         string
+	conn         net.Conn
+	reconnecting bool
+	done         chan struct{}
+	mu           sync.Mutex
+}
+
+// RingSink is sink which keeps last records in memory
+type RingSink struct {
+	records []ringRecord
+	next    int
+	full    bool
+	mu      sync.Mutex
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type sinkInfo struct {
+	sink  Sink
+	level int
+}
+
+type ringRecord struct {
+	record Record
+	data   []byte
+}
+
+type reopener interface {
+	Reopen() error
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ErrSinkIsNil is returned if given sink is nil
+var ErrSinkIsNil = errors.New("Sink is nil")
+
+// ErrSyslogNotConnected is returned if syslog sink lost connection to syslog
+// daemon and record was dropped
+var ErrSyslogNotConnected = errors.New("Sink is not connected to syslog, record was dropped")
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// syslogReconnectDelay is initial delay between reconnection attempts
+var syslogReconnectDelay = time.Second
+
+// syslogMaxReconnectDelay is maximum delay between reconnection attempts
+var syslogMaxReconnectDelay = time.Minute
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// syslogSeverity contains syslog severity codes for log levels
+var syslogSeverity = map[int]int{
+	DEBUG: 7,
+	INFO:  6,
+	WARN:  4,
+	ERROR: 3,
+	CRIT:  2,
+	AUX:   5,
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// AddSink add sink to global logger
+func AddSink(sink Sink, minLevel interface{}) error {
+	return Global.AddSink(sink, minLevel)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// AddSink add sink with given minimal level. If logger output file is not set,
+// messages will be written only to sinks.
+func (l *Logger) AddSink(sink Sink, minLevel interface{}) error {
+	if l == nil {
+		return ErrLoggerIsNil
+	}
+
+	if sink == nil {
+		return ErrSinkIsNil
+	}
+
+	level, err := convertMinLevelValue(minLevel)
+
+	if err != nil {
+		return err
+	}
+
+	l = l.root()
+
+	l.mu.Lock()
+	l.sinks = append(l.sinks, &sinkInfo{sink, level})
+	l.mu.Unlock()
+
+	return nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// NewWriterSink create new sink for given writer
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// NewStderrSink create new sink for stderr
+func NewStderrSink() *WriterSink {
+	return NewWriterSink(os.Stderr)
+}
+
+// Write write formatted record to writer
+func (s *WriterSink) Write(r *Record, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.w.Write(data)
+
+	return err
+}
+
+// Close close writer if it implements io.Closer (stdout and stderr are
+// never closed)
+func (s *WriterSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.w == os.Stdout || s.w == os.Stderr {
+		return nil
+	}
+
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// NewFileSink create new sink for given file
+func NewFileSink(file string, perms os.FileMode) (*FileSink, error) {
+	s := &FileSink{file: file, perms: perms}
+
+	err := s.Reopen()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Write write formatted record to file
+func (s *FileSink) Write(r *Record, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.fd == nil {
+		return ErrOutputNotSet
+	}
+
+	_, err := s.fd.Write(data)
+
+	return err
+}
+
+// Reopen close file and open it again
+func (s *FileSink) Reopen() error {
+	fd, err := os.OpenFile(s.file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, s.perms)
+
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+
+	if s.fd != nil {
+		s.fd.Close()
+	}
+
+	s.fd = fd
+
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Close close file
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.fd == nil {
+		return nil
+	}
+
+	err := s.fd.Close()
+	s.fd = nil
+
+	return err
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// NewSyslogSink create new syslog sink, network must be "unixgram", "unix" or
+// "udp" (e.g. NewSyslogSink("unixgram", "/dev/log", "myapp"))
+func NewSyslogSink(network, addr, app string) (*SyslogSink, error) {
+	switch network {
+	case "unix", "unixgram", "udp", "udp4", "udp6":
+		// ok
+	default:
+		return nil, fmt.Errorf("Unsupported network type %s", network)
+	}
+
+	hostname, _ := os.Hostname()
+
+	s := &SyslogSink{
+		Facility: FACILITY_USER,
+		App:      app,
+		Hostname: hostname,
+
+		network: network,
+		addr:    addr,
+		done:    make(chan struct{}),
+	}
+
+	conn, err := s.dial()
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.conn = conn
+
+	return s, nil
+}
+
+// Write send record to syslog. If connection to syslog daemon is lost (e.g.
+// daemon was restarted), record is dropped and sink reconnects in background.
+func (s *SyslogSink) Write(r *Record, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		s.startReconnect()
+		return ErrSyslogNotConnected
+	}
+
+	_, err := s.conn.Write(s.format(r))
+
+	if err != nil {
+		s.conn.Close()
+		s.conn = nil
+		s.startReconnect()
+	}
+
+	return err
+}
+
+// Close close connection to syslog
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		// already closed
+	default:
+		close(s.done)
+	}
+
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+
+	return err
+}
+
+// dial create connection to syslog daemon
+func (s *SyslogSink) dial() (net.Conn, error) {
+	return net.DialTimeout(s.network, s.addr, 5*time.Second)
+}
+
+// startReconnect start reconnection loop if it isn't already started
+// (sink must be locked)
+func (s *SyslogSink) startReconnect() {
+	if s.reconnecting {
+		return
+	}
+
+	select {
+	case <-s.done:
+		return
+	default:
+		s.reconnecting = true
+		go s.reconnect()
+	}
+}
+
+// reconnect try to connect to syslog daemon with exponential backoff until
+// success or sink closing
+func (s *SyslogSink) reconnect() {
+	delay := syslogReconnectDelay
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-time.After(delay):
+		}
+
+		conn, err := s.dial()
+
+		s.mu.Lock()
+
+		select {
+		case <-s.done:
+			if conn != nil {
+				conn.Close()
+			}
+
+			s.mu.Unlock()
+			return
+		default:
+		}
+
+		if err == nil {
+			s.conn = conn
+			s.reconnecting = false
+			s.mu.Unlock()
+			return
+		}
+
+		s.mu.Unlock()
+
+		delay *= 2
+
+		if delay > syslogMaxReconnectDelay {
+			delay = syslogMaxReconnectDelay
+		}
+	}
+}
+
+// format return record formatted as RFC 5424 message
+func (s *SyslogSink) format(r *Record) []byte {
+	var buf bytes.Buffer
+
+	severity, ok := syslogSeverity[r.Level]
+
+	if !ok {
+		severity = 6
+	}
+
+	buf.WriteString("<" + strconv.Itoa(s.Facility*8+severity) + ">1 ")
+	buf.WriteString(r.Time.Format("2006-01-02T15:04:05.000000Z07:00") + " ")
+	buf.WriteString(getSyslogHeaderField(s.Hostname) + " ")
+	buf.WriteString(getSyslogHeaderField(s.App) + " ")
+	buf.WriteString(strconv.Itoa(os.Getpid()) + " - ")
+
+	if len(r.Fields) == 0 {
+		buf.WriteString("-")
+	} else {
+		buf.WriteString("[fields@32473")
+
+		for _, field := range r.Fields {
+			buf.WriteString(" " + getSyslogParamName(field.Key) + "=\"")
+			buf.WriteString(escapeSyslogParamValue(formatFieldValue(field.Value)))
+			buf.WriteString("\"")
+		}
+
+		buf.WriteString("]")
+	}
+
+	if r.Message != "" {
+		buf.WriteString(" " + r.Message)
+	}
+
+	// Stream sockets require message delimiter
+	if s.network == "unix" {
+		buf.WriteString("\n")
+	}
+
+	return buf.Bytes()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// NewRingSink create new ring buffer sink with given size
+func NewRingSink(size int) *RingSink {
+	if size < 1 {
+		size = 1
+	}
+
+	return &RingSink{records: make([]ringRecord, size)}
+}
+
+// Write add record to ring buffer
+func (s *RingSink) Write(r *Record, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[s.next] = ringRecord{*r, append([]byte(nil), data...)}
+	s.next++
+
+	if s.next == len(s.records) {
+		s.next, s.full = 0, true
+	}
+
+	return nil
+}
+
+// Records return records from ring buffer (from oldest to newest)
+func (s *RingSink) Records() []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []Record
+
+	for _, rr := range s.getRecords() {
+		result = append(result, rr.record)
+	}
+
+	return result
+}
+
+// WriteTo write formatted records from ring buffer (from oldest to newest) to
+// given writer
+func (s *RingSink) WriteTo(w io.Writer) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var total int64
+
+	for _, rr := range s.getRecords() {
+		n, err := w.Write(rr.data)
+
+		total += int64(n)
+
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// Close clear ring buffer
+func (s *RingSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = make([]ringRecord, len(s.records))
+	s.next, s.full = 0, false
+
+	return nil
+}
+
+// getRecords return records in right order
+func (s *RingSink) getRecords() []ringRecord {
+	if !s.full {
+		return s.records[:s.next]
+	}
+
+	return append(append([]ringRecord(nil), s.records[s.next:]...), s.records[:s.next]...)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// hasSinksFor return true if logger has sinks for messages with given level
+// from logger with given name (logger must be locked)
+func (l *Logger) hasSinksFor(level int, name string) bool {
+	if minLevel, ok := l.getNameLevel(name); ok && minLevel > level {
+		return false
+	}
+
+	for _, si := range l.sinks {
+		if si.level <= level {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getSinksFor return sinks for messages with given level from logger with
+// given name (logger must be locked)
+func (l *Logger) getSinksFor(level int, name string) []Sink {
+	if minLevel, ok := l.getNameLevel(name); ok && minLevel > level {
+		return nil
+	}
+
+	var result []Sink
+
+	for _, si := range l.sinks {
+		if si.level <= level {
+			result = append(result, si.sink)
+		}
+	}
+
+	return result
+}
+
+// writeToSinks write record to given sinks and return first error (logger
+// must not be locked)
+func (l *Logger) writeToSinks(sinks []Sink, r *Record, data []byte) error {
+	if len(sinks) == 0 {
+		return nil
+	}
+
+	var result error
+
+	l.sinkMu.Lock()
+	defer l.sinkMu.Unlock()
+
+	for _, sink := range sinks {
+		err := sink.Write(r, data)
+
+		if err != nil && result == nil {
+			result = err
+		}
+	}
+
+	return result
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getSyslogHeaderField return header field value or nil value ("-")
+func getSyslogHeaderField(value string) string {
+	value = strings.Replace(value, " ", "_", -1)
+
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
+
+// getSyslogParamName return SD-PARAM name without forbidden symbols
+func getSyslogParamName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '=', ' ', ']', '"':
+			return '_'
+		}
+
+		return r
+	}, name)
+}
+
+// escapeSyslogParamValue escape SD-PARAM value
+func escapeSyslogParamValue(value string) string {
+	value = strings.Replace(value, "\\", "\\\\", -1)
+	value = strings.Replace(value, "\"", "\\\"", -1)
+
+	return strings.Replace(value, "]", "\\]", -1)
+}
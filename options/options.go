@@ -11,8 +11,11 @@ package options
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+
+	"pkg.re/essentialkaos/ek.v9/spellcheck"
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -38,8 +41,12 @@ const (
 	ERROR_WRONG_FORMAT        = 7
 	ERROR_CONFLICT            = 8
 	ERROR_BOUND_NOT_SET       = 9
+	ERROR_UNSUPPORTED_COMMAND = 10
 )
 
+// _SUGGEST_THRESHOLD is maximum distance between given and suggested names
+const _SUGGEST_THRESHOLD = 2
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 // V basic option struct
@@ -76,6 +83,7 @@ type OptionError struct {
 	Option      string
 	BoundOption string
 	Type        int
+	Suggestions []string // list of similar options or commands
 }
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -87,6 +95,10 @@ type optionName struct {
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
+// MaxSuggestions is maximum number of suggestions for unsupported options
+// and commands
+var MaxSuggestions = 3
+
 // global is global options
 var global *Options
 
@@ -102,13 +114,13 @@ func (opts *Options) Add(name string, option *V) error {
 
 	switch {
 	case option == nil:
-		return OptionError{"--" + optName.Long, "", ERROR_OPTION_IS_NIL}
+		return OptionError{Option: "--" + optName.Long, Type: ERROR_OPTION_IS_NIL}
 	case optName.Long == "":
-		return OptionError{"", "", ERROR_NO_NAME}
+		return OptionError{Type: ERROR_NO_NAME}
 	case opts.full[optName.Long] != nil:
-		return OptionError{"--" + optName.Long, "", ERROR_DUPLICATE_LONGNAME}
+		return OptionError{Option: "--" + optName.Long, Type: ERROR_DUPLICATE_LONGNAME}
 	case optName.Short != "" && opts.short[optName.Short] != "":
-		return OptionError{"-" + optName.Short, "", ERROR_DUPLICATE_SHORTNAME}
+		return OptionError{Option: "-" + optName.Short, Type: ERROR_DUPLICATE_SHORTNAME}
 	}
 
 	if option.Required {
@@ -402,6 +414,22 @@ func ParseOptionName(name string) (string, string) {
 	return a.Long, a.Short
 }
 
+// CheckCommand check that command is one of supported commands and return error
+// with suggestions if it isn't
+func CheckCommand(cmd string, commands []string) error {
+	for _, c := range commands {
+		if c == cmd {
+			return nil
+		}
+	}
+
+	return OptionError{
+		Option:      cmd,
+		Type:        ERROR_UNSUPPORTED_COMMAND,
+		Suggestions: suggest(cmd, commands, ""),
+	}
+}
+
 // Q merge several options to string
 func Q(opts ...string) string {
 	return strings.Join(opts, " ")
@@ -421,26 +449,34 @@ func (opts *Options) parseOptions(rawOpts []string) ([]string, []error) {
 		errorList  []error
 	)
 
-	for _, curOpt := range rawOpts {
+OPTLOOP:
+	for index, curOpt := range rawOpts {
 		if optName == "" || mixedOpt {
 			var (
 				curOptName  string
 				curOptValue string
+				flags       []string
+				negated     bool
 				err         error
 			)
 
 			var curOptLen = len(curOpt)
 
 			switch {
+			case curOpt == "--":
+				// everything after "--" is not an option
+				nonOptList = append(nonOptList, rawOpts[index+1:]...)
+				break OPTLOOP
+
 			case strings.TrimRight(curOpt, "-") == "":
 				nonOptList = append(nonOptList, curOpt)
 				continue
 
 			case curOptLen > 2 && curOpt[0:2] == "--":
-				curOptName, curOptValue, err = opts.parseLongOption(curOpt[2:curOptLen])
+				curOptName, curOptValue, negated, err = opts.parseLongOption(curOpt[2:curOptLen])
 
 			case curOptLen > 1 && curOpt[0:1] == "-":
-				curOptName, curOptValue, err = opts.parseShortOption(curOpt[1:curOptLen])
+				curOptName, curOptValue, flags, err = opts.parseShortOption(curOpt[1:curOptLen])
 
 			case mixedOpt:
 				errorList = appendError(
@@ -466,26 +502,38 @@ func (opts *Options) parseOptions(rawOpts []string) ([]string, []error) {
 					updateOption(opts.full[optName], optName, "true"),
 				)
 
-				mixedOpt = false
+				optName, mixedOpt = "", false
 			}
 
-			if curOptValue != "" {
+			for _, flag := range flags {
+				errorList = appendError(
+					errorList,
+					updateOption(opts.full[flag], flag, ""),
+				)
+			}
+
+			switch {
+			case negated:
+				negateOption(opts.full[curOptName])
+
+			case curOptValue != "":
 				errorList = appendError(
 					errorList,
 					updateOption(opts.full[curOptName], curOptName, curOptValue),
 				)
-			} else {
-				if opts.full[curOptName] != nil && opts.full[curOptName].Type == BOOL {
-					errorList = appendError(
-						errorList,
-						updateOption(opts.full[curOptName], curOptName, ""),
-					)
-				} else if opts.full[curOptName] != nil && opts.full[curOptName].Type == MIXED {
-					optName = curOptName
-					mixedOpt = true
-				} else {
-					optName = curOptName
-				}
+
+			case opts.full[curOptName] != nil && opts.full[curOptName].Type == BOOL:
+				errorList = appendError(
+					errorList,
+					updateOption(opts.full[curOptName], curOptName, ""),
+				)
+
+			case opts.full[curOptName] != nil && opts.full[curOptName].Type == MIXED:
+				optName = curOptName
+				mixedOpt = true
+
+			default:
+				optName = curOptName
 			}
 		} else {
 			errorList = appendError(
@@ -506,53 +554,118 @@ func (opts *Options) parseOptions(rawOpts []string) ([]string, []error) {
 				updateOption(opts.full[optName], optName, "true"),
 			)
 		} else {
-			errorList = append(errorList, OptionError{"--" + optName, "", ERROR_EMPTY_VALUE})
+			errorList = append(errorList, OptionError{Option: "--" + optName, Type: ERROR_EMPTY_VALUE})
 		}
 	}
 
 	return nonOptList, errorList
 }
 
-func (opts *Options) parseLongOption(opt string) (string, string, error) {
+// parseLongOption parse long option and return option name, value and
+// negation flag ("--no-" prefix for boolean options)
+func (opts *Options) parseLongOption(opt string) (string, string, bool, error) {
 	if strings.Contains(opt, "=") {
 		optSlice := strings.Split(opt, "=")
 
 		if len(optSlice) <= 1 || optSlice[1] == "" {
-			return "", "", OptionError{"--" + optSlice[0], "", ERROR_WRONG_FORMAT}
+			return "", "", false, OptionError{Option: "--" + optSlice[0], Type: ERROR_WRONG_FORMAT}
+		}
+
+		if opts.full[optSlice[0]] == nil {
+			return "", "", false, opts.getUnsupportedError(optSlice[0])
 		}
 
-		return optSlice[0], strings.Join(optSlice[1:], "="), nil
+		return optSlice[0], strings.Join(optSlice[1:], "="), false, nil
 	}
 
 	if opts.full[opt] != nil {
-		return opt, "", nil
+		return opt, "", false, nil
+	}
+
+	if strings.HasPrefix(opt, "no-") {
+		optName := opt[3:]
+
+		if opts.full[optName] != nil && opts.full[optName].Type == BOOL {
+			return optName, "", true, nil
+		}
 	}
 
-	return "", "", OptionError{"--" + opt, "", ERROR_UNSUPPORTED}
+	return "", "", false, opts.getUnsupportedError(opt)
 }
 
-func (opts *Options) parseShortOption(opt string) (string, string, error) {
+// parseShortOption parse short option and return option name, value and
+// list of clustered boolean options ("-abc")
+func (opts *Options) parseShortOption(opt string) (string, string, []string, error) {
 	if strings.Contains(opt, "=") {
 		optSlice := strings.Split(opt, "=")
 
 		if len(optSlice) <= 1 || optSlice[1] == "" {
-			return "", "", OptionError{"-" + optSlice[0], "", ERROR_WRONG_FORMAT}
+			return "", "", nil, OptionError{Option: "-" + optSlice[0], Type: ERROR_WRONG_FORMAT}
 		}
 
 		optName := optSlice[0]
 
-		if opts.short[optName] == "" {
-			return "", "", OptionError{"-" + optName, "", ERROR_UNSUPPORTED}
+		if opts.short[optName] != "" {
+			return opts.short[optName], strings.Join(optSlice[1:], "="), nil, nil
 		}
+	}
 
-		return opts.short[optName], strings.Join(optSlice[1:], "="), nil
+	if opts.short[opt] != "" {
+		return opts.short[opt], "", nil, nil
 	}
 
-	if opts.short[opt] == "" {
-		return "", "", OptionError{"-" + opt, "", ERROR_UNSUPPORTED}
+	return opts.parseShortOptionsCluster(opt)
+}
+
+// parseShortOptionsCluster parse group of short options ("-abc") or short option
+// with attached value ("-p8080")
+func (opts *Options) parseShortOptionsCluster(opt string) (string, string, []string, error) {
+	var flags []string
+
+	for index, r := range opt {
+		optName := opts.short[string(r)]
+
+		if optName == "" {
+			if index == 0 {
+				return "", "", nil, OptionError{Option: "-" + strings.Split(opt, "=")[0], Type: ERROR_UNSUPPORTED}
+			}
+
+			return "", "", nil, OptionError{Option: "-" + string(r), Type: ERROR_UNSUPPORTED}
+		}
+
+		rest := opt[index+len(string(r)):]
+
+		switch {
+		case rest == "":
+			return optName, "", flags, nil
+		case opts.full[optName].Type == BOOL && rest[0] == '=':
+			return "", "", nil, OptionError{Option: "-" + string(r), Type: ERROR_WRONG_FORMAT}
+		case opts.full[optName].Type == BOOL:
+			flags = append(flags, optName)
+		default:
+			return optName, strings.TrimPrefix(rest, "="), flags, nil
+		}
+	}
+
+	return "", "", nil, OptionError{Option: "-" + opt, Type: ERROR_UNSUPPORTED}
+}
+
+// getUnsupportedError return error for unsupported long option with list of
+// similar supported options
+func (opts *Options) getUnsupportedError(name string) error {
+	var names []string
+
+	for n := range opts.full {
+		names = append(names, n)
 	}
 
-	return opts.short[opt], "", nil
+	sort.Strings(names)
+
+	return OptionError{
+		Option:      "--" + name,
+		Type:        ERROR_UNSUPPORTED,
+		Suggestions: suggest(name, names, "--"),
+	}
 }
 
 func (opts *Options) validate() []error {
@@ -564,7 +677,7 @@ func (opts *Options) validate() []error {
 
 	for n, v := range opts.full {
 		if v.Required == true && v.Value == nil {
-			errorList = append(errorList, OptionError{n, "", ERROR_REQUIRED_NOT_SET})
+			errorList = append(errorList, OptionError{Option: n, Type: ERROR_REQUIRED_NOT_SET})
 		}
 
 		if v.Conflicts != "" {
@@ -572,7 +685,7 @@ func (opts *Options) validate() []error {
 
 			for _, c := range conflicts {
 				if opts.Has(c.Long) {
-					errorList = append(errorList, OptionError{n, c.Long, ERROR_CONFLICT})
+					errorList = append(errorList, OptionError{Option: n, BoundOption: c.Long, Type: ERROR_CONFLICT})
 				}
 			}
 		}
@@ -582,7 +695,7 @@ func (opts *Options) validate() []error {
 
 			for _, b := range bound {
 				if !opts.Has(b.Long) {
-					errorList = append(errorList, OptionError{n, b.Long, ERROR_BOUND_NOT_SET})
+					errorList = append(errorList, OptionError{Option: n, BoundOption: b.Long, Type: ERROR_BOUND_NOT_SET})
 				}
 			}
 		}
@@ -637,6 +750,11 @@ func updateOption(opt *V, name string, value string) error {
 	return fmt.Errorf("Option --%s has unsupported type", parseName(name).Long)
 }
 
+func negateOption(opt *V) {
+	opt.Value = false
+	opt.set = true
+}
+
 func updateStringOption(opt *V, value string) error {
 	if opt.set && opt.Mergeble {
 		opt.Value = opt.Value.(string) + " " + value
@@ -659,7 +777,7 @@ func updateFloatOption(name string, opt *V, value string) error {
 	floatValue, err := strconv.ParseFloat(value, 64)
 
 	if err != nil {
-		return OptionError{"--" + name, "", ERROR_WRONG_FORMAT}
+		return OptionError{Option: "--" + name, Type: ERROR_WRONG_FORMAT}
 	}
 
 	var resultFloat float64
@@ -684,7 +802,7 @@ func updateIntOption(name string, opt *V, value string) error {
 	intValue, err := strconv.Atoi(value)
 
 	if err != nil {
-		return OptionError{"--" + name, "", ERROR_WRONG_FORMAT}
+		return OptionError{Option: "--" + name, Type: ERROR_WRONG_FORMAT}
 	}
 
 	var resultInt int
@@ -705,6 +823,27 @@ func updateIntOption(name string, opt *V, value string) error {
 	return nil
 }
 
+// suggest return list of names similar to given name
+func suggest(name string, names []string, prefix string) []string {
+	if name == "" || len(names) == 0 || MaxSuggestions <= 0 {
+		return nil
+	}
+
+	var result []string
+
+	model := spellcheck.Train(names)
+
+	for _, n := range model.Suggest(name, MaxSuggestions) {
+		if n == "" || n == name || spellcheck.Distance(name, n) > _SUGGEST_THRESHOLD {
+			continue
+		}
+
+		result = append(result, prefix+n)
+	}
+
+	return result
+}
+
 func appendError(errList []error, err error) []error {
 	if err == nil {
 		return errList
@@ -738,7 +877,9 @@ func betweenFloat(val, min, max float64) float64 {
 func (e OptionError) Error() string {
 	switch e.Type {
 	default:
-		return fmt.Sprintf("Option %s is not supported", e.Option)
+		return fmt.Sprintf("Option %s is not supported", e.Option) + e.getSuggestionsHint()
+	case ERROR_UNSUPPORTED_COMMAND:
+		return fmt.Sprintf("Command %s is not supported", e.Option) + e.getSuggestionsHint()
 	case ERROR_EMPTY_VALUE:
 		return fmt.Sprintf("Non-boolean option %s is empty", e.Option)
 	case ERROR_REQUIRED_NOT_SET:
@@ -758,4 +899,21 @@ func (e OptionError) Error() string {
 	}
 }
 
+// getSuggestionsHint return hint with suggested options or commands
+func (e OptionError) getSuggestionsHint() string {
+	switch len(e.Suggestions) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf(" (did you mean %s?)", e.Suggestions[0])
+	}
+
+	last := len(e.Suggestions) - 1
+
+	return fmt.Sprintf(
+		" (did you mean %s or %s?)",
+		strings.Join(e.Suggestions[:last], ", "), e.Suggestions[last],
+	)
+}
+
 // ////////////////////////////////////////////////////////////////////////////////// //
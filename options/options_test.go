@@ -339,10 +339,10 @@ func (s *OptUtilSuite) TestParsing(c *C) {
 
 	// //////////////////////////////////////////////////////////////////////////////// //
 
-	fArgs, errs := NewOptions().Parse([]string{"-", "--"}, Map{"t:test": {}})
+	fArgs, errs := NewOptions().Parse([]string{"-", "---", "--", "-t"}, Map{"t:test": {}})
 
 	c.Assert(errs, HasLen, 0)
-	c.Assert(fArgs, DeepEquals, []string{"-", "--"})
+	c.Assert(fArgs, DeepEquals, []string{"-", "---", "-t"})
 
 	// //////////////////////////////////////////////////////////////////////////////// //
 
@@ -408,6 +408,147 @@ func (s *OptUtilSuite) TestParsing(c *C) {
 	c.Assert(errs[0].Error(), Equals, "Some option does not have a name")
 }
 
+func (s *OptUtilSuite) TestGNUParsing(c *C) {
+	getOptMap := func() Map {
+		return Map{
+			"a:all":     {Type: BOOL},
+			"b:brief":   {Type: BOOL},
+			"c:color":   {Type: BOOL, Value: true},
+			"p:port":    {Type: INT},
+			"o:output":  {},
+			"M:mixed":   {Type: MIXED},
+			"no-header": {Type: BOOL},
+		}
+	}
+
+	type values map[string]string
+
+	testCases := []struct {
+		args   string
+		nonOpt []string
+		values values
+		errors []string
+	}{
+		{"-abc", nil, values{"all": "true", "brief": "true", "color": "true"}, nil},
+		{"-ab file", []string{"file"}, values{"all": "true", "brief": "true"}, nil},
+		{"-p8080", nil, values{"port": "8080"}, nil},
+		{"-ap8080", nil, values{"all": "true", "port": "8080"}, nil},
+		{"-ap=8080", nil, values{"all": "true", "port": "8080"}, nil},
+		{"-ap 8080", nil, values{"all": "true", "port": "8080"}, nil},
+		{"-ooutput.txt", nil, values{"output": "output.txt"}, nil},
+		{"-ao -", nil, values{"all": "true", "output": "-"}, nil},
+		{"-aM", nil, values{"all": "true", "mixed": "true"}, nil},
+		{"-aMtest", nil, values{"all": "true", "mixed": "test"}, nil},
+		{"-M -a file", []string{"file"}, values{"all": "true", "mixed": "true"}, nil},
+		{"-a -- -b --port 80", []string{"-b", "--port", "80"}, values{"all": "true", "brief": "", "port": ""}, nil},
+		{"-M --", nil, values{"mixed": "true"}, nil},
+		{"-o -- file", []string{"file"}, values{"output": "--"}, nil},
+		{"- -a", []string{"-"}, values{"all": "true"}, nil},
+		{"--", nil, values{}, nil},
+		{"--no-color", nil, values{"color": "false"}, nil},
+		{"--color --no-color", nil, values{"color": "false"}, nil},
+		{"--no-header", nil, values{"header": "", "no-header": "true"}, nil},
+		{"--no-port", nil, values{}, []string{"Option --no-port is not supported"}},
+		{"--no-unknown", nil, values{}, []string{"Option --no-unknown is not supported"}},
+		{"-axb", nil, values{}, []string{"Option -x is not supported"}},
+		{"-xyz", nil, values{}, []string{"Option -xyz is not supported"}},
+		{"-ab=1", nil, values{}, []string{"Option -b has wrong format"}},
+		{"-apX", nil, values{}, []string{"Option --port has wrong format"}},
+	}
+
+	for _, tc := range testCases {
+		opts := NewOptions()
+		nonOpt, errs := opts.Parse(strings.Split(tc.args, " "), getOptMap())
+
+		var errMessages []string
+
+		for _, err := range errs {
+			errMessages = append(errMessages, err.Error())
+		}
+
+		c.Assert(nonOpt, DeepEquals, tc.nonOpt, Commentf("Args: %s", tc.args))
+		c.Assert(errMessages, DeepEquals, tc.errors, Commentf("Args: %s", tc.args))
+
+		for name, value := range tc.values {
+			c.Assert(opts.GetS(name), Equals, value, Commentf("Args: %s | Option: %s", tc.args, name))
+		}
+	}
+}
+
+func (s *OptUtilSuite) TestSuggestions(c *C) {
+	optMap := Map{
+		"v:verbose": {Type: BOOL},
+		"version":   {Type: BOOL, Alias: "ver"},
+		"o:output":  {},
+	}
+
+	_, errs := NewOptions().Parse([]string{"--verbos"}, optMap)
+
+	c.Assert(errs, HasLen, 1)
+	c.Assert(errs[0].(OptionError).Type, Equals, ERROR_UNSUPPORTED)
+	c.Assert(errs[0].(OptionError).Suggestions, DeepEquals, []string{"--verbose"})
+	c.Assert(errs[0].Error(), Equals, "Option --verbos is not supported (did you mean --verbose?)")
+
+	_, errs = NewOptions().Parse([]string{"--ouptut=file.txt"}, optMap)
+
+	c.Assert(errs, HasLen, 1)
+	c.Assert(errs[0].Error(), Equals, "Option --ouptut is not supported (did you mean --output?)")
+
+	_, errs = NewOptions().Parse([]string{"--vers"}, optMap)
+
+	c.Assert(errs, HasLen, 1)
+	c.Assert(errs[0].(OptionError).Suggestions, DeepEquals, []string{"--ver"})
+
+	_, errs = NewOptions().Parse([]string{"--abcdef"}, optMap)
+
+	c.Assert(errs, HasLen, 1)
+	c.Assert(errs[0].(OptionError).Suggestions, HasLen, 0)
+	c.Assert(errs[0].Error(), Equals, "Option --abcdef is not supported")
+
+	MaxSuggestions = 0
+
+	_, errs = NewOptions().Parse([]string{"--verbos"}, optMap)
+
+	c.Assert(errs, HasLen, 1)
+	c.Assert(errs[0].(OptionError).Suggestions, HasLen, 0)
+
+	MaxSuggestions = 3
+
+	optMap = Map{"ab": {}, "ac": {}, "ad": {}, "ae": {}}
+
+	for i := 0; i < 10; i++ {
+		_, errs = NewOptions().Parse([]string{"--aa"}, optMap)
+
+		c.Assert(errs, HasLen, 1)
+		c.Assert(errs[0].(OptionError).Suggestions, DeepEquals, []string{"--ab", "--ac", "--ad"})
+	}
+
+	c.Assert(CheckCommand("ab", []string{"", "cdef"}), DeepEquals, OptionError{Option: "ab", Type: ERROR_UNSUPPORTED_COMMAND})
+
+	err := OptionError{Option: "--tst", Suggestions: []string{"--test", "--tset", "--ts"}}
+
+	c.Assert(err.Error(), Equals, "Option --tst is not supported (did you mean --test, --tset or --ts?)")
+}
+
+func (s *OptUtilSuite) TestCheckCommand(c *C) {
+	commands := []string{"install", "remove", "list"}
+
+	c.Assert(CheckCommand("list", commands), IsNil)
+
+	err := CheckCommand("instal", commands)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.(OptionError).Type, Equals, ERROR_UNSUPPORTED_COMMAND)
+	c.Assert(err.Error(), Equals, "Command instal is not supported (did you mean install?)")
+
+	err = CheckCommand("build", commands)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "Command build is not supported")
+
+	c.Assert(CheckCommand("list", nil), NotNil)
+}
+
 func (s *OptUtilSuite) TestMerging(c *C) {
 	c.Assert(Q(), Equals, "")
 	c.Assert(Q("test"), Equals, "test")
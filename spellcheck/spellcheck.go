@@ -36,6 +36,10 @@ func (s suggestItems) Len() int {
 }
 
 func (s suggestItems) Less(i, j int) bool {
+	if s[i].score == s[j].score {
+		return s[i].term < s[j].term
+	}
+
 	return s[i].score < s[j].score
 }
 
@@ -65,6 +69,8 @@ func Train(words []string) *Model {
 		model.terms = append(model.terms, cw)
 	}
 
+	sort.Strings(model.terms)
+
 	return model
 }
 
@@ -107,7 +113,7 @@ func (m *Model) Suggest(word string, max int) []string {
 
 	sis := getSuggestSlice(m.terms, word)
 
-	sort.Sort(sis)
+	sort.Stable(sis)
 
 	var result []string
 
@@ -118,6 +124,12 @@ func (m *Model) Suggest(word string, max int) []string {
 	return result
 }
 
+// Distance return Damerau–Levenshtein distance between two strings
+// (case insensitive)
+func Distance(source, target string) int {
+	return getDLDistance(strings.ToLower(source), strings.ToLower(target))
+}
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 // Damerau–Levenshtein distance algorithm and code
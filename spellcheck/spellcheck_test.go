@@ -44,4 +44,20 @@ func (s *SpellcheckSuite) TestSpellcheck(c *C) {
 
 	c.Assert(model.Suggest("tes", 3), DeepEquals, []string{"test", "", "TeStInG"})
 	c.Assert(model.Suggest("tes", 1), DeepEquals, []string{"test"})
+
+	model = Train([]string{"tesc", "tesb", "tesa", "tes"})
+
+	for i := 0; i < 10; i++ {
+		c.Assert(model.Correct("tesd"), Equals, "tes")
+		c.Assert(model.Suggest("tesd", 4), DeepEquals, []string{"tes", "tesa", "tesb", "tesc"})
+	}
+}
+
+func (s *SpellcheckSuite) TestDistance(c *C) {
+	c.Assert(Distance("", ""), Equals, 0)
+	c.Assert(Distance("test", ""), Equals, 4)
+	c.Assert(Distance("verbose", "verbose"), Equals, 0)
+	c.Assert(Distance("verbos", "VERBOSE"), Equals, 1)
+	c.Assert(Distance("vrebose", "verbose"), Equals, 1)
+	c.Assert(Distance("help", "version"), Not(Equals), 1)
 }
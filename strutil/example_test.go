@@ -27,6 +27,20 @@ func ExampleEllipsis() {
 	// This is too lon...
 }
 
+func ExampleEllipsisWidth() {
+	fmt.Println(EllipsisWidth("例子 is too long message", 12))
+
+	// Output:
+	// 例子 is t...
+}
+
+func ExampleEllipsisMiddleWidth() {
+	fmt.Println(EllipsisMiddleWidth("This is too long message to show", 18))
+
+	// Output:
+	// This is ...to show
+}
+
 func ExampleSubstr() {
 	fmt.Println(Substr("This is funny message", 8, 13))
 
@@ -41,6 +55,13 @@ func ExampleLen() {
 	// 11
 }
 
+func ExampleWidth() {
+	fmt.Println(Width("Пример 例子 例"))
+
+	// Output:
+	// 14
+}
+
 func ExampleHead() {
 	fmt.Println(Head("This is funny message", 7))
 
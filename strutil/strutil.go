@@ -11,6 +11,7 @@ package strutil
 import (
 	"bytes"
 	"strings"
+	"unicode"
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -81,6 +82,36 @@ func Ellipsis(s string, maxSize int) string {
 	return Substr(s, 0, maxSize-3) + "..."
 }
 
+// EllipsisWidth trims given string to given width in terminal cells (wide East
+// Asian symbols take two cells) and adds ellipsis at the end of it
+func EllipsisWidth(s string, maxWidth int) string {
+	if Width(s) <= maxWidth {
+		return s
+	}
+
+	if maxWidth <= 3 {
+		return HeadWidth(s, maxWidth)
+	}
+
+	return HeadWidth(s, maxWidth-3) + "..."
+}
+
+// EllipsisMiddleWidth trims given string to given width in terminal cells and
+// adds ellipsis in the middle of it
+func EllipsisMiddleWidth(s string, maxWidth int) string {
+	if Width(s) <= maxWidth {
+		return s
+	}
+
+	if maxWidth <= 3 {
+		return HeadWidth(s, maxWidth)
+	}
+
+	tail := TailWidth(s, (maxWidth-3)/2)
+
+	return HeadWidth(s, maxWidth-3-Width(tail)) + "..." + tail
+}
+
 // Head return n first symbols from given string
 func Head(s string, n int) string {
 	if s == "" || n <= 0 {
@@ -111,6 +142,53 @@ func Tail(s string, n int) string {
 	return Substr(s, l-n, l)
 }
 
+// Width return number of terminal cells required for displaying given string
+// (wide East Asian symbols take two cells, combining marks don't take cells
+// at all)
+func Width(s string) int {
+	var width int
+
+	for _, r := range s {
+		width += getRuneWidth(r)
+	}
+
+	return width
+}
+
+// HeadWidth return the longest prefix of given string which fits given width
+// in terminal cells
+func HeadWidth(s string, width int) string {
+	var size int
+
+	for index, r := range s {
+		size += getRuneWidth(r)
+
+		if size > width {
+			return s[:index]
+		}
+	}
+
+	return s
+}
+
+// TailWidth return the longest suffix of given string which fits given width
+// in terminal cells
+func TailWidth(s string, width int) string {
+	runes := []rune(s)
+
+	var size int
+
+	for index := len(runes) - 1; index >= 0; index-- {
+		size += getRuneWidth(runes[index])
+
+		if size > width {
+			return string(runes[index+1:])
+		}
+	}
+
+	return s
+}
+
 // PrefixSize return prefix size
 func PrefixSize(str string, prefix rune) int {
 	if str == "" {
@@ -226,3 +304,36 @@ func formatItems(data []string) []string {
 
 	return result
 }
+
+// getRuneWidth return number of terminal cells required for displaying
+// given rune
+func getRuneWidth(r rune) int {
+	switch {
+	case r == 0,
+		unicode.Is(unicode.Mn, r),
+		unicode.Is(unicode.Me, r),
+		unicode.Is(unicode.Cf, r):
+		return 0
+
+	case isWideRune(r):
+		return 2
+	}
+
+	return 1
+}
+
+// isWideRune return true if rune is wide or fullwidth East Asian symbol
+func isWideRune(r rune) bool {
+	return r >= 0x1100 && (r <= 0x115F || // Hangul Jamo
+		r == 0x2329 || r == 0x232A ||
+		(r >= 0x2E80 && r <= 0xA4CF && r != 0x303F) || // CJK ... Yi
+		(r >= 0xAC00 && r <= 0xD7A3) || // Hangul Syllables
+		(r >= 0xF900 && r <= 0xFAFF) || // CJK Compatibility Ideographs
+		(r >= 0xFE10 && r <= 0xFE19) || // Vertical forms
+		(r >= 0xFE30 && r <= 0xFE6F) || // CJK Compatibility Forms
+		(r >= 0xFF00 && r <= 0xFF60) || // Fullwidth Forms
+		(r >= 0xFFE0 && r <= 0xFFE6) ||
+		(r >= 0x1F300 && r <= 0x1F64F) || // Emoji
+		(r >= 0x1F900 && r <= 0x1F9FF) ||
+		(r >= 0x20000 && r <= 0x3FFFD))
+}
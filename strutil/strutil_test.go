@@ -61,6 +61,30 @@ func (s *StrUtilSuite) BenchmarkEllipsis(c *C) {
 	}
 }
 
+func (s *StrUtilSuite) TestEllipsisWidth(c *C) {
+	c.Assert(EllipsisWidth("Test1234", 8), Equals, "Test1234")
+	c.Assert(EllipsisWidth("Test1234test", 8), Equals, "Test1...")
+	c.Assert(EllipsisWidth("Test1234test", 2), Equals, "Te")
+	c.Assert(EllipsisWidth("日本語テキスト", 9), Equals, "日本語...")
+	c.Assert(EllipsisMiddleWidth("abcdefghijkl", 12), Equals, "abcdefghijkl")
+	c.Assert(EllipsisMiddleWidth("abcdefghijkl", 9), Equals, "abc...jkl")
+	c.Assert(EllipsisMiddleWidth("abcdefghijkl", 8), Equals, "abc...kl")
+	c.Assert(EllipsisMiddleWidth("abcdefghijkl", 2), Equals, "ab")
+	c.Assert(EllipsisMiddleWidth("日本語テキスト", 9), Equals, "日本...ト")
+}
+
+func (s *StrUtilSuite) TestWidth(c *C) {
+	c.Assert(Width("abc"), Equals, 3)
+	c.Assert(Width("日本語"), Equals, 6)
+	c.Assert(Width("ＡＢＣ"), Equals, 6)
+	c.Assert(Width("e\u0301"), Equals, 1)
+	c.Assert(HeadWidth("日本語", 3), Equals, "日")
+	c.Assert(HeadWidth("日本語", 4), Equals, "日本")
+	c.Assert(HeadWidth("abc", 5), Equals, "abc")
+	c.Assert(TailWidth("日本語", 5), Equals, "本語")
+	c.Assert(TailWidth("abc", 5), Equals, "abc")
+}
+
 func (s *StrUtilSuite) TestHead(c *C) {
 	c.Assert(Head("", 1), Equals, "")
 	c.Assert(Head("ABCD1234", 0), Equals, "")
@@ -8,7 +8,9 @@ package terminal
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -90,3 +92,103 @@ func ExamplePrintWarnMessage() {
 	// print this text with yellow color
 	PrintWarnMessage("Warning file is not found")
 }
+
+func ExampleSelect() {
+	options := []string{"MySQL", "PostgreSQL", "SQLite"}
+
+	// PostgreSQL is selected by default
+	index, err := Select("Please select database", options, 1)
+
+	if err != nil {
+		return
+	}
+
+	fmt.Printf("Selected database: %s\n", options[index])
+}
+
+func ExampleMultiSelect() {
+	options := []string{"Server", "Client", "Documentation"}
+
+	// server and client are selected by default
+	selected, err := MultiSelect("Please select components", options, []int{0, 1})
+
+	if err != nil {
+		return
+	}
+
+	for _, index := range selected {
+		fmt.Printf("Component %s will be installed\n", options[index])
+	}
+}
+
+func ExampleReadNumber() {
+	// user must enter number from 1 to 32, 4 is used if user enters empty value
+	workers, err := ReadNumber("Please enter number of workers", 1, 32, 4)
+
+	if err != nil {
+		return
+	}
+
+	fmt.Printf("Number of workers: %d\n", workers)
+}
+
+func ExampleReadValidated() {
+	email, err := ReadValidated("Please enter email", true,
+		func(input string) error {
+			if !strings.Contains(input, "@") {
+				return errors.New("Email must contain @ symbol")
+			}
+
+			return nil
+		},
+	)
+
+	if err != nil {
+		return
+	}
+
+	fmt.Printf("Email: %s\n", email)
+}
+
+func ExampleNewScriptedInput() {
+	// all methods will read input from given lines
+	InputSource = NewScriptedInput("John", "Y")
+
+	name, _ := ReadUI("Please enter user name", true)
+	ok, _ := ReadAnswer("Create user?", "N")
+
+	fmt.Println(name, ok)
+}
+
+func ExampleSetAnswers() {
+	// answers will be used instead of reading input from user
+	SetAnswers(map[string]string{
+		"Please enter user name": "John",
+		"Create user?":           "Y",
+	})
+
+	name, _ := ReadUI("Please enter user name", true)
+	ok, _ := ReadAnswer("Create user?", "N")
+
+	fmt.Println(name, ok)
+}
+
+func ExampleLoadAnswers() {
+	// file must contain JSON object with prompt titles as keys
+	// and answers as values
+	err := LoadAnswers("/path/to/answers.json")
+
+	if err != nil {
+		fmt.Printf("Can't load answers: %v\n", err)
+		return
+	}
+
+	ok, err := ReadAnswer("Remove all files?", "N")
+
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Answer: %t\n", ok)
+}
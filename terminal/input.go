@@ -0,0 +1,188 @@
+package terminal
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+
+	"pkg.re/essentialkaos/ek.v9/fmtc"
+	"pkg.re/essentialkaos/ek.v9/jsonutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ENV_NON_INTERACTIVE is name of environment variable which can be used for
+// enabling ("1", "true" or "yes") or disabling ("0", "false" or "no") non-interactive
+// mode
+const ENV_NON_INTERACTIVE = "TERMINAL_NON_INTERACTIVE"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Input is source of user input
+type Input interface {
+	// ReadLine print prompt and read line of user input
+	ReadLine(prompt string) (string, error)
+}
+
+// Validator is function for input validation, it must return error with
+// description of problem if input is not valid
+type Validator func(input string) error
+
+// ScriptedInput is input source which returns predefined lines one by one,
+// it can be used for testing and automation
+type ScriptedInput struct {
+	lines []string
+	index int
+	mu    *sync.Mutex
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ErrNoMoreInput is returned by ScriptedInput if all lines were read
+var ErrNoMoreInput = errors.New("There is no more input data")
+
+// ErrNonInteractive is returned if prompt requires user input, but terminal is
+// in non-interactive mode and there is no default value or preloaded answer
+var ErrNonInteractive = errors.New("Can't read user input in non-interactive mode: prompt has no default value or preloaded answer")
+
+// ErrInvalidAnswer is returned if preloaded answer is not valid
+var ErrInvalidAnswer = errors.New("Preloaded answer is not valid")
+
+// NonInteractive is non-interactive mode flag. In non-interactive mode prompts
+// don't wait for user input and use default values instead. By default, mode is
+// enabled if stdin isn't a terminal, this can be changed using environment
+// variable TERMINAL_NON_INTERACTIVE.
+var NonInteractive = isNonInteractive()
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// answers contains preloaded answers
+var answers map[string]string
+
+// answersMu is answers mutex
+var answersMu = &sync.RWMutex{}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// NewScriptedInput create new input source with given lines
+func NewScriptedInput(lines ...string) *ScriptedInput {
+	return &ScriptedInput{lines: lines, mu: &sync.Mutex{}}
+}
+
+// SetAnswers set preloaded answers for prompts. Key is prompt title (without
+// color tags), value is answer in the same format as user input. Preloaded answers
+// are used instead of reading input from user.
+func SetAnswers(data map[string]string) {
+	answersMu.Lock()
+	defer answersMu.Unlock()
+
+	if len(data) == 0 {
+		answers = nil
+		return
+	}
+
+	answers = make(map[string]string)
+
+	for title, answer := range data {
+		answers[getAnswerKey(title)] = answer
+	}
+}
+
+// LoadAnswers load preloaded answers from JSON file with object where keys
+// are prompt titles and values are answers
+func LoadAnswers(file string) error {
+	data := make(map[string]string)
+	err := jsonutil.DecodeFile(file, &data)
+
+	if err != nil {
+		return err
+	}
+
+	SetAnswers(data)
+
+	return nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ReadLine return next line
+func (i *ScriptedInput) ReadLine(prompt string) (string, error) {
+	if i == nil || i.mu == nil {
+		return "", ErrNoMoreInput
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.index >= len(i.lines) {
+		return "", ErrNoMoreInput
+	}
+
+	i.index++
+
+	return i.lines[i.index-1], nil
+}
+
+// Add add lines to the end of input
+func (i *ScriptedInput) Add(lines ...string) {
+	if i == nil || i.mu == nil {
+		return
+	}
+
+	i.mu.Lock()
+	i.lines = append(i.lines, lines...)
+	i.mu.Unlock()
+}
+
+// Left return number of lines which were not read yet
+func (i *ScriptedInput) Left() int {
+	if i == nil || i.mu == nil {
+		return 0
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return len(i.lines) - i.index
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getAnswer return preloaded answer for prompt with given title
+func getAnswer(title string) (string, bool) {
+	answersMu.RLock()
+	defer answersMu.RUnlock()
+
+	if answers == nil {
+		return "", false
+	}
+
+	answer, ok := answers[getAnswerKey(title)]
+
+	return answer, ok
+}
+
+// isNonInteractive return true if non-interactive mode is forced by environment
+// variable or stdin isn't a terminal
+func isNonInteractive() bool {
+	switch strings.ToLower(os.Getenv(ENV_NON_INTERACTIVE)) {
+	case "1", "true", "yes":
+		return true
+	case "0", "false", "no":
+		return false
+	}
+
+	return !fmtc.IsTerminal(os.Stdin)
+}
+
+func getAnswerKey(title string) string {
+	return strings.TrimSpace(fmtc.Clean(title))
+}
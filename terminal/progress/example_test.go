@@ -0,0 +1,67 @@
+package progress
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func ExampleNew() {
+	resp, err := http.Get("https://domain.com/file.tar.gz")
+
+	if err != nil {
+		return
+	}
+
+	defer resp.Body.Close()
+
+	bar := New(resp.ContentLength, "file.tar.gz")
+	bar.IsSize = true
+
+	bar.Start()
+
+	// progress will be updated while reading data
+	io.Copy(ioutil.Discard, bar.Reader(resp.Body))
+
+	bar.Finish()
+}
+
+func ExampleNewGroup() {
+	bar1 := New(100, "Task 1")
+	bar2 := New(300, "Task 2")
+
+	g := NewGroup(bar1, bar2)
+
+	g.Start()
+
+	for i := 0; i < 100; i++ {
+		bar1.Add(1)
+		bar2.Add(3)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	g.Finish()
+}
+
+func ExampleNewSpinner() {
+	spinner := NewSpinner("Checking dependencies")
+
+	spinner.Start()
+
+	time.Sleep(time.Second)
+	spinner.Update("Checking dependencies (2/3)")
+	time.Sleep(time.Second)
+
+	// print message with status
+	spinner.Done(true)
+}
@@ -0,0 +1,670 @@
+// Package progress provides progress bar and spinner widgets
+package progress
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"pkg.re/essentialkaos/ek.v9/fmtc"
+	"pkg.re/essentialkaos/ek.v9/fmtutil"
+	"pkg.re/essentialkaos/ek.v9/mathutil"
+	"pkg.re/essentialkaos/ek.v9/strutil"
+	"pkg.re/essentialkaos/ek.v9/terminal/window"
+	"pkg.re/essentialkaos/ek.v9/timeutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	_CODE_CLEAR_LINE = "\033[K"
+	_CODE_MOVE_UP    = "\033[%dA"
+)
+
+const (
+	_DEFAULT_WIDTH = 80
+	_MIN_BAR_WIDTH = 10
+	_MAX_BAR_WIDTH = 60
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Bar is progress bar. Fields must be set before Start, use SetTotal and
+// SetName for changing total and name while progress is rendering.
+type Bar struct {
+	current int64 // must be first field for atomic operations on 32-bit systems
+
+	Name      string    // Name is progress name printed before bar
+	Total     int64     // Total is expected amount of work (0 if unknown)
+	Width     int       // Width is bar width (0 for fitting terminal width)
+	IsSize    bool      // IsSize is true if progress values are sizes in bytes
+	ShowSpeed bool      // ShowSpeed enable throughput output
+	ShowETA   bool      // ShowETA enable ETA output
+	Output    io.Writer // Output is writer for progress output (os.Stdout by default)
+
+	started  time.Time
+	finished time.Time
+	renderer *renderer
+	grouped  bool
+	mu       *sync.Mutex
+}
+
+// Group is group of progress bars rendered together
+type Group struct {
+	Output io.Writer // Output is writer for progress output (os.Stdout by default)
+
+	bars     []*Bar
+	renderer *renderer
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// UpdateInterval is interval between progress redrawing in terminal
+var UpdateInterval = 100 * time.Millisecond
+
+// PlainUpdateInterval is interval between printing progress if output isn't
+// a terminal
+var PlainUpdateInterval = 5 * time.Second
+
+// BarFullSymbol is symbol used for filled part of bar
+var BarFullSymbol = "█"
+
+// BarEmptySymbol is symbol used for empty part of bar
+var BarEmptySymbol = "░"
+
+// BarColorTag is fmtc tag used for filled part of bar
+var BarColorTag = "{?success}"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// widget is progress element which can be rendered
+type widget interface {
+	// render return line for rendering in terminal with given width
+	render(width int, now time.Time) string
+
+	// renderPlain return line for printing if output isn't a terminal
+	renderPlain(now time.Time) string
+}
+
+// barState contains bar data used for rendering
+type barState struct {
+	name     string
+	total    int64
+	width    int
+	started  time.Time
+	end      time.Time // now if bar isn't finished
+	finished bool
+}
+
+// renderer periodically renders widgets
+type renderer struct {
+	widgets []widget
+	output  io.Writer
+	tty     bool
+	lines   int
+	plain   []string
+	resize  *window.Subscription
+	stop    chan bool
+	done    chan bool
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// isTerminal is function used for checking output
+var isTerminal = fmtc.IsTerminal
+
+// subscribeResize is function used for subscribing to window size changes
+var subscribeResize = window.Subscribe
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// New create new progress bar
+func New(total int64, name string) *Bar {
+	return &Bar{
+		Name:      name,
+		Total:     total,
+		ShowSpeed: true,
+		ShowETA:   true,
+
+		mu: &sync.Mutex{},
+	}
+}
+
+// NewGroup create new group of progress bars
+func NewGroup(bars ...*Bar) *Group {
+	g := &Group{}
+	g.Add(bars...)
+	return g
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Start start progress rendering
+func (b *Bar) Start() {
+	if b == nil {
+		return
+	}
+
+	b.init()
+
+	b.mu.Lock()
+
+	if !b.started.IsZero() {
+		b.mu.Unlock()
+		return
+	}
+
+	b.started = time.Now()
+
+	if b.grouped {
+		b.mu.Unlock()
+		return
+	}
+
+	b.renderer = newRenderer(b.Output, b)
+
+	b.mu.Unlock()
+
+	b.renderer.start()
+}
+
+// Add add given amount of work to current progress
+func (b *Bar) Add(n int64) {
+	if b == nil {
+		return
+	}
+
+	atomic.AddInt64(&b.current, n)
+}
+
+// SetCurrent set current progress
+func (b *Bar) SetCurrent(n int64) {
+	if b == nil {
+		return
+	}
+
+	atomic.StoreInt64(&b.current, n)
+}
+
+// SetTotal set expected amount of work
+func (b *Bar) SetTotal(n int64) {
+	if b == nil {
+		return
+	}
+
+	b.init()
+
+	b.mu.Lock()
+	b.Total = n
+	b.mu.Unlock()
+}
+
+// SetName set progress name
+func (b *Bar) SetName(name string) {
+	if b == nil {
+		return
+	}
+
+	b.init()
+
+	b.mu.Lock()
+	b.Name = name
+	b.mu.Unlock()
+}
+
+// Current return current progress
+func (b *Bar) Current() int64 {
+	if b == nil {
+		return 0
+	}
+
+	return atomic.LoadInt64(&b.current)
+}
+
+// Finish stop progress rendering
+func (b *Bar) Finish() {
+	if b == nil {
+		return
+	}
+
+	b.init()
+
+	b.mu.Lock()
+
+	if b.started.IsZero() || !b.finished.IsZero() {
+		b.mu.Unlock()
+		return
+	}
+
+	b.finished = time.Now()
+	r := b.renderer
+
+	b.mu.Unlock()
+
+	if r != nil {
+		r.finish()
+	}
+}
+
+// Reader return reader which adds size of read data to progress
+func (b *Bar) Reader(r io.Reader) io.Reader {
+	return &barReader{r, b}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Add add progress bars to group
+func (g *Group) Add(bars ...*Bar) *Group {
+	if g == nil {
+		return nil
+	}
+
+	for _, b := range bars {
+		if b == nil {
+			continue
+		}
+
+		b.init()
+		b.grouped = true
+		g.bars = append(g.bars, b)
+	}
+
+	return g
+}
+
+// Start start rendering of all progress bars in group
+func (g *Group) Start() {
+	if g == nil || g.renderer != nil {
+		return
+	}
+
+	var widgets []widget
+
+	for _, b := range g.bars {
+		b.Start()
+		widgets = append(widgets, b)
+	}
+
+	g.renderer = newRenderer(g.Output, widgets...)
+	g.renderer.start()
+}
+
+// Finish finish all progress bars in group and stop rendering
+func (g *Group) Finish() {
+	if g == nil || g.renderer == nil {
+		return
+	}
+
+	for _, b := range g.bars {
+		b.Finish()
+	}
+
+	g.renderer.finish()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// barReader is reader which updates progress bar
+type barReader struct {
+	r   io.Reader
+	bar *Bar
+}
+
+// Read read data and add size of read data to progress
+func (r *barReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.bar.Add(int64(n))
+	return n, err
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// init initialize bar created without New
+func (b *Bar) init() {
+	if b.mu == nil {
+		b.mu = &sync.Mutex{}
+	}
+}
+
+// getState return bar data used for rendering
+func (b *Bar) getState(now time.Time) barState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := barState{
+		name:    b.Name,
+		total:   b.Total,
+		width:   b.Width,
+		started: b.started,
+		end:     now,
+	}
+
+	if !b.finished.IsZero() {
+		state.end, state.finished = b.finished, true
+	}
+
+	return state
+}
+
+// render return bar line for terminal
+func (b *Bar) render(width int, now time.Time) string {
+	state := b.getState(now)
+	current := b.Current()
+
+	var info []string
+
+	if state.total > 0 {
+		info = append(info, fmt.Sprintf("%3d%%", getPercent(current, state.total)))
+	}
+
+	info = append(info, b.formatProgress(current, state))
+
+	if b.ShowSpeed {
+		info = append(info, b.formatSpeed(current, state))
+	}
+
+	if b.ShowETA && !state.finished {
+		if eta := b.formatETA(current, state); eta != "" {
+			info = append(info, "ETA: "+eta)
+		}
+	}
+
+	if state.total <= 0 {
+		info = fitInfo(info, width)
+		infoText := strings.Join(info, "  ")
+
+		return fitName(state.name, width-strutil.Width(infoText)) + infoText
+	}
+
+	minBarWidth := _MIN_BAR_WIDTH
+
+	if state.width > 0 {
+		minBarWidth = state.width
+	}
+
+	// Line must fit terminal width, otherwise it will be wrapped and redraw
+	// of several lines will be broken, so we drop optional info fields and
+	// shorten name if there is not enough space
+	info = fitInfo(info, width-minBarWidth-2)
+	infoText := strings.Join(info, "  ")
+	name := fitName(state.name, width-minBarWidth-2-strutil.Width(infoText))
+	freeSpace := width - strutil.Width(name) - strutil.Width(infoText) - 2
+
+	barWidth := state.width
+
+	if barWidth <= 0 {
+		barWidth = mathutil.Between(freeSpace, _MIN_BAR_WIDTH, _MAX_BAR_WIDTH)
+	}
+
+	// Bar can be narrower than minimal width only in a very narrow terminal
+	barWidth = mathutil.Min(barWidth, freeSpace)
+
+	if barWidth <= 0 {
+		return name + infoText
+	}
+
+	return name + renderBar(current, state.total, barWidth) + "  " + infoText
+}
+
+// renderPlain return progress line for output which isn't a terminal
+func (b *Bar) renderPlain(now time.Time) string {
+	state := b.getState(now)
+	current := b.Current()
+
+	var info []string
+
+	info = append(info, b.formatProgress(current, state))
+
+	if b.ShowSpeed {
+		info = append(info, b.formatSpeed(current, state))
+	}
+
+	if b.ShowETA && !state.finished {
+		if eta := b.formatETA(current, state); eta != "" {
+			info = append(info, "ETA: "+eta)
+		}
+	}
+
+	var result string
+
+	if state.name != "" {
+		result = state.name + ": "
+	}
+
+	if state.total > 0 {
+		result += fmt.Sprintf("%d%% ", getPercent(current, state.total))
+	}
+
+	return result + "(" + strings.Join(info, ", ") + ")"
+}
+
+// formatProgress return formatted current progress
+func (b *Bar) formatProgress(current int64, state barState) string {
+	if state.total <= 0 {
+		return b.formatValue(current)
+	}
+
+	return b.formatValue(current) + "/" + b.formatValue(state.total)
+}
+
+// formatSpeed return formatted throughput
+func (b *Bar) formatSpeed(current int64, state barState) string {
+	return b.formatValue(int64(getSpeed(current, state.started, state.end))) + "/s"
+}
+
+// formatETA return formatted estimated time of arrival
+func (b *Bar) formatETA(current int64, state barState) string {
+	speed := getSpeed(current, state.started, state.end)
+
+	if state.total <= 0 || speed <= 0 || current >= state.total {
+		return ""
+	}
+
+	eta := time.Duration(float64(state.total-current) / speed * float64(time.Second))
+
+	return timeutil.PrettyDuration(eta)
+}
+
+// formatValue format progress value
+func (b *Bar) formatValue(value int64) string {
+	if b.IsSize {
+		return fmtutil.PrettySize(value)
+	}
+
+	return fmtutil.PrettyNum(value)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// newRenderer create new renderer for given widgets
+func newRenderer(output io.Writer, widgets ...widget) *renderer {
+	if output == nil {
+		output = os.Stdout
+	}
+
+	return &renderer{
+		widgets: widgets,
+		output:  output,
+		plain:   make([]string, len(widgets)),
+	}
+}
+
+// start render widgets and start rendering loop
+func (r *renderer) start() {
+	r.tty = isTerminal(r.output)
+	r.stop, r.done = make(chan bool), make(chan bool)
+
+	// Subscription keeps actual window size, so we don't need to read it
+	// on every redraw
+	if r.tty {
+		r.resize = subscribeResize()
+	}
+
+	r.render(time.Now())
+
+	interval := PlainUpdateInterval
+
+	if r.tty {
+		interval = UpdateInterval
+	}
+
+	go r.loop(interval)
+}
+
+// finish stop rendering loop and render final state of widgets
+func (r *renderer) finish() {
+	if r.stop == nil {
+		return
+	}
+
+	close(r.stop)
+	<-r.done
+
+	r.stop = nil
+	r.render(time.Now())
+
+	if r.tty {
+		fmtc.Fprint(r.output, "\n")
+	}
+
+	if r.resize != nil {
+		r.resize.Unsubscribe()
+		r.resize = nil
+	}
+}
+
+// loop render widgets with given interval until renderer is stopped
+func (r *renderer) loop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	defer ticker.Stop()
+	defer close(r.done)
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case now := <-ticker.C:
+			r.render(now)
+		}
+	}
+}
+
+// render render all widgets
+func (r *renderer) render(now time.Time) {
+	var buf bytes.Buffer
+
+	if !r.tty {
+		for index, w := range r.widgets {
+			line := w.renderPlain(now)
+
+			if line != r.plain[index] {
+				buf.WriteString(line + "\n")
+				r.plain[index] = line
+			}
+		}
+
+		fmtc.Fprint(r.output, buf.String())
+
+		return
+	}
+
+	width := _DEFAULT_WIDTH
+
+	if r.resize != nil {
+		width = r.resize.Size().Width
+	}
+
+	buf.WriteString("\r")
+
+	if r.lines > 1 {
+		buf.WriteString(fmt.Sprintf(_CODE_MOVE_UP, r.lines-1))
+	}
+
+	for index, w := range r.widgets {
+		if index != 0 {
+			buf.WriteString("\n")
+		}
+
+		buf.WriteString(w.render(width-1, now) + "{!}" + _CODE_CLEAR_LINE)
+	}
+
+	r.lines = len(r.widgets)
+
+	fmtc.Fprint(r.output, buf.String())
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// renderBar render bar with given width
+func renderBar(current, total int64, width int) string {
+	full := int(float64(width) * float64(getPercent(current, total)) / 100)
+
+	return BarColorTag + strings.Repeat(BarFullSymbol, full) + "{!}" +
+		"{?hint}" + strings.Repeat(BarEmptySymbol, width-full) + "{!}"
+}
+
+// getPercent return progress in percents
+func getPercent(current, total int64) int {
+	if total <= 0 || current <= 0 {
+		return 0
+	}
+
+	if current >= total {
+		return 100
+	}
+
+	return int(current * 100 / total)
+}
+
+// getSpeed return throughput per second
+func getSpeed(current int64, started, end time.Time) float64 {
+	duration := end.Sub(started).Seconds()
+
+	if started.IsZero() || duration <= 0 {
+		return 0
+	}
+
+	return float64(current) / duration
+}
+
+// fitInfo drop info fields from the end until they fit given width, the
+// first field is shortened if it doesn't fit
+func fitInfo(info []string, width int) []string {
+	for len(info) > 1 && strutil.Width(strings.Join(info, "  ")) > width {
+		info = info[:len(info)-1]
+	}
+
+	if len(info) == 1 {
+		info[0] = strutil.HeadWidth(info[0], width)
+	}
+
+	return info
+}
+
+// fitName return name with trailing space shortened to given width (empty
+// string if there is no space for name)
+func fitName(name string, width int) string {
+	if name == "" || width < 2 {
+		return ""
+	}
+
+	return strutil.EllipsisWidth(name, width-1) + " "
+}
+
+// getTextWidth return text width without color tags
+func getTextWidth(text string) int {
+	return strutil.Width(fmtc.Clean(text))
+}
@@ -0,0 +1,342 @@
+package progress
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	. "pkg.re/check.v1"
+
+	"pkg.re/essentialkaos/ek.v9/fmtc"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func Test(t *testing.T) { TestingT(t) }
+
+type ProgressSuite struct{}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var _ = Suite(&ProgressSuite{})
+
+func (s *ProgressSuite) SetUpSuite(c *C) {
+	UpdateInterval = 5 * time.Millisecond
+	PlainUpdateInterval = 5 * time.Millisecond
+}
+
+func (s *ProgressSuite) TearDownTest(c *C) {
+	isTerminal = fmtc.IsTerminal
+}
+
+func (s *ProgressSuite) TestBarRender(c *C) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	bar := New(1000, "Test")
+	bar.started = start
+	bar.SetCurrent(250)
+
+	line := fmtc.Clean(bar.render(80, start.Add(5*time.Second)))
+
+	c.Assert(line, Equals, "Test "+strings.Repeat(BarFullSymbol, 8)+strings.Repeat(BarEmptySymbol, 27)+"   25%  250/1,000  50/s  ETA: 15 seconds")
+	c.Assert(getTextWidth(line), Equals, 80)
+
+	bar.Width = 10
+	bar.IsSize = true
+	bar.ShowETA = false
+	bar.Total = 4 * 1024 * 1024
+	bar.SetCurrent(1024 * 1024)
+
+	line = fmtc.Clean(bar.render(80, start.Add(time.Second)))
+
+	c.Assert(line, Equals, "Test "+strings.Repeat(BarFullSymbol, 2)+strings.Repeat(BarEmptySymbol, 8)+"   25%  1MB/4MB  1MB/s")
+
+	bar.Name = ""
+	bar.Total = 0
+	bar.ShowSpeed = false
+
+	c.Assert(fmtc.Clean(bar.render(80, start.Add(time.Second))), Equals, "1MB")
+
+	bar = New(100, "Test")
+	bar.started = start
+	bar.finished = start.Add(10 * time.Second)
+	bar.SetCurrent(100)
+
+	line = fmtc.Clean(bar.render(200, start.Add(time.Hour)))
+
+	c.Assert(strings.HasPrefix(line, "Test "+strings.Repeat(BarFullSymbol, _MAX_BAR_WIDTH)+" "), Equals, true)
+	c.Assert(strings.HasSuffix(line, " 100%  100/100  10/s"), Equals, true)
+
+	line = fmtc.Clean(bar.render(20, start.Add(time.Hour)))
+
+	c.Assert(line, Equals, "Tes "+strings.Repeat(BarFullSymbol, _MIN_BAR_WIDTH)+"  100%")
+}
+
+func (s *ProgressSuite) TestBarRenderNarrow(c *C) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	name := "/var/lib/data/very/long/path/to/some/file/with/long/name.tar.gz"
+
+	bar := New(1000, name)
+	bar.started = start
+	bar.ShowSpeed = true
+	bar.ShowETA = true
+	bar.SetCurrent(250)
+
+	for _, width := range []int{5, 10, 20, 40, 60, 79} {
+		line := fmtc.Clean(bar.render(width, start.Add(5*time.Second)))
+		c.Assert(getTextWidth(line) <= width, Equals, true, Commentf("Width: %d, Line: %q", width, line))
+	}
+
+	line := fmtc.Clean(bar.render(60, start.Add(5*time.Second)))
+
+	c.Assert(line, Equals, "/var/l... "+strings.Repeat(BarFullSymbol, 2)+strings.Repeat(BarEmptySymbol, 8)+"   25%  250/1,000  50/s  ETA: 15 seconds")
+
+	line = fmtc.Clean(bar.render(40, start.Add(5*time.Second)))
+
+	c.Assert(line, Equals, "/va... "+strings.Repeat(BarFullSymbol, 2)+strings.Repeat(BarEmptySymbol, 8)+"   25%  250/1,000  50/s")
+
+	bar.Total = 0
+
+	line = fmtc.Clean(bar.render(40, start.Add(5*time.Second)))
+
+	c.Assert(line, Equals, "/var/lib/data/very/long/pat... 250  50/s")
+
+	sp := NewSpinner(name)
+	sp.started = start
+
+	line = fmtc.Clean(sp.render(20, start))
+
+	c.Assert(line, Equals, SpinnerFrames[0]+" /var/lib/data/v...")
+	c.Assert(getTextWidth(line), Equals, 20)
+}
+
+func (s *ProgressSuite) TestBarRenderPlain(c *C) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	bar := New(1000, "Test")
+	bar.started = start
+	bar.SetCurrent(250)
+
+	c.Assert(fmtc.Clean(bar.renderPlain(start.Add(5*time.Second))), Equals, "Test: 25% (250/1,000, 50/s, ETA: 15 seconds)")
+
+	bar.finished = start.Add(10 * time.Second)
+	bar.SetCurrent(1000)
+
+	c.Assert(fmtc.Clean(bar.renderPlain(start.Add(time.Hour))), Equals, "Test: 100% (1,000/1,000, 100/s)")
+
+	bar = New(0, "")
+	bar.started = start
+	bar.SetCurrent(10)
+
+	c.Assert(fmtc.Clean(bar.renderPlain(start)), Equals, "(10, 0/s)")
+}
+
+func (s *ProgressSuite) TestBarPlainOutput(c *C) {
+	// Disable periodic rendering, widgets are rendered manually
+	PlainUpdateInterval = time.Hour
+	defer func() { PlainUpdateInterval = 5 * time.Millisecond }()
+
+	buf := &bytes.Buffer{}
+
+	bar := New(3, "Test")
+	bar.Output = buf
+	bar.ShowSpeed, bar.ShowETA = false, false
+
+	bar.Start()
+	bar.Start()
+
+	c.Assert(buf.String(), Equals, "Test: 0% (0/3)\n")
+
+	bar.Add(1)
+	bar.renderer.render(time.Now())
+	bar.renderer.render(time.Now())
+	bar.Add(2)
+
+	bar.Finish()
+	bar.Finish()
+
+	c.Assert(buf.String(), Equals, "Test: 0% (0/3)\nTest: 33% (1/3)\nTest: 100% (3/3)\n")
+	c.Assert(bar.Current(), Equals, int64(3))
+}
+
+func (s *ProgressSuite) TestBarSetters(c *C) {
+	isTerminal = func(w io.Writer) bool { return true }
+
+	buf := &bytes.Buffer{}
+
+	bar := New(0, "Test")
+	bar.Output = buf
+	bar.ShowSpeed, bar.ShowETA = false, false
+
+	bar.Start()
+
+	for i := 0; i < 10; i++ {
+		bar.SetTotal(int64(i + 100))
+		bar.SetName("Download")
+		time.Sleep(time.Millisecond)
+	}
+
+	bar.SetCurrent(50)
+	bar.Finish()
+
+	c.Assert(bar.Total, Equals, int64(109))
+	c.Assert(bar.Name, Equals, "Download")
+	c.Assert(strings.HasSuffix(fmtc.Clean(buf.String()), "  45%  50/109\x1b[K\n"), Equals, true)
+	c.Assert(bar.renderer.resize, IsNil)
+
+	bar = &Bar{}
+	bar.SetTotal(10)
+	bar.SetName("Test")
+
+	c.Assert(bar.Total, Equals, int64(10))
+	c.Assert(bar.Name, Equals, "Test")
+}
+
+func (s *ProgressSuite) TestBarTerminalOutput(c *C) {
+	isTerminal = func(w io.Writer) bool { return true }
+
+	buf := &bytes.Buffer{}
+
+	bar := New(0, "Test")
+	bar.Output = buf
+	bar.ShowSpeed = false
+
+	bar.Start()
+	bar.Add(10)
+	bar.Finish()
+
+	c.Assert(strings.HasPrefix(buf.String(), "\rTest 0\x1b[K"), Equals, true)
+	c.Assert(strings.HasSuffix(buf.String(), "\rTest 10\x1b[K\n"), Equals, true)
+}
+
+func (s *ProgressSuite) TestGroup(c *C) {
+	isTerminal = func(w io.Writer) bool { return true }
+
+	buf := &bytes.Buffer{}
+
+	bar1, bar2 := New(0, "A"), New(0, "B")
+	bar1.ShowSpeed, bar2.ShowSpeed = false, false
+
+	g := NewGroup(bar1, nil).Add(bar2)
+	g.Output = buf
+
+	g.Start()
+	g.Start()
+
+	c.Assert(buf.String(), Equals, "\rA 0\x1b[K\nB 0\x1b[K")
+
+	buf.Reset()
+	bar1.Add(1)
+	bar2.Add(2)
+
+	g.Finish()
+	g.Finish()
+
+	c.Assert(strings.HasSuffix(buf.String(), "\r\x1b[1AA 1\x1b[K\nB 2\x1b[K\n"), Equals, true)
+
+	isTerminal = func(w io.Writer) bool { return false }
+
+	buf = &bytes.Buffer{}
+	bar1, bar2 = New(0, "A"), New(0, "B")
+	bar1.ShowSpeed, bar2.ShowSpeed = false, false
+
+	g = NewGroup(bar1, bar2)
+	g.Output = buf
+
+	g.Start()
+	bar2.Add(1)
+	g.Finish()
+
+	c.Assert(buf.String(), Equals, "A: (0)\nB: (0)\nB: (1)\n")
+}
+
+func (s *ProgressSuite) TestReader(c *C) {
+	bar := New(5, "Test")
+
+	data, err := ioutil.ReadAll(bar.Reader(strings.NewReader("ABCDE")))
+
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "ABCDE")
+	c.Assert(bar.Current(), Equals, int64(5))
+}
+
+func (s *ProgressSuite) TestSpinner(c *C) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	sp := NewSpinner("Test")
+	sp.started = start
+
+	c.Assert(fmtc.Clean(sp.render(80, start)), Equals, SpinnerFrames[0]+" Test")
+	c.Assert(fmtc.Clean(sp.render(80, start.Add(SpinnerInterval*3))), Equals, SpinnerFrames[3]+" Test")
+	c.Assert(fmtc.Clean(sp.renderPlain(start)), Equals, "Test...")
+
+	sp.Update("Test 2")
+	sp.status = _SPINNER_OK
+
+	c.Assert(fmtc.Clean(sp.render(80, start)), Equals, "✔ Test 2")
+	c.Assert(fmtc.Clean(sp.renderPlain(start)), Equals, "Test 2: OK")
+
+	sp.status = _SPINNER_ERROR
+
+	c.Assert(fmtc.Clean(sp.render(80, start)), Equals, "✖ Test 2")
+	c.Assert(fmtc.Clean(sp.renderPlain(start)), Equals, "Test 2: ERROR")
+
+	buf := &bytes.Buffer{}
+
+	sp = NewSpinner("Test")
+	sp.Output = buf
+
+	sp.Done(true)
+	sp.Start()
+	sp.Start()
+	sp.Done(false)
+	sp.Done(true)
+
+	c.Assert(buf.String(), Equals, "Test...\nTest: ERROR\n")
+}
+
+func (s *ProgressSuite) TestNil(c *C) {
+	var bar *Bar
+	var g *Group
+	var sp *Spinner
+
+	bar.Start()
+	bar.Add(1)
+	bar.SetCurrent(1)
+	bar.SetTotal(1)
+	bar.SetName("")
+	bar.Finish()
+
+	c.Assert(bar.Current(), Equals, int64(0))
+
+	g.Start()
+	g.Finish()
+
+	c.Assert(g.Add(New(1, "")), IsNil)
+
+	sp.Start()
+	sp.Update("")
+	sp.Done(true)
+
+	(&Spinner{}).Start()
+	(&Bar{}).Finish()
+}
+
+func (s *ProgressSuite) TestHelpers(c *C) {
+	c.Assert(getPercent(0, 0), Equals, 0)
+	c.Assert(getPercent(-1, 10), Equals, 0)
+	c.Assert(getPercent(20, 10), Equals, 100)
+	c.Assert(getPercent(5, 10), Equals, 50)
+
+	c.Assert(getSpeed(10, time.Time{}, time.Now()), Equals, 0.0)
+}
@@ -0,0 +1,161 @@
+package progress
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"pkg.re/essentialkaos/ek.v9/strutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	_SPINNER_RUNNING int8 = 0
+	_SPINNER_OK           = 1
+	_SPINNER_ERROR        = 2
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Spinner is spinner for work with unknown length
+type Spinner struct {
+	Output io.Writer // Output is writer for spinner output (os.Stdout by default)
+
+	message  string
+	status   int8
+	started  time.Time
+	renderer *renderer
+	mu       *sync.Mutex
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// SpinnerFrames contains spinner animation frames
+var SpinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// SpinnerInterval is interval between spinner animation frames
+var SpinnerInterval = 100 * time.Millisecond
+
+// SpinnerOKSymbol is symbol printed if work successfully done
+var SpinnerOKSymbol = "{?success}✔{!}"
+
+// SpinnerErrorSymbol is symbol printed if work failed
+var SpinnerErrorSymbol = "{?error}✖{!}"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// NewSpinner create new spinner with given message
+func NewSpinner(message string) *Spinner {
+	return &Spinner{message: message, mu: &sync.Mutex{}}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Start start spinner rendering
+func (s *Spinner) Start() {
+	if s == nil || s.mu == nil {
+		return
+	}
+
+	s.mu.Lock()
+
+	if s.renderer != nil {
+		s.mu.Unlock()
+		return
+	}
+
+	s.started = time.Now()
+	s.renderer = newRenderer(s.Output, s)
+
+	s.mu.Unlock()
+
+	s.renderer.start()
+}
+
+// Update change spinner message
+func (s *Spinner) Update(message string) {
+	if s == nil || s.mu == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.message = message
+	s.mu.Unlock()
+}
+
+// Done stop spinner and print message with status of work
+func (s *Spinner) Done(ok bool) {
+	if s == nil || s.mu == nil {
+		return
+	}
+
+	s.mu.Lock()
+
+	if s.renderer == nil || s.status != _SPINNER_RUNNING {
+		s.mu.Unlock()
+		return
+	}
+
+	if ok {
+		s.status = _SPINNER_OK
+	} else {
+		s.status = _SPINNER_ERROR
+	}
+
+	s.mu.Unlock()
+
+	s.renderer.finish()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// render return spinner line for terminal
+func (s *Spinner) render(width int, now time.Time) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch s.status {
+	case _SPINNER_OK:
+		return SpinnerOKSymbol + " " + fitMessage(s.message, SpinnerOKSymbol, width)
+	case _SPINNER_ERROR:
+		return SpinnerErrorSymbol + " " + fitMessage(s.message, SpinnerErrorSymbol, width)
+	}
+
+	if len(SpinnerFrames) == 0 || SpinnerInterval <= 0 {
+		return strutil.EllipsisWidth(s.message, width)
+	}
+
+	frame := SpinnerFrames[int(now.Sub(s.started)/SpinnerInterval)%len(SpinnerFrames)]
+
+	return "{?hint}" + frame + "{!} " + fitMessage(s.message, frame, width)
+}
+
+// renderPlain return spinner line for output which isn't a terminal
+func (s *Spinner) renderPlain(now time.Time) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch s.status {
+	case _SPINNER_OK:
+		return s.message + ": {?success}OK{!}"
+	case _SPINNER_ERROR:
+		return s.message + ": {?error}ERROR{!}"
+	}
+
+	return s.message + "..."
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// fitMessage shorten message, so it fits given width together with symbol
+func fitMessage(message, symbol string, width int) string {
+	return strutil.EllipsisWidth(message, width-getTextWidth(symbol)-1)
+}
@@ -0,0 +1,606 @@
+// +build linux, darwin, !windows
+
+package terminal
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"pkg.re/essentialkaos/ek.v9/fmtc"
+	"pkg.re/essentialkaos/ek.v9/mathutil"
+	"pkg.re/essentialkaos/ek.v9/strutil"
+	"pkg.re/essentialkaos/ek.v9/terminal/window"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	_KEY_UNKNOWN = iota
+	_KEY_UP
+	_KEY_DOWN
+	_KEY_SPACE
+	_KEY_ALL
+	_KEY_ENTER
+	_KEY_CANCEL
+)
+
+// _MAX_SEQUENCE_SIZE is maximum size of escape sequence
+const _MAX_SEQUENCE_SIZE = 16
+
+const (
+	_CODE_CLEAR_LINE   = "\033[K"
+	_CODE_CLEAR_SCREEN = "\033[J"
+	_CODE_MOVE_UP      = "\033[%dA"
+	_CODE_HIDE_CURSOR  = "\033[?25l"
+	_CODE_SHOW_CURSOR  = "\033[?25h"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// menu contains state of interactive menu
+type menu struct {
+	options []string
+	checked []bool
+	cursor  int
+	multi   bool
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ErrEmptyOptions is returned if list of options for selection is empty
+var ErrEmptyOptions = errors.New("List of options is empty")
+
+// MenuPointer is symbol used for pointing to current option in menu
+var MenuPointer = "❯"
+
+// MenuChecked is symbol used for checked options in multi-select menu
+var MenuChecked = "[x]"
+
+// MenuUnchecked is symbol used for unchecked options in multi-select menu
+var MenuUnchecked = "[ ]"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// readerFromTTY is reader used for reading keys in interactive menus
+var readerFromTTY io.Reader = os.Stdin
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Select show menu and return index of selected option. In terminal, option can
+// be selected using arrow keys, otherwise user must enter option number or
+// name. If default index is negative, there is no default option.
+func Select(title string, options []string, defaultIndex int) (int, error) {
+	if len(options) == 0 {
+		return -1, ErrEmptyOptions
+	}
+
+	if defaultIndex >= len(options) {
+		defaultIndex = -1
+	}
+
+	if isInteractive(title) {
+		m := &menu{options: options, cursor: defaultIndex}
+
+		if m.cursor < 0 {
+			m.cursor = 0
+		}
+
+		err := runMenu(title, m)
+
+		if err != errNoRawMode {
+			if err != nil {
+				return -1, err
+			}
+
+			return m.cursor, nil
+		}
+	}
+
+	printOptions(options, nil, defaultIndex)
+
+	for {
+		input, kind, err := readUserInput(title, colorozeTitle(title), false, false)
+
+		if err != nil {
+			return -1, err
+		}
+
+		input = strings.TrimSpace(input)
+
+		if input == "" && defaultIndex >= 0 {
+			return defaultIndex, nil
+		}
+
+		index := findOption(options, input)
+
+		if index != -1 {
+			return index, nil
+		}
+
+		err = warnInvalidInput(kind, "\nPlease enter number from 1 to %d\n", len(options))
+
+		if err != nil {
+			return -1, err
+		}
+	}
+}
+
+// MultiSelect show menu with checkboxes and return indices of selected options.
+// In terminal, options can be checked using arrow keys and space, otherwise user
+// must enter numbers of options separated by commas (ranges like 2-4 are
+// supported too). Empty input means that selection isn't changed.
+func MultiSelect(title string, options []string, selected []int) ([]int, error) {
+	if len(options) == 0 {
+		return nil, ErrEmptyOptions
+	}
+
+	checked := make([]bool, len(options))
+
+	for _, index := range selected {
+		if index >= 0 && index < len(options) {
+			checked[index] = true
+		}
+	}
+
+	if isInteractive(title) {
+		m := &menu{options: options, checked: checked, multi: true}
+		err := runMenu(title, m)
+
+		if err != errNoRawMode {
+			if err != nil {
+				return nil, err
+			}
+
+			return getChecked(m.checked), nil
+		}
+	}
+
+	printOptions(options, checked, -1)
+
+	for {
+		input, kind, err := readUserInput(title, colorozeTitle(title), false, false)
+
+		if err != nil {
+			return nil, err
+		}
+
+		input = strings.TrimSpace(input)
+
+		if input == "" {
+			return getChecked(checked), nil
+		}
+
+		result, ok := parseSelection(input, len(options))
+
+		if ok {
+			return result, nil
+		}
+
+		err = warnInvalidInput(
+			kind,
+			"\nPlease enter numbers from 1 to %d separated by commas (e.g. 1,3-4)\n",
+			len(options),
+		)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ReadNumber read integer number in given range. If default value is defined,
+// it will be used if user enters empty value.
+func ReadNumber(title string, min, max int, defaultValue ...int) (int, error) {
+	var prompt string
+
+	if len(defaultValue) != 0 {
+		prompt = fmt.Sprintf("%s (%d-%d) [%d]", title, min, max, defaultValue[0])
+	} else {
+		prompt = fmt.Sprintf("%s (%d-%d)", title, min, max)
+	}
+
+	for {
+		input, kind, err := readUserInput(title, colorozeTitle(prompt), false, false)
+
+		if err != nil {
+			return 0, err
+		}
+
+		input = strings.TrimSpace(input)
+
+		if input == "" && len(defaultValue) != 0 {
+			return defaultValue[0], nil
+		}
+
+		num, err := strconv.Atoi(input)
+
+		if err == nil && num >= min && num <= max {
+			return num, nil
+		}
+
+		err = warnInvalidInput(kind, "\nPlease enter number from %d to %d\n", min, max)
+
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// ReadValidated read user input and check it using given validator. If input
+// is not valid, error returned by validator will be shown and user will be
+// asked again.
+func ReadValidated(title string, nonEmpty bool, validator Validator) (string, error) {
+	for {
+		input, kind, err := readUserInput(title, colorozeTitle(title), nonEmpty, false)
+
+		if err != nil {
+			return "", err
+		}
+
+		if validator == nil {
+			return input, nil
+		}
+
+		err = validator(input)
+
+		if err == nil {
+			return input, nil
+		}
+
+		err = warnInvalidInput(kind, "\n"+err.Error()+"\n")
+
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// handleKey change menu state and return true if selection is done
+func (m *menu) handleKey(key int) bool {
+	switch key {
+	case _KEY_UP:
+		m.cursor = (m.cursor - 1 + len(m.options)) % len(m.options)
+	case _KEY_DOWN:
+		m.cursor = (m.cursor + 1) % len(m.options)
+	case _KEY_SPACE:
+		if m.multi {
+			m.checked[m.cursor] = !m.checked[m.cursor]
+		}
+	case _KEY_ALL:
+		if m.multi {
+			state := len(getChecked(m.checked)) != len(m.checked)
+
+			for index := range m.checked {
+				m.checked[index] = state
+			}
+		}
+	case _KEY_ENTER:
+		return true
+	}
+
+	return false
+}
+
+// render return menu lines. Every option takes exactly one line not wider than
+// given width (0 means no limit), otherwise menu can't be cleared properly.
+func (m *menu) render(width int) []string {
+	var result []string
+
+	for index, option := range m.options {
+		var line string
+
+		if m.multi {
+			if m.checked[index] {
+				line = MenuChecked + " "
+			} else {
+				line = MenuUnchecked + " "
+			}
+		}
+
+		if index == m.cursor {
+			line = MenuPointer + " " + line
+		} else {
+			line = strings.Repeat(" ", strutil.Width(MenuPointer)+1) + line
+		}
+
+		optionWidth := 0
+
+		if width > 0 {
+			optionWidth = mathutil.Max(width-strutil.Width(line), 1)
+		}
+
+		line += formatOption(option, optionWidth)
+
+		if index == m.cursor {
+			line = "{c*}" + line + "{!}"
+		}
+
+		result = append(result, line)
+	}
+
+	return result
+}
+
+// getSummary return text with selected options
+func (m *menu) getSummary() string {
+	if !m.multi {
+		return m.options[m.cursor]
+	}
+
+	var result []string
+
+	for _, index := range getChecked(m.checked) {
+		result = append(result, m.options[index])
+	}
+
+	return strings.Join(result, ", ")
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// isInteractive return true if default input source is used, there is no
+// preloaded answer for prompt and both input and output are terminals
+func isInteractive(title string) bool {
+	if NonInteractive {
+		return false
+	}
+
+	if _, ok := getAnswer(title); ok {
+		return false
+	}
+
+	return InputSource == Input(defaultInput) &&
+		fmtc.IsTerminal(os.Stdin) && fmtc.IsTerminal(os.Stdout)
+}
+
+// runMenu show interactive menu and process keys until selection is done
+func runMenu(title string, m *menu) error {
+	restore, err := makeRaw(os.Stdin.Fd())
+
+	if err != nil {
+		return errNoRawMode
+	}
+
+	defer restore()
+
+	hint := "↑/↓ to move, enter to select"
+
+	if m.multi {
+		hint = "↑/↓ to move, space to check, a to check all, enter to confirm"
+	}
+
+	fmtc.Printf("{c}%s{!} {?hint}(%s){!}\n", title, hint)
+	// Last column isn't used, because some terminals wrap line right after
+	// writing symbol to it
+	width := mathutil.Max(window.GetWidth()-1, 0)
+
+	fmtc.Fprint(os.Stdout, _CODE_HIDE_CURSOR+strings.Join(m.render(width), "\n"))
+
+	defer fmtc.Fprint(os.Stdout, _CODE_SHOW_CURSOR)
+
+	lines := len(m.options)
+	keys := bufio.NewReader(readerFromTTY)
+
+	for {
+		key, err := readKey(keys)
+
+		if err == nil && key == _KEY_CANCEL {
+			err = ErrKillSignal
+		}
+
+		if err != nil {
+			clearMenu(lines)
+			return err
+		}
+
+		done := m.handleKey(key)
+
+		clearMenu(lines)
+
+		if done {
+			fmtc.Printf(_CODE_MOVE_UP+"\r"+_CODE_CLEAR_LINE, 1)
+			fmtc.Printf("{c}%s:{!} %s\n", title, m.getSummary())
+			return nil
+		}
+
+		fmtc.Fprint(os.Stdout, strings.Join(m.render(width), "\n"))
+	}
+}
+
+// formatOption return option text without color tags and line breaks which
+// fits given width (0 or less means no limit)
+func formatOption(option string, width int) string {
+	option = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+
+		return r
+	}, fmtc.Clean(option))
+
+	if width <= 0 {
+		return option
+	}
+
+	return strutil.EllipsisWidth(option, width)
+}
+
+// clearMenu move cursor to the beginning of menu and clear it
+func clearMenu(lines int) {
+	if lines > 1 {
+		fmt.Printf(_CODE_MOVE_UP, lines-1)
+	}
+
+	fmt.Print("\r" + _CODE_CLEAR_SCREEN)
+}
+
+// readKey read key from given reader
+func readKey(r *bufio.Reader) (int, error) {
+	data, err := readKeyCodes(r)
+
+	if err != nil {
+		return _KEY_UNKNOWN, err
+	}
+
+	return parseKey(data), nil
+}
+
+// readKeyCodes read codes of one key (symbol or escape sequence) from given
+// reader. Escape sequence can be split between several reads or several
+// sequences can be read at once, so we read it byte by byte until final
+// symbol. Esc key without sequence must be followed by any other key (as in
+// linenoise).
+func readKeyCodes(r *bufio.Reader) ([]byte, error) {
+	symbol, _, err := r.ReadRune()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if symbol != '\033' {
+		return []byte(string(symbol)), nil
+	}
+
+	c, err := r.ReadByte()
+
+	if err != nil {
+		return []byte{'\033'}, nil
+	}
+
+	if c != '[' && c != 'O' {
+		r.UnreadByte()
+		return []byte{'\033'}, nil
+	}
+
+	data := []byte{'\033', c}
+
+	for len(data) < _MAX_SEQUENCE_SIZE {
+		c, err = r.ReadByte()
+
+		if err != nil {
+			return nil, err
+		}
+
+		data = append(data, c)
+
+		// Sequence ends with symbol from range @-~
+		if c >= '@' && c <= '~' {
+			break
+		}
+	}
+
+	return data, nil
+}
+
+// parseKey return key for given key codes
+func parseKey(data []byte) int {
+	switch string(data) {
+	case "\033[A", "\033OA", "k":
+		return _KEY_UP
+	case "\033[B", "\033OB", "j", "\t":
+		return _KEY_DOWN
+	case " ", "x":
+		return _KEY_SPACE
+	case "a":
+		return _KEY_ALL
+	case "\r", "\n":
+		return _KEY_ENTER
+	case "\x03", "\x04", "\033", "q":
+		return _KEY_CANCEL
+	}
+
+	return _KEY_UNKNOWN
+}
+
+// printOptions print numbered list of options
+func printOptions(options []string, checked []bool, defaultIndex int) {
+	for index, option := range options {
+		switch {
+		case checked != nil && checked[index]:
+			fmtc.Printf("{s}%2d.{!} %s %s\n", index+1, MenuChecked, option)
+		case checked != nil:
+			fmtc.Printf("{s}%2d.{!} %s %s\n", index+1, MenuUnchecked, option)
+		case index == defaultIndex:
+			fmtc.Printf("{s}%2d.{!} %s {?hint}(default){!}\n", index+1, option)
+		default:
+			fmtc.Printf("{s}%2d.{!} %s\n", index+1, option)
+		}
+	}
+}
+
+// findOption return index of option with given number or name
+func findOption(options []string, input string) int {
+	num, err := strconv.Atoi(input)
+
+	if err == nil {
+		if num >= 1 && num <= len(options) {
+			return num - 1
+		}
+
+		return -1
+	}
+
+	for index, option := range options {
+		if strings.EqualFold(option, input) {
+			return index
+		}
+	}
+
+	return -1
+}
+
+// parseSelection parse list of option numbers (e.g. "1,3-4")
+func parseSelection(input string, total int) ([]int, bool) {
+	checked := make([]bool, total)
+
+	input = strings.Replace(input, ",", " ", -1)
+
+	for _, item := range strings.Fields(input) {
+		from, to := item, item
+
+		if strings.Contains(item, "-") {
+			from, to = item[:strings.Index(item, "-")], item[strings.Index(item, "-")+1:]
+		}
+
+		start, err1 := strconv.Atoi(from)
+		end, err2 := strconv.Atoi(to)
+
+		if err1 != nil || err2 != nil || start < 1 || end > total || start > end {
+			return nil, false
+		}
+
+		for num := start; num <= end; num++ {
+			checked[num-1] = true
+		}
+	}
+
+	return getChecked(checked), true
+}
+
+// getChecked return indices of checked options
+func getChecked(checked []bool) []int {
+	result := []int{}
+
+	for index, ok := range checked {
+		if ok {
+			result = append(result, index)
+		}
+	}
+
+	return result
+}
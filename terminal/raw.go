@@ -0,0 +1,65 @@
+// +build linux darwin freebsd
+
+package terminal
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"errors"
+	"syscall"
+	"unsafe"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// errNoRawMode is returned if terminal can't be switched to raw mode
+var errNoRawMode = errors.New("Can't switch terminal to raw mode")
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// makeRaw switch terminal to raw mode and return function for restoring
+// previous state. We can't use linenoise for reading keys in menus, because
+// it provides only line editing API and doesn't expose raw mode.
+func makeRaw(fd uintptr) (func(), error) {
+	var state syscall.Termios
+
+	err := ioctlTermios(fd, _IOCTL_GET_TERMIOS, &state)
+
+	if err != nil {
+		return nil, errNoRawMode
+	}
+
+	raw := state
+	raw.Iflag &^= syscall.ICRNL | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+
+	err = ioctlTermios(fd, _IOCTL_SET_TERMIOS, &raw)
+
+	if err != nil {
+		return nil, errNoRawMode
+	}
+
+	return func() { ioctlTermios(fd, _IOCTL_SET_TERMIOS, &state) }, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func ioctlTermios(fd, request uintptr, state *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(
+		syscall.SYS_IOCTL, fd, request,
+		uintptr(unsafe.Pointer(state)),
+	)
+
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+// +build darwin freebsd
+
+package terminal
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"syscall"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	_IOCTL_GET_TERMIOS = syscall.TIOCGETA
+	_IOCTL_SET_TERMIOS = syscall.TIOCSETA
+)
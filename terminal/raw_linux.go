@@ -0,0 +1,19 @@
+package terminal
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"syscall"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	_IOCTL_GET_TERMIOS = syscall.TCGETS
+	_IOCTL_SET_TERMIOS = syscall.TCSETS
+)
@@ -0,0 +1,27 @@
+// +build !linux,!darwin,!freebsd,!windows
+
+package terminal
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"errors"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// errNoRawMode is returned if terminal can't be switched to raw mode
+var errNoRawMode = errors.New("Can't switch terminal to raw mode")
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// makeRaw always return error, because raw mode isn't supported on this platform,
+// so menus fall back to numbered options
+func makeRaw(fd uintptr) (func(), error) {
+	return nil, errNoRawMode
+}
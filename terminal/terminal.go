@@ -24,6 +24,14 @@ import (
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
+const (
+	_INPUT_USER      int8 = 0
+	_INPUT_PRELOADED      = 1
+	_INPUT_DEFAULT        = 2
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
 // ErrKillSignal is error type when user cancel input
 var ErrKillSignal = linenoise.ErrKillSignal
 
@@ -36,17 +44,38 @@ var MaskSymbol = "*"
 // MaskSymbolColorTag is fmtc color tag used for MaskSymbol output
 var MaskSymbolColorTag = ""
 
+// InputSource is source of user input used by all methods for reading input
+// (linenoise by default)
+var InputSource Input = defaultInput
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 var tmux int8
 
+// defaultInput is default linenoise based input source
+var defaultInput = &lineInput{}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// lineInput is linenoise based input source
+type lineInput struct{}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ReadLine read line using linenoise
+func (i *lineInput) ReadLine(prompt string) (string, error) {
+	return linenoise.Line(prompt)
+}
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 // ReadUI read user input
 func ReadUI(title string, nonEmpty bool) (string, error) {
-	return readUserInput(
-		colorozeTitle(title), nonEmpty, false,
+	answer, _, err := readUserInput(
+		title, colorozeTitle(title), nonEmpty, false,
 	)
+
+	return answer, err
 }
 
 // ReadAnswer read user answer for yes/no question
@@ -58,8 +87,8 @@ func ReadAnswer(title string, defaultAnswers ...string) (bool, error) {
 	}
 
 	for {
-		answer, err := readUserInput(
-			getAnswerTitle(title, defaultAnswer), false, false,
+		answer, kind, err := readUserInput(
+			title, getAnswerTitle(title, defaultAnswer), false, false,
 		)
 
 		if err != nil {
@@ -75,8 +104,12 @@ func ReadAnswer(title string, defaultAnswers ...string) (bool, error) {
 			return true, nil
 		case "N":
 			return false, nil
-		default:
-			PrintWarnMessage("\nPlease enter Y or N\n")
+		}
+
+		err = warnInvalidInput(kind, "\nPlease enter Y or N\n")
+
+		if err != nil {
+			return false, err
 		}
 	}
 }
@@ -84,39 +117,41 @@ func ReadAnswer(title string, defaultAnswers ...string) (bool, error) {
 // ReadPassword read password or some private input which will be hidden
 // after pressing Enter
 func ReadPassword(title string, nonEmpty bool) (string, error) {
-	return readUserInput(colorozeTitle(title), nonEmpty, true)
+	answer, _, err := readUserInput(title, colorozeTitle(title), nonEmpty, true)
+
+	return answer, err
 }
 
 // PrintErrorMessage print error message
 func PrintErrorMessage(message string, args ...interface{}) {
 	if len(args) == 0 {
-		fmtc.Fprintf(os.Stderr, "{r}%s{!}\n", message)
+		fmtc.Fprintf(os.Stderr, "{?error}%s{!}\n", message)
 	} else {
-		fmtc.Fprintf(os.Stderr, "{r}%s{!}\n", fmt.Sprintf(message, args...))
+		fmtc.Fprintf(os.Stderr, "{?error}%s{!}\n", fmt.Sprintf(message, args...))
 	}
 }
 
 // PrintWarnMessage print warning message
 func PrintWarnMessage(message string, args ...interface{}) {
 	if len(args) == 0 {
-		fmtc.Fprintf(os.Stderr, "{y}%s{!}\n", message)
+		fmtc.Fprintf(os.Stderr, "{?warning}%s{!}\n", message)
 	} else {
-		fmtc.Fprintf(os.Stderr, "{y}%s{!}\n", fmt.Sprintf(message, args...))
+		fmtc.Fprintf(os.Stderr, "{?warning}%s{!}\n", fmt.Sprintf(message, args...))
 	}
 }
 
 // PrintActionMessage print message about action currently in progress
 func PrintActionMessage(message string) {
-	fmtc.Printf("{*}%s:{!} ", message)
+	fmtc.Printf("{?action}%s:{!} ", message)
 }
 
 // PrintActionStatus print message with action execution status
 func PrintActionStatus(status int) {
 	switch status {
 	case 0:
-		fmtc.Println("{g}OK{!}")
+		fmtc.Println("{?success}OK{!}")
 	case 1:
-		fmtc.Println("{r}ERROR{!}")
+		fmtc.Println("{?error}ERROR{!}")
 	}
 }
 
@@ -168,21 +203,41 @@ func getAnswerTitle(title, defaultAnswer string) string {
 	}
 }
 
-func readUserInput(title string, nonEmpty bool, private bool) (string, error) {
+func readUserInput(key, title string, nonEmpty bool, private bool) (string, int8, error) {
 	if title != "" {
 		fmtc.Println(title)
 	}
 
+	answer, ok := getAnswer(key)
+
+	if ok {
+		if nonEmpty && strings.TrimSpace(answer) == "" {
+			return "", _INPUT_PRELOADED, ErrInvalidAnswer
+		}
+
+		printAnswer(answer, private)
+
+		return answer, _INPUT_PRELOADED, nil
+	}
+
+	if NonInteractive && InputSource == Input(defaultInput) {
+		if nonEmpty {
+			return "", _INPUT_DEFAULT, ErrNonInteractive
+		}
+
+		return "", _INPUT_DEFAULT, nil
+	}
+
 	var (
 		input string
 		err   error
 	)
 
 	for {
-		input, err = linenoise.Line(Prompt)
+		input, err = InputSource.ReadLine(Prompt)
 
 		if err != nil {
-			return "", err
+			return "", _INPUT_USER, err
 		}
 
 		if nonEmpty && strings.TrimSpace(input) == "" {
@@ -201,7 +256,28 @@ func readUserInput(title string, nonEmpty bool, private bool) (string, error) {
 		break
 	}
 
-	return input, err
+	return input, _INPUT_USER, err
+}
+
+func printAnswer(answer string, private bool) {
+	if private {
+		answer = strings.Repeat(MaskSymbol, utf8.RuneCountInString(answer))
+	}
+
+	fmt.Println(Prompt + answer)
+}
+
+func warnInvalidInput(kind int8, message string, args ...interface{}) error {
+	switch kind {
+	case _INPUT_PRELOADED:
+		return ErrInvalidAnswer
+	case _INPUT_DEFAULT:
+		return ErrNonInteractive
+	}
+
+	PrintWarnMessage(message, args...)
+
+	return nil
 }
 
 func colorozeTitle(title string) string {
@@ -0,0 +1,463 @@
+// +build linux, darwin, !windows
+
+package terminal
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bufio"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	. "pkg.re/check.v1"
+
+	"pkg.re/essentialkaos/ek.v9/fmtc"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func Test(t *testing.T) { TestingT(t) }
+
+type TerminalSuite struct{}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var _ = Suite(&TerminalSuite{})
+
+func (s *TerminalSuite) TearDownTest(c *C) {
+	InputSource = defaultInput
+	NonInteractive = false
+	SetAnswers(nil)
+}
+
+func (s *TerminalSuite) TestScriptedInput(c *C) {
+	input := NewScriptedInput("A")
+	input.Add("B")
+
+	c.Assert(input.Left(), Equals, 2)
+
+	line, err := input.ReadLine("> ")
+
+	c.Assert(err, IsNil)
+	c.Assert(line, Equals, "A")
+
+	line, err = input.ReadLine("> ")
+
+	c.Assert(err, IsNil)
+	c.Assert(line, Equals, "B")
+
+	_, err = input.ReadLine("> ")
+
+	c.Assert(err, Equals, ErrNoMoreInput)
+	c.Assert(input.Left(), Equals, 0)
+
+	var nilInput *ScriptedInput
+
+	_, err = nilInput.ReadLine("> ")
+
+	c.Assert(err, Equals, ErrNoMoreInput)
+	c.Assert(nilInput.Left(), Equals, 0)
+
+	nilInput.Add("A")
+}
+
+func (s *TerminalSuite) TestBasicInput(c *C) {
+	InputSource = NewScriptedInput("", "John", "maybe", "", "secret")
+
+	name, err := ReadUI("Enter name", true)
+
+	c.Assert(err, IsNil)
+	c.Assert(name, Equals, "John")
+
+	ok, err := ReadAnswer("Continue?", "Y")
+
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+
+	password, err := ReadPassword("Enter password", true)
+
+	c.Assert(err, IsNil)
+	c.Assert(password, Equals, "secret")
+
+	_, err = ReadUI("Enter name", false)
+
+	c.Assert(err, Equals, ErrNoMoreInput)
+}
+
+func (s *TerminalSuite) TestSelect(c *C) {
+	options := []string{"MySQL", "PostgreSQL", "SQLite"}
+
+	_, err := Select("Database", nil, 0)
+
+	c.Assert(err, Equals, ErrEmptyOptions)
+
+	InputSource = NewScriptedInput("", "4", "abc", "sqlite", "", "2")
+
+	index, err := Select("Database", options, -1)
+
+	c.Assert(err, IsNil)
+	c.Assert(index, Equals, 2)
+
+	index, err = Select("Database", options, 1)
+
+	c.Assert(err, IsNil)
+	c.Assert(index, Equals, 1)
+
+	index, err = Select("Database", options, 10)
+
+	c.Assert(err, IsNil)
+	c.Assert(index, Equals, 1)
+
+	_, err = Select("Database", options, 0)
+
+	c.Assert(err, Equals, ErrNoMoreInput)
+}
+
+func (s *TerminalSuite) TestMultiSelect(c *C) {
+	options := []string{"A", "B", "C", "D"}
+
+	_, err := MultiSelect("Components", nil, nil)
+
+	c.Assert(err, Equals, ErrEmptyOptions)
+
+	InputSource = NewScriptedInput("", "5", "1,x", "3-2", "1, 3-4")
+
+	selected, err := MultiSelect("Components", options, []int{1, 7})
+
+	c.Assert(err, IsNil)
+	c.Assert(selected, DeepEquals, []int{1})
+
+	selected, err = MultiSelect("Components", options, nil)
+
+	c.Assert(err, IsNil)
+	c.Assert(selected, DeepEquals, []int{0, 2, 3})
+
+	_, err = MultiSelect("Components", options, nil)
+
+	c.Assert(err, Equals, ErrNoMoreInput)
+}
+
+func (s *TerminalSuite) TestReadNumber(c *C) {
+	InputSource = NewScriptedInput("", "abc", "0", "11", "5", "")
+
+	num, err := ReadNumber("Number of workers", 1, 10)
+
+	c.Assert(err, IsNil)
+	c.Assert(num, Equals, 5)
+
+	num, err = ReadNumber("Number of workers", 1, 10, 3)
+
+	c.Assert(err, IsNil)
+	c.Assert(num, Equals, 3)
+
+	_, err = ReadNumber("Number of workers", 1, 10)
+
+	c.Assert(err, Equals, ErrNoMoreInput)
+}
+
+func (s *TerminalSuite) TestReadValidated(c *C) {
+	validator := func(input string) error {
+		if !strings.Contains(input, "@") {
+			return errors.New("Email must contain @")
+		}
+
+		return nil
+	}
+
+	InputSource = NewScriptedInput("", "john", "john@domain.com", "any")
+
+	email, err := ReadValidated("Email", true, validator)
+
+	c.Assert(err, IsNil)
+	c.Assert(email, Equals, "john@domain.com")
+
+	value, err := ReadValidated("Value", false, nil)
+
+	c.Assert(err, IsNil)
+	c.Assert(value, Equals, "any")
+
+	_, err = ReadValidated("Email", true, validator)
+
+	c.Assert(err, Equals, ErrNoMoreInput)
+}
+
+func (s *TerminalSuite) TestMenu(c *C) {
+	m := &menu{options: []string{"A", "B", "C"}}
+
+	c.Assert(m.handleKey(_KEY_UP), Equals, false)
+	c.Assert(m.cursor, Equals, 2)
+	c.Assert(m.handleKey(_KEY_DOWN), Equals, false)
+	c.Assert(m.cursor, Equals, 0)
+	c.Assert(m.handleKey(_KEY_SPACE), Equals, false)
+	c.Assert(m.handleKey(_KEY_ALL), Equals, false)
+	c.Assert(m.handleKey(_KEY_UNKNOWN), Equals, false)
+	c.Assert(m.handleKey(_KEY_DOWN), Equals, false)
+	c.Assert(m.handleKey(_KEY_ENTER), Equals, true)
+	c.Assert(m.getSummary(), Equals, "B")
+	c.Assert(fmtc.Clean(strings.Join(m.render(0), "\n")), Equals, "  A\n❯ B\n  C")
+
+	m = &menu{options: []string{"A", "B", "C"}, checked: []bool{false, true, false}, multi: true}
+
+	m.handleKey(_KEY_SPACE)
+	m.handleKey(_KEY_DOWN)
+	m.handleKey(_KEY_SPACE)
+
+	c.Assert(getChecked(m.checked), DeepEquals, []int{0})
+
+	m.handleKey(_KEY_ALL)
+
+	c.Assert(getChecked(m.checked), DeepEquals, []int{0, 1, 2})
+
+	m.handleKey(_KEY_ALL)
+
+	c.Assert(getChecked(m.checked), DeepEquals, []int{})
+
+	m.handleKey(_KEY_SPACE)
+	m.handleKey(_KEY_DOWN)
+	m.handleKey(_KEY_SPACE)
+
+	c.Assert(m.getSummary(), Equals, "B, C")
+	c.Assert(fmtc.Clean(strings.Join(m.render(0), "\n")), Equals, "  [ ] A\n  [x] B\n❯ [x] C")
+
+	m = &menu{options: []string{"{r}Red{!} {unknown}", "Multi\nline", "Very long option text"}}
+
+	c.Assert(m.render(0), DeepEquals, []string{"{c*}❯ Red {unknown}{!}", "  Multi line", "  Very long option text"})
+	c.Assert(m.render(12), DeepEquals, []string{"{c*}❯ Red {un...{!}", "  Multi line", "  Very lo..."})
+	c.Assert(m.render(2), DeepEquals, []string{"{c*}❯ R{!}", "  M", "  V"})
+}
+
+func (s *TerminalSuite) TestKeys(c *C) {
+	c.Assert(parseKey([]byte("\033[A")), Equals, _KEY_UP)
+	c.Assert(parseKey([]byte("\033OB")), Equals, _KEY_DOWN)
+	c.Assert(parseKey([]byte(" ")), Equals, _KEY_SPACE)
+	c.Assert(parseKey([]byte("a")), Equals, _KEY_ALL)
+	c.Assert(parseKey([]byte("\r")), Equals, _KEY_ENTER)
+	c.Assert(parseKey([]byte("\x03")), Equals, _KEY_CANCEL)
+	c.Assert(parseKey([]byte("\033[C")), Equals, _KEY_UNKNOWN)
+
+	key, err := readKey(bufio.NewReader(strings.NewReader("\033[B")))
+
+	c.Assert(err, IsNil)
+	c.Assert(key, Equals, _KEY_DOWN)
+
+	_, err = readKey(bufio.NewReader(strings.NewReader("")))
+
+	c.Assert(err, NotNil)
+
+	// Several keys read at once
+	r := bufio.NewReader(strings.NewReader("\033[B\033[1;5A\033OAjЖ\033\033x\033"))
+
+	for _, k := range []int{
+		_KEY_DOWN, _KEY_UNKNOWN, _KEY_UP, _KEY_DOWN, _KEY_UNKNOWN,
+		_KEY_CANCEL, _KEY_CANCEL, _KEY_SPACE, _KEY_CANCEL,
+	} {
+		key, err = readKey(r)
+
+		c.Assert(err, IsNil)
+		c.Assert(key, Equals, k)
+	}
+
+	// Escape sequence split between reads
+	r = bufio.NewReader(iotest.OneByteReader(strings.NewReader("\033[A\033[B")))
+
+	key, err = readKey(r)
+
+	c.Assert(err, IsNil)
+	c.Assert(key, Equals, _KEY_UP)
+
+	key, err = readKey(r)
+
+	c.Assert(err, IsNil)
+	c.Assert(key, Equals, _KEY_DOWN)
+
+	_, err = readKey(bufio.NewReader(strings.NewReader("\033[1;")))
+
+	c.Assert(err, NotNil)
+}
+
+func (s *TerminalSuite) TestHelpers(c *C) {
+	c.Assert(findOption([]string{"A"}, "0"), Equals, -1)
+	c.Assert(findOption([]string{"A"}, "a"), Equals, 0)
+	c.Assert(findOption([]string{"A"}, "b"), Equals, -1)
+
+	_, ok := parseSelection("1-x", 3)
+	c.Assert(ok, Equals, false)
+
+	_, err := makeRaw(^uintptr(0))
+	c.Assert(err, Equals, errNoRawMode)
+
+	c.Assert(isInteractive("Test"), Equals, false)
+}
+
+func (s *TerminalSuite) TestNonInteractive(c *C) {
+	NonInteractive = true
+
+	options := []string{"A", "B", "C"}
+
+	ok, err := ReadAnswer("Continue?", "Y")
+
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+
+	_, err = ReadAnswer("Continue?")
+
+	c.Assert(err, Equals, ErrNonInteractive)
+
+	_, err = ReadUI("Enter name", true)
+
+	c.Assert(err, Equals, ErrNonInteractive)
+
+	name, err := ReadUI("Enter name", false)
+
+	c.Assert(err, IsNil)
+	c.Assert(name, Equals, "")
+
+	index, err := Select("Choose option", options, 1)
+
+	c.Assert(err, IsNil)
+	c.Assert(index, Equals, 1)
+
+	_, err = Select("Choose option", options, -1)
+
+	c.Assert(err, Equals, ErrNonInteractive)
+
+	selected, err := MultiSelect("Choose options", options, []int{0, 2})
+
+	c.Assert(err, IsNil)
+	c.Assert(selected, DeepEquals, []int{0, 2})
+
+	num, err := ReadNumber("Enter number", 1, 10, 5)
+
+	c.Assert(err, IsNil)
+	c.Assert(num, Equals, 5)
+
+	_, err = ReadNumber("Enter number", 1, 10)
+
+	c.Assert(err, Equals, ErrNonInteractive)
+
+	_, err = ReadValidated("Enter email", false, func(input string) error {
+		return errors.New("Email is empty")
+	})
+
+	c.Assert(err, Equals, ErrNonInteractive)
+	c.Assert(isInteractive("Choose option"), Equals, false)
+
+	InputSource = NewScriptedInput("N")
+
+	ok, err = ReadAnswer("Continue?", "Y")
+
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, false)
+}
+
+func (s *TerminalSuite) TestNonInteractiveDetection(c *C) {
+	defer os.Unsetenv(ENV_NON_INTERACTIVE)
+
+	os.Setenv(ENV_NON_INTERACTIVE, "yes")
+	c.Assert(isNonInteractive(), Equals, true)
+
+	os.Setenv(ENV_NON_INTERACTIVE, "0")
+	c.Assert(isNonInteractive(), Equals, false)
+
+	os.Unsetenv(ENV_NON_INTERACTIVE)
+	c.Assert(isNonInteractive(), Equals, !fmtc.IsTerminal(os.Stdin))
+}
+
+func (s *TerminalSuite) TestNonInteractiveDevNull(c *C) {
+	devNull, err := os.Open(os.DevNull)
+
+	c.Assert(err, IsNil)
+
+	stdin := os.Stdin
+	os.Stdin = devNull
+
+	defer func() {
+		os.Stdin = stdin
+		devNull.Close()
+	}()
+
+	os.Unsetenv(ENV_NON_INTERACTIVE)
+
+	NonInteractive = isNonInteractive()
+
+	c.Assert(NonInteractive, Equals, true)
+
+	ok, err := ReadAnswer("Continue?", "Y")
+
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+}
+
+func (s *TerminalSuite) TestAnswers(c *C) {
+	NonInteractive = true
+
+	SetAnswers(map[string]string{
+		"{c}Continue?":  "n",
+		"Enter name":    "John",
+		"Password ":     "secret",
+		"Choose option": "C",
+		"Enter number":  "7",
+		"Bad answer":    "maybe",
+		"Empty answer":  "",
+	})
+
+	ok, err := ReadAnswer("Continue?", "Y")
+
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, false)
+
+	name, err := ReadUI("Enter name", true)
+
+	c.Assert(err, IsNil)
+	c.Assert(name, Equals, "John")
+
+	password, err := ReadPassword("Password", true)
+
+	c.Assert(err, IsNil)
+	c.Assert(password, Equals, "secret")
+
+	index, err := Select("Choose option", []string{"A", "B", "C"}, 0)
+
+	c.Assert(err, IsNil)
+	c.Assert(index, Equals, 2)
+
+	num, err := ReadNumber("Enter number", 1, 10)
+
+	c.Assert(err, IsNil)
+	c.Assert(num, Equals, 7)
+
+	_, err = ReadAnswer("Bad answer")
+
+	c.Assert(err, Equals, ErrInvalidAnswer)
+
+	_, err = ReadUI("Empty answer", true)
+
+	c.Assert(err, Equals, ErrInvalidAnswer)
+
+	answersFile := c.MkDir() + "/answers.json"
+	err = ioutil.WriteFile(answersFile, []byte(`{"Enter name": "Bob"}`), 0644)
+
+	c.Assert(err, IsNil)
+	c.Assert(LoadAnswers(answersFile), IsNil)
+	c.Assert(LoadAnswers(c.MkDir()+"/unknown.json"), NotNil)
+
+	name, err = ReadUI("Enter name", true)
+
+	c.Assert(err, IsNil)
+	c.Assert(name, Equals, "Bob")
+
+	SetAnswers(nil)
+
+	_, err = ReadUI("Enter name", true)
+
+	c.Assert(err, Equals, ErrNonInteractive)
+}
@@ -27,6 +27,12 @@ var MaskSymbol = "*"
 // MaskSymbolColorTag is fmtc color tag used for MaskSymbol output
 var MaskSymbolColorTag = ""
 
+// InputSource is source of user input used by all methods for reading input
+var InputSource Input
+
+// ErrEmptyOptions is returned if list of options for selection is empty
+var ErrEmptyOptions = errors.New("List of options is empty")
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 func ReadUI(title string, nonEmpty bool) (string, error) {
@@ -41,6 +47,38 @@ func ReadPassword(title string, nonEmpty bool) (string, error) {
 	return "", nil
 }
 
+func Select(title string, options []string, defaultIndex int) (int, error) {
+	if len(options) == 0 {
+		return -1, ErrEmptyOptions
+	}
+
+	if defaultIndex < 0 || defaultIndex >= len(options) {
+		return -1, ErrNonInteractive
+	}
+
+	return defaultIndex, nil
+}
+
+func MultiSelect(title string, options []string, selected []int) ([]int, error) {
+	if len(options) == 0 {
+		return nil, ErrEmptyOptions
+	}
+
+	return selected, nil
+}
+
+func ReadNumber(title string, min, max int, defaultValue ...int) (int, error) {
+	if len(defaultValue) == 0 {
+		return 0, ErrNonInteractive
+	}
+
+	return defaultValue[0], nil
+}
+
+func ReadValidated(title string, nonEmpty bool, validator Validator) (string, error) {
+	return "", nil
+}
+
 func PrintErrorMessage(message string, args ...interface{}) {
 	return
 }
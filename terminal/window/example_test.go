@@ -45,3 +45,17 @@ func ExampleGetHeight() {
 
 	fmt.Printf("Window height: %d\n", height)
 }
+
+func ExampleSubscribe() {
+	sub := Subscribe()
+
+	defer sub.Unsubscribe()
+
+	size := sub.Size()
+
+	fmt.Printf("Window size: %d x %d\n", size.Width, size.Height)
+
+	for size = range sub.C {
+		fmt.Printf("Window resized: %d x %d\n", size.Width, size.Height)
+	}
+}
@@ -0,0 +1,184 @@
+package window
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"sync"
+	"time"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Size contains window size
+type Size struct {
+	Width  int
+	Height int
+}
+
+// Subscription is subscription to window size changes
+type Subscription struct {
+	C <-chan Size // C is channel with new window sizes
+
+	ch chan Size
+}
+
+// watcher contains state of resize events watcher
+type watcher struct {
+	subs map[*Subscription]bool
+	last Size
+	stop chan bool
+	mu   *sync.Mutex
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ResizeDelay is delay used for merging series of resize events into one
+var ResizeDelay = 100 * time.Millisecond
+
+// DefaultSize is size returned by Subscription.Size if output isn't a terminal
+var DefaultSize = Size{80, 24}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// sizeFunc is function used for reading window size
+var sizeFunc = GetSize
+
+// global is global resize events watcher
+var global = &watcher{
+	subs: make(map[*Subscription]bool),
+	mu:   &sync.Mutex{},
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Subscribe create subscription to window size changes. New size will be sent to
+// subscription channel after every window resize. If subscriber doesn't read size
+// in time, only the latest size will be kept in channel. If output isn't a
+// terminal, nothing will be sent.
+func Subscribe() *Subscription {
+	ch := make(chan Size, 1)
+	sub := &Subscription{C: ch, ch: ch}
+
+	global.add(sub)
+
+	return sub
+}
+
+// Size return last known window size or default size if output isn't a terminal
+func (s *Subscription) Size() Size {
+	global.mu.Lock()
+	defer global.mu.Unlock()
+
+	if !global.last.isValid() {
+		return DefaultSize
+	}
+
+	return global.last
+}
+
+// Unsubscribe cancel subscription. Subscription channel will be closed.
+func (s *Subscription) Unsubscribe() {
+	if s == nil || s.ch == nil {
+		return
+	}
+
+	global.remove(s)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// add add subscription and start watching for resize events if required
+func (w *watcher) add(sub *Subscription) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.subs[sub] = true
+
+	if w.stop != nil {
+		return
+	}
+
+	w.last = getSize()
+	w.stop = make(chan bool)
+
+	go w.run(w.stop)
+}
+
+// remove remove subscription and stop watching for resize events if there is
+// no more subscriptions
+func (w *watcher) remove(sub *Subscription) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.subs[sub] {
+		return
+	}
+
+	delete(w.subs, sub)
+	close(sub.ch)
+
+	if len(w.subs) == 0 && w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
+
+// run process resize events until watcher is stopped
+func (w *watcher) run(stop chan bool) {
+	events := make(chan bool, 1)
+	cancel := notifyResize(events)
+
+	defer cancel()
+
+	var delay <-chan time.Time
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-events:
+			delay = time.After(ResizeDelay)
+		case <-delay:
+			delay = nil
+			w.update(getSize())
+		}
+	}
+}
+
+// update send new size to all subscribers if size was changed
+func (w *watcher) update(size Size) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !size.isValid() || size == w.last {
+		return
+	}
+
+	w.last = size
+
+	for sub := range w.subs {
+		select {
+		case <-sub.ch:
+		default:
+		}
+
+		sub.ch <- size
+	}
+}
+
+// isValid return true if size is known
+func (s Size) isValid() bool {
+	return s.Width > 0 && s.Height > 0
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func getSize() Size {
+	width, height := sizeFunc()
+	return Size{width, height}
+}
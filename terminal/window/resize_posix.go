@@ -0,0 +1,47 @@
+// +build !windows
+
+package window
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"os"
+	ossignal "os/signal"
+
+	"pkg.re/essentialkaos/ek.v9/signal"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// notifyResize send event to given channel on every WINCH signal and return
+// function for stopping notifications
+func notifyResize(events chan bool) func() {
+	c := make(chan os.Signal, 1)
+	done := make(chan bool)
+
+	ossignal.Notify(c, signal.WINCH)
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-c:
+				select {
+				case events <- true:
+				default:
+				}
+			}
+		}
+	}()
+
+	return func() {
+		ossignal.Stop(c)
+		close(done)
+	}
+}
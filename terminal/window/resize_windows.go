@@ -0,0 +1,47 @@
+// +build windows
+
+package window
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"time"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// PollInterval is interval of window size checks on systems without WINCH signal
+var PollInterval = time.Second
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// notifyResize send event to given channel periodically and return function
+// for stopping notifications
+func notifyResize(events chan bool) func() {
+	ticker := time.NewTicker(PollInterval)
+	done := make(chan bool)
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				select {
+				case events <- true:
+				default:
+				}
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
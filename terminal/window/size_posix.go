@@ -40,6 +40,8 @@ func GetSize() (int, int) {
 		return -1, -1
 	}
 
+	defer t.Close()
+
 	var sz winsize
 
 	_, _, _ = syscall.Syscall(
@@ -8,7 +8,11 @@ package window
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 import (
+	"os"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 
 	. "pkg.re/check.v1"
 )
@@ -54,3 +58,83 @@ func (s *WindowSuite) TestErrors(c *C) {
 
 	tty = "/dev/tty"
 }
+
+func (s *WindowSuite) TestResize(c *C) {
+	mu := &sync.Mutex{}
+	size := Size{100, 40}
+
+	sizeFunc = func() (int, int) {
+		mu.Lock()
+		defer mu.Unlock()
+		return size.Width, size.Height
+	}
+
+	ResizeDelay = 20 * time.Millisecond
+
+	defer func() {
+		sizeFunc = GetSize
+		ResizeDelay = 100 * time.Millisecond
+	}()
+
+	sub := Subscribe()
+
+	c.Assert(sub.Size(), DeepEquals, Size{100, 40})
+
+	mu.Lock()
+	size = Size{120, 50}
+	mu.Unlock()
+
+	for i := 0; i < 3; i++ {
+		syscall.Kill(os.Getpid(), syscall.SIGWINCH)
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	select {
+	case newSize := <-sub.C:
+		c.Assert(newSize, DeepEquals, Size{120, 50})
+	case <-time.After(time.Second):
+		c.Fatal("Resize event wasn't received")
+	}
+
+	syscall.Kill(os.Getpid(), syscall.SIGWINCH)
+	time.Sleep(60 * time.Millisecond)
+
+	select {
+	case <-sub.C:
+		c.Fatal("Event received for unchanged size")
+	default:
+	}
+
+	c.Assert(sub.Size(), DeepEquals, Size{120, 50})
+
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+
+	_, ok := <-sub.C
+
+	c.Assert(ok, Equals, false)
+
+	var nilSub *Subscription
+
+	nilSub.Unsubscribe()
+}
+
+func (s *WindowSuite) TestResizeNoTTY(c *C) {
+	sizeFunc = func() (int, int) { return -1, -1 }
+
+	defer func() { sizeFunc = GetSize }()
+
+	sub := Subscribe()
+
+	c.Assert(sub.Size(), DeepEquals, DefaultSize)
+
+	global.update(getSize())
+
+	select {
+	case <-sub.C:
+		c.Fatal("Event received for non-TTY output")
+	default:
+	}
+
+	sub.Unsubscribe()
+}
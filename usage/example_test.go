@@ -7,6 +7,12 @@ package usage
 //                                                                                    //
 // ////////////////////////////////////////////////////////////////////////////////// //
 
+import (
+	"fmt"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
 func ExampleAbout_Render() {
 	about := About{
 		App:     "MySupperApp",
@@ -17,6 +23,7 @@ func ExampleAbout_Render() {
 		Year:    2009,    // Year when company was founded
 		License: "MIT",
 		Owner:   "John Dow <john@domain.com>",
+		URL:     "https://domain.com", // Homepage URL
 	}
 
 	about.Render()
@@ -56,3 +63,26 @@ func ExampleInfo_Render() {
 	// render all data
 	info.Render()
 }
+
+func ExampleInfo_Man() {
+	info := NewInfo("myapp", "items...")
+
+	info.AddCommand("add", "Add item", "file")
+	info.AddOption("v:version", "Print version")
+
+	about := &About{App: "MySupperApp", Version: "1.0.1", Desc: "My super golang utility"}
+
+	// Man page can be saved to file and packaged with application
+	fmt.Println(info.Man(about))
+}
+
+func ExampleInfo_Markdown() {
+	info := NewInfo("myapp", "items...")
+
+	info.AddCommand("add", "Add item", "file")
+	info.AddOption("v:version", "Print version")
+
+	about := &About{App: "MySupperApp", Version: "1.0.1", Desc: "My super golang utility"}
+
+	fmt.Println(info.Markdown(about))
+}
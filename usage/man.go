@@ -0,0 +1,169 @@
+package usage
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"strings"
+
+	"pkg.re/essentialkaos/ek.v9/fmtc"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ManSection is man page section used for generated man pages
+var ManSection = "1"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Man return usage info as man page in troff format (man(7)), about info
+// can be nil
+func (info *Info) Man(about *About) string {
+	var buf bytes.Buffer
+
+	buf.WriteString(".TH " + escapeManArg(strings.ToUpper(info.name)) + " " + ManSection)
+	buf.WriteString(" \"\" \"" + escapeManArg(getVersionString(info, about)) + "\" \"User Commands\"\n")
+
+	buf.WriteString(".SH NAME\n")
+
+	if about != nil && about.Desc != "" {
+		buf.WriteString(escapeMan(info.name) + " \\- " + escapeMan(about.Desc) + "\n")
+	} else {
+		buf.WriteString(escapeMan(info.name) + "\n")
+	}
+
+	buf.WriteString(".SH SYNOPSIS\n")
+	buf.WriteString(".B " + escapeMan(info.name) + "\n")
+
+	if len(info.options) != 0 {
+		buf.WriteString("[\\fIoptions\\fR]\n")
+	}
+
+	if len(info.commands) != 0 {
+		buf.WriteString("[\\fIcommand\\fR]\n")
+	}
+
+	if info.args != "" {
+		buf.WriteString(escapeMan(info.args) + "\n")
+	}
+
+	if info.spoiler != "" {
+		buf.WriteString(".SH DESCRIPTION\n")
+		buf.WriteString(escapeMan(fmtc.Clean(info.spoiler)) + "\n")
+	}
+
+	if len(info.commands) != 0 {
+		buf.WriteString(".SH COMMANDS\n")
+		writeManEntities(&buf, info.commands)
+	}
+
+	if len(info.options) != 0 {
+		buf.WriteString(".SH OPTIONS\n")
+		writeManEntities(&buf, info.options)
+	}
+
+	if len(info.examples) != 0 {
+		buf.WriteString(".SH EXAMPLES\n")
+
+		for _, example := range info.examples {
+			buf.WriteString(".TP\n")
+			buf.WriteString(".B " + escapeMan(info.name+" "+example.cmd) + "\n")
+
+			if example.desc != "" {
+				buf.WriteString(escapeMan(fmtc.Clean(example.desc)) + "\n")
+			}
+		}
+	}
+
+	if about != nil && about.Owner != "" {
+		buf.WriteString(".SH AUTHOR\n")
+		buf.WriteString(escapeMan(about.Owner) + "\n")
+	}
+
+	if about != nil && (about.Owner != "" || about.License != "") {
+		buf.WriteString(".SH COPYRIGHT\n")
+
+		if about.Owner != "" {
+			buf.WriteString(escapeMan(getCopyright(about)) + "\n")
+		}
+
+		if about.Owner != "" && about.License != "" {
+			buf.WriteString(".br\n")
+		}
+
+		if about.License != "" {
+			buf.WriteString(escapeMan(about.License) + "\n")
+		}
+	}
+
+	return buf.String()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// writeManEntities write commands or options to buffer
+func writeManEntities(buf *bytes.Buffer, entities []*entity) {
+	var curGroup string
+
+	for index, entity := range entities {
+		if curGroup != entity.group {
+			// Default groups are used as section names
+			if index != 0 || (entity.group != "Commands" && entity.group != "Options") {
+				buf.WriteString(".SS " + escapeManArg(entity.group) + "\n")
+			}
+
+			curGroup = entity.group
+		}
+
+		buf.WriteString(".TP\n")
+
+		if entity.group == "Options" {
+			long, short := parseOptionName(entity.name)
+
+			buf.WriteString("\\fB\\-\\-" + escapeMan(long) + "\\fR")
+
+			if short != "" {
+				buf.WriteString(", \\fB\\-" + escapeMan(short) + "\\fR")
+			}
+		} else {
+			buf.WriteString("\\fB" + escapeMan(entity.name) + "\\fR")
+		}
+
+		for _, arg := range entity.args {
+			if strings.HasPrefix(arg, "?") {
+				buf.WriteString(" [\\fI" + escapeMan(arg[1:]) + "\\fR]")
+			} else {
+				buf.WriteString(" \\fI" + escapeMan(arg) + "\\fR")
+			}
+		}
+
+		buf.WriteString("\n" + escapeMan(fmtc.Clean(entity.desc)) + "\n")
+	}
+}
+
+// escapeMan escape text for using in man page
+func escapeMan(text string) string {
+	text = strings.Replace(text, "\\", "\\e", -1)
+	text = strings.Replace(text, "-", "\\-", -1)
+
+	lines := strings.Split(text, "\n")
+
+	for index, line := range lines {
+		// Lines started with control characters must be escaped
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[index] = "\\&" + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// escapeManArg escape text for using as quoted macro argument
+func escapeManArg(text string) string {
+	return strings.Replace(escapeMan(text), "\"", "\\(dq", -1)
+}
@@ -0,0 +1,138 @@
+package usage
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                     Copyright (c) 2009-2017 ESSENTIAL KAOS                         //
+//        Essential Kaos Open Source License <https://essentialkaos.com/ekol>         //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"strings"
+
+	"pkg.re/essentialkaos/ek.v9/fmtc"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Markdown return usage info as markdown document, about info can be nil
+func (info *Info) Markdown(about *About) string {
+	var buf bytes.Buffer
+
+	buf.WriteString("# " + escapeMarkdown(getVersionString(info, about)) + "\n")
+
+	if about != nil && about.Desc != "" {
+		buf.WriteString("\n" + escapeMarkdown(about.Desc) + "\n")
+	}
+
+	buf.WriteString("\n## Usage\n\n```\n" + info.name)
+
+	if len(info.options) != 0 {
+		buf.WriteString(" {options}")
+	}
+
+	if len(info.commands) != 0 {
+		buf.WriteString(" {command}")
+	}
+
+	if info.args != "" {
+		buf.WriteString(" " + info.args)
+	}
+
+	buf.WriteString("\n```\n")
+
+	if info.spoiler != "" {
+		buf.WriteString("\n" + escapeMarkdown(fmtc.Clean(info.spoiler)) + "\n")
+	}
+
+	if len(info.commands) != 0 {
+		writeMarkdownEntities(&buf, info.commands, "Command")
+	}
+
+	if len(info.options) != 0 {
+		writeMarkdownEntities(&buf, info.options, "Option")
+	}
+
+	if len(info.examples) != 0 {
+		buf.WriteString("\n## Examples\n")
+
+		for _, example := range info.examples {
+			buf.WriteString("\n```\n" + info.name + " " + example.cmd + "\n```\n")
+
+			if example.desc != "" {
+				buf.WriteString("\n" + escapeMarkdown(fmtc.Clean(example.desc)) + "\n")
+			}
+		}
+	}
+
+	if about != nil && (about.Owner != "" || about.License != "") {
+		buf.WriteString("\n## License\n\n")
+
+		if about.Owner != "" {
+			buf.WriteString(escapeMarkdown(getCopyright(about)))
+		}
+
+		if about.Owner != "" && about.License != "" {
+			buf.WriteString("  \n")
+		}
+
+		if about.License != "" {
+			buf.WriteString(escapeMarkdown(about.License))
+		}
+
+		buf.WriteString("\n")
+	}
+
+	return buf.String()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// writeMarkdownEntities write commands or options as markdown tables
+func writeMarkdownEntities(buf *bytes.Buffer, entities []*entity, column string) {
+	var curGroup string
+
+	for _, entity := range entities {
+		if curGroup != entity.group {
+			buf.WriteString("\n## " + escapeMarkdown(entity.group) + "\n\n")
+			buf.WriteString("| " + column + " | Description |\n")
+			buf.WriteString("|" + strings.Repeat("-", len(column)+2) + "|-------------|\n")
+
+			curGroup = entity.group
+		}
+
+		var name string
+
+		if entity.group == "Options" {
+			long, short := parseOptionName(entity.name)
+
+			name = "`--" + long + "`"
+
+			if short != "" {
+				name += ", `-" + short + "`"
+			}
+		} else {
+			name = "`" + entity.name + "`"
+		}
+
+		for _, arg := range entity.args {
+			if strings.HasPrefix(arg, "?") {
+				name += " _[" + escapeMarkdown(arg[1:]) + "]_"
+			} else {
+				name += " _" + escapeMarkdown(arg) + "_"
+			}
+		}
+
+		buf.WriteString("| " + name + " | " + escapeMarkdown(fmtc.Clean(entity.desc)) + " |\n")
+	}
+}
+
+// escapeMarkdown escape markdown control characters
+func escapeMarkdown(text string) string {
+	for _, s := range []string{"\\", "`", "*", "_", "|", "<", ">", "[", "]"} {
+		text = strings.Replace(text, s, "\\"+s, -1)
+	}
+
+	return text
+}
@@ -9,6 +9,7 @@ package usage
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -39,6 +40,10 @@ type About struct {
 	Year    int    // Year is year when owner company was founded
 	License string // License is name of license
 	Owner   string // Owner is name of owner (company/developer)
+	URL     string // URL is application homepage URL
+
+	// CopyrightYear is last year of copyright period (current year if not set)
+	CopyrightYear int
 
 	// Function for checking application updates
 	UpdateChecker UpdateChecker
@@ -100,8 +105,8 @@ func NewInfo(args ...string) *Info {
 		options:  make([]*entity, 0),
 		examples: make([]*example, 0),
 
-		CommandsColorTag: "{y}",
-		OptionsColorTag:  "{g}",
+		CommandsColorTag: "{?command}",
+		OptionsColorTag:  "{?option}",
 		Breadcrumbs:      true,
 	}
 
@@ -147,7 +152,7 @@ func (info *Info) AddSpoiler(spoiler string) {
 
 // Render print usage info to console
 func (info *Info) Render() {
-	usageMessage := "\n{*}Usage:{!} " + info.name
+	usageMessage := "\n{?header}Usage:{!} " + info.name
 
 	if len(info.options) != 0 {
 		usageMessage += " " + info.OptionsColorTag + "{options}{!}"
@@ -188,32 +193,28 @@ func (about *About) Render() {
 	switch {
 	case about.Build != "":
 		fmtc.Printf(
-			"\n{*c}%s {c}%s{!}{s}%s{!} {s-}(%s){!} - %s\n\n",
+			"\n{?app}%s {?version}%s{!}{s}%s{!} {?hint}(%s){!} - %s\n\n",
 			about.App, about.Version,
 			about.Release, about.Build, about.Desc,
 		)
 	default:
 		fmtc.Printf(
-			"\n{*c}%s {c}%s{!}{s}%s{!} - %s\n\n",
+			"\n{?app}%s {?version}%s{!}{s}%s{!} - %s\n\n",
 			about.App, about.Version,
 			about.Release, about.Desc,
 		)
 	}
 
 	if about.Owner != "" {
-		if about.Year == 0 {
-			fmtc.Printf(
-				"{s-}Copyright (C) %d %s{!}\n",
-				time.Now().Year(), about.Owner)
-		} else {
-			fmtc.Printf(
-				"{s-}Copyright (C) %d-%d %s{!}\n",
-				about.Year, time.Now().Year(), about.Owner)
-		}
+		fmtc.Printf("{?hint}%s{!}\n", getCopyright(about))
 	}
 
 	if about.License != "" {
-		fmtc.Printf("{s-}%s{!}\n", about.License)
+		fmtc.Printf("{?hint}%s{!}\n", about.License)
+	}
+
+	if about.URL != "" {
+		fmtc.Println("{?hint}" + fmtc.Link(about.URL, about.URL) + "{!}")
 	}
 
 	if about.UpdateChecker.CheckFunc != nil && about.UpdateChecker.Data != "" {
@@ -259,12 +260,13 @@ func appendEntity(data []string, entities *[]*entity, group string) {
 
 // formatOption format entity name
 func formatOption(entity *entity) string {
-	if strings.Contains(entity.name, ":") {
-		optionSlice := strings.Split(entity.name, ":")
-		return "--" + optionSlice[1] + ", -" + optionSlice[0]
+	long, short := parseOptionName(entity.name)
+
+	if short != "" {
+		return "--" + long + ", -" + short
 	}
 
-	return "--" + entity.name
+	return "--" + long
 }
 
 // renderEntities render entities
@@ -307,7 +309,7 @@ func renderExamples(info *Info) {
 		fmtc.Printf("  %s %s\n", info.name, example.cmd)
 
 		if example.desc != "" {
-			fmtc.Printf("  {s-}%s{!}\n", example.desc)
+			fmtc.Printf("  {?hint}%s{!}\n", example.desc)
 		}
 
 		if index < total-1 {
@@ -322,7 +324,7 @@ func renderArgs(args []string) string {
 
 	for _, a := range args {
 		if strings.HasPrefix(a, "?") {
-			result += "{s-}" + a[1:] + "{!} "
+			result += "{?hint}" + a[1:] + "{!} "
 		} else {
 			result += "{s}" + a + "{!} "
 		}
@@ -337,7 +339,7 @@ func getEntitySeparator(entity *entity, maxSize int, breadcrumbs bool) string {
 	entLen := getEntitySize(entity)
 
 	if breadcrumbs && !fmtc.DisableColors && maxSize > _BREADCRUMBS_MIN_SIZE {
-		return " {s-}" + _DOTS[:maxSize-entLen] + "{!} "
+		return " {?hint}" + _DOTS[:maxSize-entLen] + "{!} "
 	}
 
 	return " " + _SPACES[:maxSize-entLen] + " "
@@ -379,9 +381,47 @@ func getEntitySize(entity *entity) int {
 	return size
 }
 
+// parseOptionName return long and short option names
+func parseOptionName(name string) (string, string) {
+	if strings.Contains(name, ":") {
+		optionSlice := strings.Split(name, ":")
+		return optionSlice[1], optionSlice[0]
+	}
+
+	return name, ""
+}
+
+// getVersionString return application name with version
+func getVersionString(info *Info, about *About) string {
+	if about == nil || about.App == "" {
+		return info.name
+	}
+
+	if about.Version == "" {
+		return about.App
+	}
+
+	return about.App + " " + about.Version + about.Release
+}
+
+// getCopyright return copyright string
+func getCopyright(about *About) string {
+	year := about.CopyrightYear
+
+	if year == 0 {
+		year = time.Now().Year()
+	}
+
+	if about.Year == 0 {
+		return fmt.Sprintf("Copyright (C) %d %s", year, about.Owner)
+	}
+
+	return fmt.Sprintf("Copyright (C) %d-%d %s", about.Year, year, about.Owner)
+}
+
 // printGroupHeader print category header
 func printGroupHeader(name string) {
-	fmtc.Printf("\n{*}%s{!}\n\n", name)
+	fmtc.Printf("\n{?header}%s{!}\n\n", name)
 }
 
 // isNewerVersion return true if latest version is greater than current
@@ -421,9 +461,9 @@ func printNewVersionInfo(curVersion, newVersion string, releaseDate time.Time) {
 
 	switch {
 	case cv.Major() != nv.Major():
-		colorTag = "{r}"
+		colorTag = "{?error}"
 	case cv.Minor() != nv.Minor():
-		colorTag = "{y}"
+		colorTag = "{?warning}"
 	}
 
 	fmtc.NewLine()
@@ -431,10 +471,10 @@ func printNewVersionInfo(curVersion, newVersion string, releaseDate time.Time) {
 
 	switch days {
 	case 0:
-		fmtc.Println("{s-}(released today){!}")
+		fmtc.Println("{?hint}(released today){!}")
 	case 1:
-		fmtc.Println("{s-}(released 1 day ago){!}")
+		fmtc.Println("{?hint}(released 1 day ago){!}")
 	default:
-		fmtc.Printf("{s-}(released %d days ago){!}\n", days)
+		fmtc.Printf("{?hint}(released %d days ago){!}\n", days)
 	}
 }
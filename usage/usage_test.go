@@ -36,6 +36,7 @@ func (s *UsageSuite) TestAbout(c *C) {
 		Year:    2010,
 		Owner:   "Some company",
 		License: "MIT",
+		URL:     "https://domain.com",
 	}
 
 	about.Render()
@@ -103,3 +104,138 @@ func (s *UsageSuite) TestVersionInfo(c *C) {
 	printNewVersionInfo("1.0.0", "1.1.0", d2)
 	printNewVersionInfo("1.0.0", "1.0.1", d3)
 }
+
+func (s *UsageSuite) TestMan(c *C) {
+	info := NewInfo("my-app", "file...")
+
+	info.AddSpoiler("This is {g}usage{!} spoiler\n.with dot")
+
+	info.AddCommand("print", "Print command", "file", "?mode")
+
+	info.AddGroup("Extra Commands")
+
+	info.AddCommand("read", "Read \"command\"")
+
+	info.AddOption("t:test", "Test {y}option{!}", "arg")
+	info.AddOption("verbose", "Verbose output")
+
+	info.AddExample("print file.txt", "Print {g}file{!}")
+	info.AddExample("read")
+
+	about := &About{
+		App:     "MyApp",
+		Version: "1.0.0",
+		Release: "-beta1",
+		Desc:    "Test application",
+		Year:    2010,
+		Owner:   "Some company",
+		License: "MIT",
+
+		CopyrightYear: 2017,
+	}
+
+	c.Assert(info.Man(about), Equals, `.TH MY\-APP 1 "" "MyApp 1.0.0\-beta1" "User Commands"
+.SH NAME
+my\-app \- Test application
+.SH SYNOPSIS
+.B my\-app
+[\fIoptions\fR]
+[\fIcommand\fR]
+file...
+.SH DESCRIPTION
+This is usage spoiler
+\&.with dot
+.SH COMMANDS
+.TP
+\fBprint\fR \fIfile\fR [\fImode\fR]
+Print command
+.SS Extra Commands
+.TP
+\fBread\fR
+Read "command"
+.SH OPTIONS
+.TP
+\fB\-\-test\fR, \fB\-t\fR \fIarg\fR
+Test option
+.TP
+\fB\-\-verbose\fR
+Verbose output
+.SH EXAMPLES
+.TP
+.B my\-app print file.txt
+Print file
+.TP
+.B my\-app read
+.SH AUTHOR
+Some company
+.SH COPYRIGHT
+Copyright (C) 2010\-2017 Some company
+.br
+MIT
+`)
+
+	info = NewInfo("app")
+
+	c.Assert(info.Man(nil), Equals, `.TH APP 1 "" "app" "User Commands"
+.SH NAME
+app
+.SH SYNOPSIS
+.B app
+`)
+}
+
+func (s *UsageSuite) TestMarkdown(c *C) {
+	info := NewInfo("my-app", "file...")
+
+	info.AddSpoiler("This is {g}usage{!} spoiler")
+
+	info.AddCommand("print", "Print command", "file", "?mode")
+
+	info.AddGroup("Extra Commands")
+
+	info.AddCommand("read", "Read | write command")
+
+	info.AddOption("t:test", "Test {y}option{!}", "arg")
+	info.AddOption("verbose", "Verbose output")
+
+	info.AddExample("print file.txt", "Print {g}file{!}")
+	info.AddExample("read")
+
+	about := &About{
+		App:     "MyApp",
+		Version: "1.0.0",
+		Desc:    "Test application",
+		Owner:   "Some company",
+		License: "MIT",
+
+		CopyrightYear: 2017,
+	}
+
+	c.Assert(info.Markdown(about), Equals, "# MyApp 1.0.0\n"+
+		"\nTest application\n"+
+		"\n## Usage\n\n```\nmy-app {options} {command} file...\n```\n"+
+		"\nThis is usage spoiler\n"+
+		"\n## Commands\n\n"+
+		"| Command | Description |\n"+
+		"|---------|-------------|\n"+
+		"| `print` _file_ _[mode]_ | Print command |\n"+
+		"\n## Extra Commands\n\n"+
+		"| Command | Description |\n"+
+		"|---------|-------------|\n"+
+		"| `read` | Read \\| write command |\n"+
+		"\n## Options\n\n"+
+		"| Option | Description |\n"+
+		"|--------|-------------|\n"+
+		"| `--test`, `-t` _arg_ | Test option |\n"+
+		"| `--verbose` | Verbose output |\n"+
+		"\n## Examples\n"+
+		"\n```\nmy-app print file.txt\n```\n"+
+		"\nPrint file\n"+
+		"\n```\nmy-app read\n```\n"+
+		"\n## License\n\n"+
+		"Copyright (C) 2017 Some company  \nMIT\n")
+
+	info = NewInfo("app")
+
+	c.Assert(info.Markdown(nil), Equals, "# app\n\n## Usage\n\n```\napp\n```\n")
+}